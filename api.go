@@ -0,0 +1,393 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"goMH/config"
+	"goMH/core"
+	"goMH/journal"
+	"goMH/secrets"
+	"goMH/tui"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTP API для запуска модулей без консоли (например, через туннель FRPC).
+// Все запросы требуют заголовок "Authorization: Bearer <токен>".
+//
+//	GET  /api/modules              модули и их состояние (как goMH status --json)
+//	POST /api/runs                 запуск модуля: {"module": "FRPC", "answers": {"local-port": 5985}}
+//	GET  /api/runs                 последние запуски
+//	GET  /api/runs/{id}            состояние запуска
+//	GET  /api/runs/{id}/events     записи журнала запуска (NDJSON), поток до завершения
+//	POST /api/logs/collect         сбор логов в архив: {"days": 7}
+//
+// Одновременно выполняется только один запуск, остальные получают 409.
+
+const (
+	defaultListenAddr = "127.0.0.1:7700"
+	apiTokenFile      = "api.token"
+	// maxAPIRuns - сколько последних запусков хранится для /api/runs.
+	maxAPIRuns = 20
+	// maxAPIRequestBody - предельный размер тела запроса.
+	maxAPIRequestBody = 1 << 20
+)
+
+// Состояния запуска через API. Завершенные совпадают со статусами журнала.
+const (
+	runRunning   = "running"
+	runOK        = "ok"
+	runError     = "error"
+	runCancelled = "cancelled"
+)
+
+// runInfo - состояние запуска в ответах API.
+type runInfo struct {
+	ID       string     `json:"id"`
+	Module   string     `json:"module"`
+	Status   string     `json:"status"`
+	Started  time.Time  `json:"started"`
+	Finished *time.Time `json:"finished,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// apiRun - запуск модуля через API и накопленные записи его журнала.
+type apiRun struct {
+	mu     sync.Mutex
+	info   runInfo
+	events []journal.Record
+	// changed закрывается и заменяется при каждой новой записи и по завершении.
+	changed chan struct{}
+}
+
+func newAPIRun(id, module string) *apiRun {
+	return &apiRun{
+		info:    runInfo{ID: id, Module: module, Status: runRunning, Started: time.Now()},
+		changed: make(chan struct{}),
+	}
+}
+
+func (r *apiRun) add(rec journal.Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, rec)
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+func (r *apiRun) finish(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.info.Finished = &now
+	switch {
+	case core.IsCancelled(err):
+		r.info.Status = runCancelled
+		r.info.Error = err.Error()
+	case err != nil:
+		r.info.Status = runError
+		r.info.Error = tui.Mask(err.Error())
+	default:
+		r.info.Status = runOK
+	}
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+func (r *apiRun) snapshot() runInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.info
+}
+
+// eventsSince возвращает записи начиная с from, признак завершения и канал,
+// который закроется при следующем изменении.
+func (r *apiRun) eventsSince(from int) ([]journal.Record, bool, <-chan struct{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []journal.Record
+	if from < len(r.events) {
+		events = append(events, r.events[from:]...)
+	}
+	return events, r.info.Finished != nil, r.changed
+}
+
+// apiServer обслуживает HTTP API поверх App.
+type apiServer struct {
+	app   *App
+	token string
+
+	mu      sync.Mutex
+	runs    []*apiRun
+	current *apiRun
+	nextID  int
+}
+
+func (a *App) cmdServe(args []string) int {
+	params, err := parseParams(args)
+	if err != nil {
+		tui.Error(err.Error())
+		return exitUsage
+	}
+	listen := params["listen"]
+	if listen == "" || listen == "true" {
+		listen = defaultListenAddr
+	}
+	host, _, err := net.SplitHostPort(listen)
+	if err != nil {
+		tui.Error(fmt.Sprintf("Некорректный адрес --listen '%s': %v", listen, err))
+		return exitUsage
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		tui.Warn("API доступен не только с этой машины. Трафик не шифруется: используйте туннель FRPC и 127.0.0.1.")
+	}
+
+	token, source, err := a.apiToken()
+	if err != nil {
+		tui.Error(err.Error())
+		return exitError
+	}
+	// Вопросы без ответа в запросе - ошибка запуска, в консоль ничего не спрашивается
+	a.AskMissing = false
+
+	s := &apiServer{app: a, token: token}
+	server := &http.Server{Addr: listen, Handler: s.routes(), ReadHeaderTimeout: 10 * time.Second}
+	tui.SuccessF("HTTP API goMH слушает http://%s (токен: %s). Ctrl+C - остановить.", listen, source)
+	if err := server.ListenAndServe(); err != nil {
+		tui.Error(fmt.Sprintf("Ошибка HTTP-сервера: %v", err))
+		return exitError
+	}
+	return exitOK
+}
+
+// apiToken возвращает токен API из GOMH_API_TOKEN или из файла api.token в RootPath.
+// Если файла нет, создается случайный токен.
+func (a *App) apiToken() (token, source string, err error) {
+	if token := os.Getenv(secrets.EnvAPIToken); token != "" {
+		tui.AddSecret(token)
+		return token, "переменная " + secrets.EnvAPIToken, nil
+	}
+	path := filepath.Join(a.Cfg.RootPath, apiTokenFile)
+	if data, err := os.ReadFile(path); err == nil && strings.TrimSpace(string(data)) != "" {
+		token = strings.TrimSpace(string(data))
+		tui.AddSecret(token)
+		return token, path, nil
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(buf)
+	if err := os.MkdirAll(a.Cfg.RootPath, 0755); err != nil {
+		return "", "", fmt.Errorf("не удалось создать %s: %w", a.Cfg.RootPath, err)
+	}
+	if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", "", fmt.Errorf("не удалось сохранить токен API: %w", err)
+	}
+	tui.AddSecret(token)
+	return token, path + " (создан)", nil
+}
+
+func (s *apiServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/modules", s.handleModules)
+	mux.HandleFunc("GET /api/runs", s.handleRuns)
+	mux.HandleFunc("POST /api/runs", s.handleStartRun)
+	mux.HandleFunc("GET /api/runs/{id}", s.handleRun)
+	mux.HandleFunc("GET /api/runs/{id}/events", s.handleRunEvents)
+	mux.HandleFunc("POST /api/logs/collect", s.handleCollectLogs)
+	return s.authorize(mux)
+}
+
+// authorize пропускает только запросы с правильным токеном и ограничивает размер тела.
+func (s *apiServer) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeAPIError(w, http.StatusUnauthorized, "неверный или отсутствующий токен")
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxAPIRequestBody)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *apiServer) handleModules(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.app.collectStatus())
+}
+
+func (s *apiServer) handleRuns(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	runs := make([]runInfo, 0, len(s.runs))
+	for _, run := range s.runs {
+		runs = append(runs, run.snapshot())
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, runs)
+}
+
+func (s *apiServer) handleStartRun(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Module  string                 `json:"module"`
+		Answers map[string]interface{} `json:"answers"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "некорректный JSON: "+err.Error())
+		return
+	}
+	// Ответы приводятся к строкам так же, как ответы модулей в профилях
+	answers, err := config.ProfileModule{ID: req.Module, Answers: req.Answers}.Params()
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.start(w, req.Module, answers)
+}
+
+func (s *apiServer) handleCollectLogs(w http.ResponseWriter, r *http.Request) {
+	req := struct {
+		Days int `json:"days"`
+	}{Days: 1}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeAPIError(w, http.StatusBadRequest, "некорректный JSON: "+err.Error())
+			return
+		}
+	}
+	if req.Days < 1 {
+		writeAPIError(w, http.StatusBadRequest, "days должно быть положительным числом")
+		return
+	}
+	s.start(w, "ServiceUtils", map[string]string{
+		"action": "collect-logs",
+		"days":   strconv.Itoa(req.Days),
+		"dirs":   "all",
+	})
+}
+
+func (s *apiServer) handleRun(w http.ResponseWriter, r *http.Request) {
+	run := s.find(r.PathValue("id"))
+	if run == nil {
+		writeAPIError(w, http.StatusNotFound, "запуск не найден")
+		return
+	}
+	writeJSON(w, http.StatusOK, run.snapshot())
+}
+
+// handleRunEvents передает записи журнала запуска по одной в строке (NDJSON)
+// и держит соединение открытым, пока запуск не завершится.
+func (s *apiServer) handleRunEvents(w http.ResponseWriter, r *http.Request) {
+	run := s.find(r.PathValue("id"))
+	if run == nil {
+		writeAPIError(w, http.StatusNotFound, "запуск не найден")
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+
+	sent := 0
+	for {
+		events, finished, changed := run.eventsSince(sent)
+		for _, event := range events {
+			if err := encoder.Encode(event); err != nil {
+				return
+			}
+		}
+		sent += len(events)
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if finished {
+			return
+		}
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// start запускает модуль в фоне и отвечает 202 с идентификатором запуска.
+func (s *apiServer) start(w http.ResponseWriter, moduleID string, answers map[string]string) {
+	module, ok := s.app.findModule(moduleID)
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, fmt.Sprintf("модуль '%s' не найден или не включен в конфигурации", moduleID))
+		return
+	}
+
+	s.mu.Lock()
+	if s.current != nil {
+		busy := s.current.snapshot()
+		s.mu.Unlock()
+		writeAPIError(w, http.StatusConflict, fmt.Sprintf("уже выполняется запуск %s (модуль %s)", busy.ID, busy.Module))
+		return
+	}
+	s.nextID++
+	run := newAPIRun(strconv.Itoa(s.nextID), module.ID())
+	s.current = run
+	s.runs = append(s.runs, run)
+	if len(s.runs) > maxAPIRuns {
+		s.runs = s.runs[len(s.runs)-maxAPIRuns:]
+	}
+	s.mu.Unlock()
+
+	tui.Title(fmt.Sprintf("\n--- Запуск модуля %s через API (запуск %s) ---", module.ID(), run.info.ID))
+	go s.execute(run, module, answers)
+	writeJSON(w, http.StatusAccepted, run.snapshot())
+}
+
+func (s *apiServer) execute(run *apiRun, module core.Installer, answers map[string]string) {
+	var err error
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("внутренняя ошибка модуля: %v", recovered)
+		}
+		printOutcome(err)
+		run.finish(err)
+		s.mu.Lock()
+		s.current = nil
+		s.mu.Unlock()
+	}()
+
+	if s.app.Journal != nil {
+		unsubscribe := s.app.Journal.Subscribe(run.add)
+		defer unsubscribe()
+	}
+	err = s.app.runModule(module, s.app.prompterFor(module.ID(), core.Params(answers), false))
+}
+
+func (s *apiServer) find(id string) *apiRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, run := range s.runs {
+		if run.info.ID == id {
+			return run
+		}
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(value)
+}
+
+func writeAPIError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]string{"error": message})
+}
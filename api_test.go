@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"goMH/config"
+	"goMH/core"
+	"goMH/journal"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testAPIToken = "test-token"
+
+// blockingModule выполняется, пока не закрыт release.
+type blockingModule struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (m *blockingModule) ID() string       { return "Test" }
+func (m *blockingModule) MenuText() string { return "Тестовый модуль" }
+
+func (m *blockingModule) Run(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	close(m.started)
+	<-m.release
+	return nil
+}
+
+// testAPIServer поднимает API с одним модулем Test и журналом во временной директории.
+func testAPIServer(t *testing.T) (*httptest.Server, *blockingModule) {
+	t.Helper()
+	j, err := journal.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { j.Close() })
+
+	module := &blockingModule{started: make(chan struct{}), release: make(chan struct{})}
+	app := &App{
+		Cfg:     &config.Config{Modules: []config.ModuleDef{{ID: "Test"}}},
+		Modules: map[string]core.Installer{"Test": module},
+		Journal: j,
+	}
+	s := &apiServer{app: app, token: testAPIToken}
+	srv := httptest.NewServer(s.routes())
+	t.Cleanup(srv.Close)
+	return srv, module
+}
+
+func apiRequest(t *testing.T, srv *httptest.Server, method, path, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testAPIToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestAPIAuthorization(t *testing.T) {
+	srv, _ := testAPIServer(t)
+	tests := []struct {
+		header string
+		want   int
+	}{
+		{"", http.StatusUnauthorized},
+		{testAPIToken, http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"bearer " + testAPIToken, http.StatusUnauthorized},
+		{"Bearer " + testAPIToken, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/api/runs", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("Authorization %q: код %d, want %d", tt.header, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestAPIRejectsLargeBody(t *testing.T) {
+	srv, _ := testAPIServer(t)
+	body := `{"module": "Test", "answers": {"x": "` + strings.Repeat("a", maxAPIRequestBody) + `"}}`
+	if resp := apiRequest(t, srv, http.MethodPost, "/api/runs", body); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("код %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestAPIConcurrentRunConflict(t *testing.T) {
+	srv, module := testAPIServer(t)
+	defer close(module.release)
+
+	if resp := apiRequest(t, srv, http.MethodPost, "/api/runs", `{"module": "Test"}`); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("первый запуск: код %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+	<-module.started
+	if resp := apiRequest(t, srv, http.MethodPost, "/api/runs", `{"module": "Test"}`); resp.StatusCode != http.StatusConflict {
+		t.Errorf("второй запуск: код %d, want %d", resp.StatusCode, http.StatusConflict)
+	}
+}
+
+func TestAPIEventsStreamEndsWithRun(t *testing.T) {
+	srv, module := testAPIServer(t)
+
+	resp := apiRequest(t, srv, http.MethodPost, "/api/runs", `{"module": "Test"}`)
+	var info runInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		t.Fatalf("ответ запуска: %v", err)
+	}
+	<-module.started
+
+	events := apiRequest(t, srv, http.MethodGet, "/api/runs/"+info.ID+"/events", "")
+	if ct := events.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type %q, want application/x-ndjson", ct)
+	}
+	close(module.release)
+
+	done := make(chan []string)
+	go func() {
+		var lines []string
+		scanner := bufio.NewScanner(events.Body)
+		for scanner.Scan() {
+			var rec journal.Record
+			if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+				t.Errorf("строка %q не JSON: %v", scanner.Text(), err)
+			}
+			lines = append(lines, rec.Event+":"+rec.Status)
+		}
+		done <- lines
+	}()
+	select {
+	case lines := <-done:
+		want := journal.EventModuleFinish + ":ok"
+		if len(lines) == 0 || lines[len(lines)-1] != want {
+			t.Errorf("записи потока %v, want последнюю %s", lines, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("поток событий не завершился после окончания запуска")
+	}
+
+	var run runInfo
+	if err := json.NewDecoder(apiRequest(t, srv, http.MethodGet, "/api/runs/"+info.ID, "").Body).Decode(&run); err != nil {
+		t.Fatal(err)
+	}
+	if run.Status != runOK || run.Finished == nil {
+		t.Errorf("состояние запуска %+v, want завершен со статусом ok", run)
+	}
+}
+
+func TestAPIRunFinishStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, runOK},
+		{context.Canceled, runCancelled},
+		{core.ErrCancelled, runCancelled},
+		{core.ErrNoAnswer, runError},
+	}
+	for _, tt := range tests {
+		run := newAPIRun("1", "Test")
+		run.finish(tt.err)
+		if got := run.snapshot().Status; got != tt.want {
+			t.Errorf("finish(%v): статус %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
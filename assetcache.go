@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"goMH/assetmgr"
+	"goMH/tui"
+	"path/filepath"
+	"strconv"
+)
+
+// cacheListing - содержимое кэша для goMH assets ls --json.
+type cacheListing struct {
+	Path    string                `json:"path"`
+	Size    int64                 `json:"size"`
+	MaxSize int64                 `json:"max_size,omitempty"`
+	Files   []assetmgr.CacheEntry `json:"files"`
+}
+
+// cmdAssetsList выводит файлы кэша: ресурс, размер, время последнего использования.
+func (a *App) cmdAssetsList(args []string) int {
+	params, err := parseParams(args)
+	if err != nil {
+		tui.Error(err.Error())
+		return exitUsage
+	}
+	entries, err := a.Cache.CacheEntries()
+	if err != nil {
+		tui.Error(fmt.Sprintf("Не удалось прочитать кэш: %v", err))
+		return exitError
+	}
+
+	listing := cacheListing{Path: a.Cfg.AssetsCachePath, MaxSize: a.Cfg.AssetsCacheMaxMB << 20, Files: entries}
+	for _, entry := range entries {
+		listing.Size += entry.Size
+	}
+	if params["json"] != "" {
+		data, err := json.MarshalIndent(listing, "", "  ")
+		if err != nil {
+			tui.Error(fmt.Sprintf("Не удалось сформировать JSON: %v", err))
+			return exitError
+		}
+		fmt.Println(string(data))
+		return exitOK
+	}
+
+	limit := "без ограничения"
+	if listing.MaxSize > 0 {
+		limit = "лимит " + tui.FormatBytes(listing.MaxSize)
+	}
+	fmt.Printf("Кэш ресурсов: %s - %s, %s (файлов: %d)\n", listing.Path, tui.FormatBytes(listing.Size), limit, len(entries))
+	if len(entries) == 0 {
+		return exitOK
+	}
+	fmt.Printf("  %-28s %-10s %-16s %s\n", "РЕСУРС", "РАЗМЕР", "ИСПОЛЬЗОВАН", "ФАЙЛ")
+	for _, entry := range entries {
+		fmt.Printf("  %-28s %-10s %-16s %s\n", cacheOwner(entry), tui.FormatBytes(entry.Size),
+			entry.LastUsed.Format("2006-01-02 15:04"), a.cacheRelPath(entry.Path))
+	}
+	return exitOK
+}
+
+// cmdAssetsGC удаляет из кэша неучтенные файлы, прежние версии ресурсов и давно не
+// использованные файлы сверх лимита (assets_cache_max_mb или --max-size).
+func (a *App) cmdAssetsGC(args []string) int {
+	params, err := parseParams(args)
+	if err != nil {
+		tui.Error(err.Error())
+		return exitUsage
+	}
+	maxSize := a.Cfg.AssetsCacheMaxMB
+	if value, ok := params["max-size"]; ok {
+		if maxSize, err = strconv.ParseInt(value, 10, 64); err != nil || maxSize < 0 {
+			tui.Error(fmt.Sprintf("--max-size: ожидается размер в МБ, получено '%s'", value))
+			return exitUsage
+		}
+	}
+
+	garbage, err := a.Cache.CacheGarbage(maxSize << 20)
+	if err != nil {
+		tui.Error(fmt.Sprintf("Не удалось прочитать кэш: %v", err))
+		return exitError
+	}
+	if len(garbage) == 0 {
+		tui.Success("Удалять из кэша нечего.")
+		return exitOK
+	}
+	return a.removeFromCache(garbage)
+}
+
+// cmdAssetsPurge очищает кэш целиком или удаляет файлы указанных ресурсов (все версии).
+func (a *App) cmdAssetsPurge(ids []string) int {
+	entries, err := a.Cache.CacheEntries()
+	if err != nil {
+		tui.Error(fmt.Sprintf("Не удалось прочитать кэш: %v", err))
+		return exitError
+	}
+
+	selected := entries
+	if len(ids) > 0 {
+		wanted := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			wanted[id] = true
+		}
+		selected = nil
+		for _, entry := range entries {
+			if wanted[entry.AssetID] {
+				entry.Reason = "очистка ресурса"
+				selected = append(selected, entry)
+			}
+		}
+	} else {
+		for i := range selected {
+			selected[i].Reason = "очистка кэша"
+		}
+	}
+	if len(selected) == 0 {
+		tui.Info("В кэше нет файлов указанных ресурсов.")
+		return exitOK
+	}
+	return a.removeFromCache(selected)
+}
+
+// removeFromCache удаляет файлы кэша (в режиме dry-run - записывает в план) и выводит итог.
+func (a *App) removeFromCache(entries []assetmgr.CacheEntry) int {
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+		if a.Plan != nil {
+			a.Plan.Add("кэш", "удалить %s (%s): %s", entry.Path, tui.FormatBytes(entry.Size), entry.Reason)
+			continue
+		}
+		fmt.Printf("  %-28s %-10s %s - %s\n", cacheOwner(entry), tui.FormatBytes(entry.Size), a.cacheRelPath(entry.Path), entry.Reason)
+	}
+	if a.Plan != nil {
+		a.Plan.Print()
+		a.Plan.Reset()
+		return exitOK
+	}
+
+	if err := a.Cache.RemoveFromCache(entries); err != nil {
+		tui.Error(err.Error())
+		return exitError
+	}
+	tui.Success(fmt.Sprintf("Удалено файлов: %d, освобождено %s.", len(entries), tui.FormatBytes(total)))
+	return exitOK
+}
+
+// cacheOwner - ресурс каталога, которому принадлежит файл кэша, для вывода.
+func cacheOwner(entry assetmgr.CacheEntry) string {
+	switch {
+	case entry.AssetID != "":
+		return entry.AssetID
+	case entry.Untracked:
+		return "(не учтен)"
+	}
+	return "-"
+}
+
+// cacheRelPath - путь файла относительно директории кэша.
+func (a *App) cacheRelPath(path string) string {
+	if rel, err := filepath.Rel(a.Cfg.AssetsCachePath, path); err == nil {
+		return rel
+	}
+	return path
+}
@@ -0,0 +1,183 @@
+package assetmgr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Все файлы менеджер пишет сначала во временный файл или директорию рядом с целевыми
+// и затем переименовывает: после сбоя в кэше и директориях назначения остаются либо
+// старые файлы, либо новые целиком, но не половина файла.
+
+// writeAtomic записывает файл path через временный файл в той же директории.
+func writeAtomic(path string, perm fs.FileMode, write func(w io.Writer) error) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+
+	err = write(tmpFile)
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+	}
+	return err
+}
+
+// writeFileAtomic - os.WriteFile через временный файл.
+func writeFileAtomic(path string, data []byte, perm fs.FileMode) error {
+	return writeAtomic(path, perm, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// stagingDir возвращает временную директорию для распаковки в dest: рядом с ней,
+// чтобы готовые файлы можно было перенести переименованием. Остатки прошлого
+// прерванного запуска удаляются, а если он прервался посреди замены файлов
+// в commitStaging, прежние файлы dest возвращаются на место.
+func stagingDir(dest string) (string, error) {
+	if err := restoreAside(dest); err != nil {
+		return "", err
+	}
+	staging := filepath.Join(filepath.Dir(dest), "."+filepath.Base(dest)+".staging")
+	if err := os.RemoveAll(staging); err != nil {
+		return "", err
+	}
+	return staging, os.MkdirAll(staging, 0755)
+}
+
+// asideDir - куда commitStaging переносит заменяемые файлы dest на время замены.
+func asideDir(dest string) string {
+	return filepath.Join(filepath.Dir(dest), "."+filepath.Base(dest)+".old")
+}
+
+// discardDir - куда переименовывается asideDir после успешной замены: удаление может
+// прерваться, а оставшиеся в asideDir файлы restoreAside вернул бы поверх новых.
+func discardDir(dest string) string {
+	return filepath.Join(filepath.Dir(dest), "."+filepath.Base(dest)+".discard")
+}
+
+// restoreAside возвращает в dest файлы, которые прерванная замена успела убрать
+// в asideDir, и удаляет остатки завершенной замены.
+func restoreAside(dest string) error {
+	if err := os.RemoveAll(discardDir(dest)); err != nil {
+		return err
+	}
+	aside := asideDir(dest)
+	if _, err := os.Stat(aside); err != nil {
+		return nil
+	}
+	err := filepath.WalkDir(aside, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(aside, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return os.Rename(path, target)
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось вернуть прежние файлы %s из %s: %w", dest, aside, err)
+	}
+	return os.RemoveAll(aside)
+}
+
+// commitStaging переносит распакованное из staging в dest. Пустая или отсутствующая dest
+// заменяется директорией целиком. Иначе файлы заменяются по одному: прежний файл dest
+// переносится в asideDir, на его место переименовывается файл из staging. Файлы dest,
+// которых нет в архиве, не трогаются. Если какой-то файл заменить не удалось (например,
+// он занят запущенной службой), уже замененные файлы возвращаются из asideDir.
+func commitStaging(staging, dest string) error {
+	defer os.RemoveAll(staging)
+	os.Remove(dest) // удаляется, только если пустая
+	if _, err := os.Stat(dest); errors.Is(err, fs.ErrNotExist) {
+		return os.Rename(staging, dest)
+	}
+
+	// replaced - замененный файл dest и признак того, что прежний файл убран в asideDir
+	type replaced struct {
+		rel   string
+		aside bool
+	}
+	aside := asideDir(dest)
+	var done []replaced
+	rollback := func(cause error) error {
+		for i := len(done) - 1; i >= 0; i-- {
+			target := filepath.Join(dest, done[i].rel)
+			if !done[i].aside {
+				os.Remove(target)
+				continue
+			}
+			if err := os.Rename(filepath.Join(aside, done[i].rel), target); err != nil {
+				return fmt.Errorf("%w; прежние файлы остались в %s: %v", cause, aside, err)
+			}
+		}
+		os.RemoveAll(aside)
+		return cause
+	}
+
+	err := filepath.WalkDir(staging, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(staging, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		step := replaced{rel: rel}
+		if fi, err := os.Lstat(target); err == nil {
+			if fi.IsDir() {
+				return fmt.Errorf("на месте файла %s находится директория", target)
+			}
+			backup := filepath.Join(aside, rel)
+			if err := os.MkdirAll(filepath.Dir(backup), 0755); err != nil {
+				return err
+			}
+			if err := os.Rename(target, backup); err != nil {
+				return fmt.Errorf("не удалось заменить %s: %w", target, err)
+			}
+			step.aside = true
+		}
+		done = append(done, step)
+		if err := os.Rename(path, target); err != nil {
+			return fmt.Errorf("не удалось заменить %s: %w", target, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return rollback(err)
+	}
+
+	if err := os.Rename(aside, discardDir(dest)); err != nil {
+		os.RemoveAll(aside)
+	}
+	os.RemoveAll(discardDir(dest))
+	return nil
+}
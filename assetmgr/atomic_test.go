@@ -0,0 +1,107 @@
+package assetmgr
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeTree создает файлы files (путь относительно root -> содержимое).
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readTree возвращает файлы под root в виде "путь=содержимое".
+func readTree(t *testing.T, root string) string {
+	t.Helper()
+	var files []string
+	filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, _ := os.ReadFile(path)
+		rel, _ := filepath.Rel(root, path)
+		files = append(files, filepath.ToSlash(rel)+"="+string(data))
+		return nil
+	})
+	sort.Strings(files)
+	return strings.Join(files, ", ")
+}
+
+func TestCommitStagingReplacesNonEmptyDest(t *testing.T) {
+	parent := t.TempDir()
+	dest := filepath.Join(parent, "vcomcaster")
+	writeTree(t, dest, map[string]string{"app.exe": "old", "settings.ini": "local", "logs/today.log": "log"})
+
+	staging, err := stagingDir(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTree(t, staging, map[string]string{"app.exe": "new", "lib/core.dll": "dll"})
+	if err := commitStaging(staging, dest); err != nil {
+		t.Fatalf("commitStaging: %v", err)
+	}
+
+	want := "app.exe=new, lib/core.dll=dll, logs/today.log=log, settings.ini=local"
+	if got := readTree(t, dest); got != want {
+		t.Errorf("dest: %s, want %s", got, want)
+	}
+	if entries, _ := os.ReadDir(parent); len(entries) != 1 {
+		t.Errorf("рядом с dest остались временные директории: %v", entries)
+	}
+}
+
+func TestCommitStagingRollsBackOnFailure(t *testing.T) {
+	parent := t.TempDir()
+	dest := filepath.Join(parent, "frpc")
+	// На месте файла архива "nssm" в dest директория: замена на нем прерывается
+	writeTree(t, dest, map[string]string{"frpc.exe": "old", "frpc.ini": "local", "nssm/log.txt": "log"})
+
+	staging, err := stagingDir(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTree(t, staging, map[string]string{"frpc.exe": "new", "new.dll": "dll", "nssm": "file"})
+	if err := commitStaging(staging, dest); err == nil {
+		t.Fatal("commitStaging должен вернуть ошибку")
+	}
+
+	want := "frpc.exe=old, frpc.ini=local, nssm/log.txt=log"
+	if got := readTree(t, dest); got != want {
+		t.Errorf("dest после отката: %s, want %s", got, want)
+	}
+	if entries, _ := os.ReadDir(parent); len(entries) != 1 {
+		t.Errorf("рядом с dest остались временные директории: %v", entries)
+	}
+}
+
+func TestStagingDirRestoresInterruptedSwap(t *testing.T) {
+	parent := t.TempDir()
+	dest := filepath.Join(parent, "getad")
+	// Прошлый запуск заменил getad.exe, убрав прежний в сторону, и прервался
+	writeTree(t, asideDir(dest), map[string]string{"getad.exe": "old"})
+	writeTree(t, dest, map[string]string{"getad.exe": "new", "getad.ini": "local"})
+
+	staging, err := stagingDir(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(staging)
+	if got := readTree(t, dest); got != "getad.exe=old, getad.ini=local" {
+		t.Errorf("dest после восстановления: %s, want прежние файлы", got)
+	}
+	if _, err := os.Stat(asideDir(dest)); !os.IsNotExist(err) {
+		t.Errorf("прежние файлы не возвращены на место: %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+package assetmgr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"goMH/trust"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Файлы, скачанные в AssetsCachePath, учитываются в манифесте кэша (manifest.json):
+// источник, хеш, размер, время последнего использования и ресурс каталога. По манифесту
+// размер кэша ограничивается assets_cache_max_mb (первыми удаляются давно не использованные
+// файлы), а команды goMH assets ls|gc|purge показывают и чистят кэш.
+const manifestName = "manifest.json"
+
+// stalePartAge - через сколько недокачанный файл считается брошенным (см. CacheGarbage).
+const stalePartAge = 7 * 24 * time.Hour
+
+// CacheEntry - файл в кэше.
+type CacheEntry struct {
+	Path     string    `json:"path"`
+	AssetID  string    `json:"asset_id,omitempty"`
+	Source   string    `json:"source,omitempty"`
+	SHA256   string    `json:"sha256,omitempty"`
+	Size     int64     `json:"size"`
+	LastUsed time.Time `json:"last_used"`
+
+	// Untracked - файл есть в директории кэша, но не учтен в манифесте (недокачанный или
+	// скачанный старой версией goMH); LastUsed для него - время изменения файла.
+	Untracked bool `json:"untracked,omitempty"`
+	// Reason - почему файл будет удален (заполняется в CacheGarbage).
+	Reason string `json:"-"`
+}
+
+type cacheManifest struct {
+	Files []CacheEntry `json:"files"`
+}
+
+func (m *Manager) manifestPath() string {
+	return filepath.Join(m.cfg.AssetsCachePath, manifestName)
+}
+
+// inCache сообщает, лежит ли путь внутри AssetsCachePath.
+func (m *Manager) inCache(path string) bool {
+	rel, err := filepath.Rel(filepath.Clean(m.cfg.AssetsCachePath), filepath.Clean(path))
+	return err == nil && rel != "." && !strings.HasPrefix(rel, "..")
+}
+
+// loadManifest читает манифест. Записи об удаленных файлах отбрасываются, поврежденный
+// манифест считается пустым. Вызывается под cacheMu.
+func (m *Manager) loadManifest() map[string]*CacheEntry {
+	entries := make(map[string]*CacheEntry)
+	data, err := os.ReadFile(m.manifestPath())
+	if err != nil {
+		return entries
+	}
+	var manifest cacheManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		fmt.Printf("Предупреждение: манифест кэша поврежден (%v), файлы кэша будут учтены заново.\n", err)
+		return entries
+	}
+	for _, entry := range manifest.Files {
+		if fi, err := os.Stat(entry.Path); err == nil && !fi.IsDir() {
+			entries[entry.Path] = &entry
+		}
+	}
+	return entries
+}
+
+// saveManifest записывает манифест (через временный файл). Вызывается под cacheMu.
+func (m *Manager) saveManifest(entries map[string]*CacheEntry) error {
+	manifest := cacheManifest{Files: make([]CacheEntry, 0, len(entries))}
+	for _, entry := range entries {
+		manifest.Files = append(manifest.Files, *entry)
+	}
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(m.manifestPath(), data, 0644); err != nil {
+		return fmt.Errorf("не удалось сохранить манифест кэша: %w", err)
+	}
+	return nil
+}
+
+// fresh сообщает, что файл не менялся с момента записи в манифест и хеш в записи актуален.
+func (e *CacheEntry) fresh(fi os.FileInfo) bool {
+	return e.SHA256 != "" && e.Size == fi.Size() && !fi.ModTime().After(e.LastUsed)
+}
+
+// recordCache отмечает в манифесте, что файл кэша скачан или использован, и удаляет
+// давно не использованные файлы, если кэш превысил assets_cache_max_mb. Файлы, которые
+// использовались в этой сессии, не удаляются: они могут быть еще нужны запущенному модулю.
+// Хеш считается заново, только если файл изменился; он нужен для goMH assets ls и gc,
+// ресурсы с sha256 в каталоге всегда проверяются по самому файлу (см. verifySHA256).
+// Пустые sourceURL и assetID не меняют записи.
+func (m *Manager) recordCache(ctx context.Context, localPath, sourceURL, assetID string) error {
+	localPath = filepath.Clean(localPath)
+	fi, err := os.Stat(localPath)
+	if err != nil {
+		return err
+	}
+
+	m.cacheMu.Lock()
+	defer m.cacheMu.Unlock()
+	entries := m.loadManifest()
+	entry, ok := entries[localPath]
+	if !ok {
+		entry = &CacheEntry{Path: localPath}
+		entries[localPath] = entry
+	}
+	if !entry.fresh(fi) {
+		sum, err := trust.FileSHA256(localPath)
+		if err != nil {
+			return err
+		}
+		entry.SHA256, entry.Size = sum, fi.Size()
+	}
+	if sourceURL != "" {
+		entry.Source = sourceURL
+	}
+	if assetID != "" {
+		entry.AssetID = assetID
+	}
+	entry.LastUsed = time.Now()
+	if m.sessionFiles == nil {
+		m.sessionFiles = make(map[string]bool)
+	}
+	m.sessionFiles[localPath] = true
+
+	if limit := m.cfg.AssetsCacheMaxMB << 20; limit > 0 {
+		report := reporterFrom(ctx)
+		for _, old := range lruOverflow(entries, limit, m.sessionFiles) {
+			if err := m.removeCached(old.Path); err != nil {
+				report.Printf("Предупреждение: не удалось удалить %s из кэша: %v\n", old.Path, err)
+				continue
+			}
+			delete(entries, old.Path)
+			report.Printf("Кэш больше %d МБ: удален давно не использованный файл %s.\n", m.cfg.AssetsCacheMaxMB, old.Path)
+		}
+	}
+	return m.saveManifest(entries)
+}
+
+// lruOverflow возвращает записи, которые нужно удалить, чтобы общий размер не превышал
+// limit байт: сначала те, что дольше всего не использовались. Файлы из keep не удаляются.
+func lruOverflow(entries map[string]*CacheEntry, limit int64, keep map[string]bool) []CacheEntry {
+	var total int64
+	candidates := make([]*CacheEntry, 0, len(entries))
+	for _, entry := range entries {
+		total += entry.Size
+		if !keep[entry.Path] {
+			candidates = append(candidates, entry)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].LastUsed.Before(candidates[j].LastUsed) })
+
+	var overflow []CacheEntry
+	for _, entry := range candidates {
+		if total <= limit {
+			break
+		}
+		total -= entry.Size
+		overflow = append(overflow, *entry)
+	}
+	return overflow
+}
+
+// removeCached удаляет файл кэша (недокачанный - вместе с состоянием) и опустевшие
+// директории над ним.
+func (m *Manager) removeCached(path string) error {
+	if strings.HasSuffix(path, partSuffix) {
+		partFile(path).discard()
+	} else if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for dir := filepath.Dir(path); m.inCache(dir); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// CacheEntries возвращает файлы кэша - учтенные в манифесте и неучтенные, начиная
+// с недавно использованных.
+func (m *Manager) CacheEntries() ([]CacheEntry, error) {
+	m.cacheMu.Lock()
+	defer m.cacheMu.Unlock()
+	entries := m.loadManifest()
+
+	list := make([]CacheEntry, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, *entry)
+	}
+	err := filepath.WalkDir(filepath.Clean(m.cfg.AssetsCachePath), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || entries[path] != nil || path == m.manifestPath() ||
+			strings.HasSuffix(path, partSuffix+".json") {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			list = append(list, CacheEntry{Path: path, Size: info.Size(), LastUsed: info.ModTime(), Untracked: true})
+		}
+		return nil
+	})
+	sort.Slice(list, func(i, j int) bool { return list[i].LastUsed.After(list[j].LastUsed) })
+	return list, err
+}
+
+// CacheGarbage возвращает файлы, которые удалит goMH assets gc:
+//   - неучтенные в манифесте (кроме недокачанных за последние stalePartAge);
+//   - ресурсы, которых больше нет в каталоге, и прежние версии ресурсов (в каталоге сменилось имя файла);
+//   - давно не использованные файлы сверх limit байт (0 - без ограничения).
+func (m *Manager) CacheGarbage(limit int64) ([]CacheEntry, error) {
+	list, err := m.CacheEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	var garbage []CacheEntry
+	kept := make(map[string]*CacheEntry)
+	for _, entry := range list {
+		switch {
+		case entry.Untracked && strings.HasSuffix(entry.Path, partSuffix):
+			if time.Since(entry.LastUsed) < stalePartAge {
+				continue
+			}
+			entry.Reason = "брошенная недокачанная часть"
+		case entry.Untracked:
+			entry.Reason = "не учтен в манифесте"
+		case entry.AssetID != "":
+			current, err := m.CachePath(entry.AssetID)
+			switch {
+			case err != nil:
+				entry.Reason = "ресурса больше нет в каталоге"
+			case filepath.Clean(current) != entry.Path:
+				entry.Reason = "прежняя версия ресурса"
+			}
+		}
+		if entry.Reason == "" {
+			kept[entry.Path] = &entry
+			continue
+		}
+		garbage = append(garbage, entry)
+	}
+
+	if limit > 0 {
+		for _, entry := range lruOverflow(kept, limit, nil) {
+			entry.Reason = "давно не использовался"
+			garbage = append(garbage, entry)
+		}
+	}
+	return garbage, nil
+}
+
+// RemoveFromCache удаляет файлы кэша и их записи в манифесте.
+func (m *Manager) RemoveFromCache(list []CacheEntry) error {
+	m.cacheMu.Lock()
+	defer m.cacheMu.Unlock()
+
+	var errs []error
+	for _, entry := range list {
+		if err := m.removeCached(entry.Path); err != nil {
+			errs = append(errs, fmt.Errorf("не удалось удалить %s: %w", entry.Path, err))
+		}
+	}
+	// loadManifest отбрасывает записи об удаленных файлах
+	errs = append(errs, m.saveManifest(m.loadManifest()))
+	return errors.Join(errs...)
+}
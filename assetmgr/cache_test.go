@@ -0,0 +1,67 @@
+package assetmgr
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"goMH/config"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// cacheFile создает в кэше файл размером size байт.
+func cacheFile(t *testing.T, m *Manager, name string, size int) string {
+	t.Helper()
+	path := filepath.Join(m.cfg.AssetsCachePath, name)
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRecordCacheKeepsSessionFiles(t *testing.T) {
+	m := &Manager{cfg: &config.Config{AssetsCachePath: t.TempDir(), AssetsCacheMaxMB: 1}}
+	first := cacheFile(t, m, "first.bin", 700<<10)
+	if err := m.recordCache(context.Background(), first, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	second := cacheFile(t, m, "second.bin", 700<<10)
+	if err := m.recordCache(context.Background(), second, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(first); err != nil {
+		t.Errorf("файл, использованный в этой сессии, удален при превышении лимита: %v", err)
+	}
+
+	// В новой сессии давно не использованный файл вытесняется
+	next := &Manager{cfg: m.cfg}
+	third := cacheFile(t, next, "third.bin", 100<<10)
+	if err := next.recordCache(context.Background(), third, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Errorf("давно не использованный файл не удален: %v", err)
+	}
+}
+
+func TestVerifySHA256IgnoresManifestHash(t *testing.T) {
+	m := &Manager{cfg: &config.Config{AssetsCachePath: t.TempDir()}}
+	path := cacheFile(t, m, "setup.exe", 16)
+	if err := m.recordCache(context.Background(), path, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(make([]byte, 16))
+	expected := hex.EncodeToString(sum[:])
+
+	// Подмена с тем же размером и прежним временем изменения: запись манифеста выглядит актуальной
+	fi, _ := os.Stat(path)
+	if err := os.WriteFile(path, []byte("0123456789abcdef"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, fi.ModTime(), fi.ModTime())
+
+	if err := m.verifySHA256(path, expected); err == nil {
+		t.Error("verifySHA256 принял подмененный файл по хешу из манифеста")
+	}
+}
@@ -2,15 +2,18 @@ package assetmgr
 
 import (
 	"archive/zip"
+	"context"
+	"errors"
 	"fmt"
 	"goMH/config"
 	"goMH/core"
+	"goMH/trust"
 	"io"
 	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jlaffaye/ftp"
@@ -19,6 +22,14 @@ import (
 
 type Manager struct {
 	cfg *config.Config
+
+	// OnAttempt, если задан, вызывается после каждой попытки скачивания (для журнала аудита).
+	OnAttempt func(url string, attempt int, err error)
+
+	// cacheMu защищает манифест кэша (см. cache.go) и sessionFiles при параллельных загрузках.
+	cacheMu sync.Mutex
+	// sessionFiles - файлы кэша, использованные в этой сессии; они не вытесняются.
+	sessionFiles map[string]bool
 }
 
 func New(cfg *config.Config) (*Manager, error) {
@@ -36,35 +47,91 @@ func (m *Manager) Cfg() *config.Config {
 	return m.cfg
 }
 
-func (m *Manager) DownloadToCache(assetName string) (string, error) {
+// CachePath возвращает путь, по которому ресурс хранится в кэше: <кэш>\<ID ресурса>\<имя файла>.
+// Имя файла из URL сохраняется (установщикам важно расширение), а директория ресурса
+// разделяет ресурсы с одинаковыми именами файлов.
+func (m *Manager) CachePath(assetName string) (string, error) {
 	assetInfo, ok := m.cfg.AssetCatalog[assetName]
 	if !ok {
 		return "", fmt.Errorf("ресурс '%s' не найден в каталоге", assetName)
 	}
+	return filepath.Join(m.cfg.AssetsCachePath, assetName, filepath.Base(assetInfo.URL)), nil
+}
 
-	fileName := filepath.Base(assetInfo.URL)
-	localCachePath := filepath.Join(m.cfg.AssetsCachePath, fileName)
-
-	downloadMethod := strings.ToUpper(assetInfo.DownloadMethod)
-	if downloadMethod == "" {
-		downloadMethod = "HTTP"
+// migrateLegacyCache переносит файл ресурса, скачанный прежними версиями прямо в директорию
+// кэша, на новое место. Если имя файла есть у нескольких ресурсов каталога, неизвестно,
+// чей это файл: он остается на месте и удаляется командой goMH assets gc.
+func (m *Manager) migrateLegacyCache(assetName, cachePath string) error {
+	fileName := filepath.Base(m.cfg.AssetCatalog[assetName].URL)
+	legacyPath := filepath.Join(m.cfg.AssetsCachePath, fileName)
+	if _, err := os.Stat(cachePath); err == nil {
+		return nil
 	}
-
-	var err error
-	if downloadMethod == "HTTP" {
-		_, err = m.DownloadHTTPWithProgress(assetInfo.URL, localCachePath)
-	} else if downloadMethod == "FTP" {
-		parsedURL, _ := url.Parse(assetInfo.URL)
-		_, err = m.DownloadFTPWithProgress(parsedURL.Path, localCachePath)
-	} else {
-		return "", fmt.Errorf("неизвестный метод загрузки: %s", downloadMethod)
+	if fi, err := os.Stat(legacyPath); err != nil || fi.IsDir() {
+		return nil
 	}
+	for id, asset := range m.cfg.AssetCatalog {
+		if id != assetName && filepath.Base(asset.URL) == fileName {
+			return nil
+		}
+	}
+	if err := os.Rename(legacyPath, cachePath); err != nil {
+		return fmt.Errorf("не удалось перенести %s в %s: %w", legacyPath, cachePath, err)
+	}
+	return nil
+}
 
+// hashAttempts - сколько раз скачивается ресурс, если хеш файла не совпал с каталогом.
+const hashAttempts = 2
+
+// DownloadToCache скачивает ресурс в кэш с основного адреса или зеркал (см. fetch).
+// Если в каталоге задан sha256, файл из кэша используется только при совпадении хеша,
+// а скачанный файл проверяется: при несовпадении он удаляется и скачивается заново.
+func (m *Manager) DownloadToCache(ctx context.Context, assetName string) (string, error) {
+	report := reporterFrom(ctx)
+	localCachePath, err := m.CachePath(assetName)
 	if err != nil {
-		return "", fmt.Errorf("ошибка при загрузке ресурса '%s' в кэш: %w", assetName, err)
+		return "", err
+	}
+	assetInfo := m.cfg.AssetCatalog[assetName]
+	if err := os.MkdirAll(filepath.Dir(localCachePath), 0755); err != nil {
+		return "", fmt.Errorf("не удалось создать директорию %s: %w", filepath.Dir(localCachePath), err)
+	}
+	if err := m.migrateLegacyCache(assetName, localCachePath); err != nil {
+		report.Printf("Предупреждение: %v. Ресурс будет скачан заново.\n", err)
+	}
+
+	if assetInfo.SHA256 != "" {
+		if _, err := os.Stat(localCachePath); err == nil {
+			if err := m.verifySHA256(localCachePath, assetInfo.SHA256); err == nil {
+				report.Printf("Файл '%s' уже в кэше, хеш SHA-256 совпадает. Пропускаем.\n", filepath.Base(localCachePath))
+				if err := m.recordCache(ctx, localCachePath, "", assetName); err != nil {
+					report.Printf("Предупреждение: не удалось обновить манифест кэша: %v\n", err)
+				}
+				return localCachePath, nil
+			}
+			report.Printf("Предупреждение: хеш файла '%s' в кэше не совпадает с каталогом. Файл удален.\n", filepath.Base(localCachePath))
+			os.Remove(localCachePath)
+		}
 	}
 
-	return localCachePath, nil
+	for attempt := 1; ; attempt++ {
+		if _, err := m.fetch(ctx, m.assetSources(assetInfo), localCachePath, m.retryPolicy(&assetInfo), assetName); err != nil {
+			return "", fmt.Errorf("ошибка при загрузке ресурса '%s' в кэш: %w", assetName, err)
+		}
+		if assetInfo.SHA256 == "" {
+			return localCachePath, nil
+		}
+		err := m.verifySHA256(localCachePath, assetInfo.SHA256)
+		if err == nil {
+			return localCachePath, nil
+		}
+		os.Remove(localCachePath)
+		if attempt >= hashAttempts {
+			return "", fmt.Errorf("ресурс '%s' не прошел проверку: %w", assetName, err)
+		}
+		report.Printf("Предупреждение: %v. Файл удален, повторное скачивание...\n", err)
+	}
 }
 
 // ProcessFromCache обрабатывает файл из кэша (копирует/распаковывает) в его конечную директорию.
@@ -83,6 +150,10 @@ func (m *Manager) ProcessFromCache(assetName, cachePath string) error {
 
 	switch assetInfo.Type {
 	case "zip":
+		// Без destination архив распаковался бы прямо в root_path (см. Validate)
+		if assetInfo.Destination == "" {
+			return fmt.Errorf("для архива '%s' не указан destination", assetName)
+		}
 		if err := unzip(cachePath, finalDestPath); err != nil {
 			return fmt.Errorf("ошибка распаковки '%s': %w", fileName, err)
 		}
@@ -97,8 +168,8 @@ func (m *Manager) ProcessFromCache(assetName, cachePath string) error {
 }
 
 // Метод Get теперь можно упростить, используя новые функции
-func (m *Manager) Get(assetName string) (string, error) {
-	cachePath, err := m.DownloadToCache(assetName)
+func (m *Manager) Get(ctx context.Context, assetName string) (string, error) {
+	cachePath, err := m.DownloadToCache(ctx, assetName)
 	if err != nil {
 		return "", err
 	}
@@ -112,72 +183,145 @@ func (m *Manager) Get(assetName string) (string, error) {
 }
 
 // DownloadFTPWithProgress скачивает файл по FTP с проверкой размера и прогресс-баром.
+// Файл пишется в localPath.part; при повторной попытке (см. download_retry) скачивание
+// продолжается с места остановки (команда REST), в том числе при следующем запуске.
 // ftpPath - это путь на сервере, например /distr/iiko/Setup.Front.exe
-func (m *Manager) DownloadFTPWithProgress(ftpPath, localPath string) (bool, error) {
-	fileName := filepath.Base(ftpPath)
-
+func (m *Manager) DownloadFTPWithProgress(ctx context.Context, ftpPath, localPath string) (bool, error) {
 	// Убедимся, что директория для сохранения файла существует
 	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
 		return false, fmt.Errorf("не удалось создать директорию %s: %w", filepath.Dir(localPath), err)
 	}
+	return m.fetch(ctx, []source{m.ftpSource(ftpPath)}, localPath, m.retryPolicy(nil), "")
+}
 
-	c, err := ftp.Dial(m.cfg.FTP.Host, ftp.DialWithTimeout(10*time.Second))
+// DownloadMirrors скачивает файл с первого доступного адреса из списка (HTTP или FTP)
+// по политике повторов download_retry.
+func (m *Manager) DownloadMirrors(ctx context.Context, urls []string, localPath string) (bool, error) {
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return false, fmt.Errorf("не удалось создать директорию %s: %w", filepath.Dir(localPath), err)
+	}
+	var sources []source
+	for _, u := range urls {
+		sources = append(sources, m.sourceFor(u, ""))
+	}
+	return m.fetch(ctx, sources, localPath, m.retryPolicy(nil), "")
+}
+
+// downloadFTP выполняет одну попытку скачивания с FTP-сервера server.
+func (m *Manager) downloadFTP(ctx context.Context, server config.FTPConfig, ftpPath, localPath string) (bool, error) {
+	fileName := filepath.Base(ftpPath)
+	report := reporterFrom(ctx)
+
+	if err := m.cfg.SecretError(server.Pass); err != nil {
+		return false, permanent(err)
+	}
+	c, err := ftp.Dial(server.Host, ftp.DialWithTimeout(10*time.Second), ftp.DialWithContext(ctx))
 	if err != nil {
 		return false, fmt.Errorf("не удалось подключиться к FTP: %w", err)
 	}
 	defer c.Quit()
 
-	if err := c.Login(m.cfg.FTP.User, m.cfg.FTP.Pass); err != nil {
-		return false, fmt.Errorf("ошибка входа на FTP: %w", err)
+	if err := c.Login(server.User, server.Pass); err != nil {
+		return false, permanent(fmt.Errorf("ошибка входа на FTP: %w", err))
 	}
 
 	remoteSize, err := c.FileSize(ftpPath)
 	if err != nil {
-		fmt.Printf("Предупреждение: не удалось получить размер файла '%s' на FTP: %v. Загрузка будет выполнена без проверки.\n", fileName, err)
+		report.Printf("Предупреждение: не удалось получить размер файла '%s' на FTP: %v. Загрузка будет выполнена без проверки.\n", fileName, err)
 		remoteSize = -1
 	}
 
 	if fi, err := os.Stat(localPath); err == nil {
 		if remoteSize > 0 && fi.Size() == remoteSize {
-			fmt.Printf("Файл '%s' уже существует и размер совпадает. Пропускаем.\n", fileName)
+			report.Printf("Файл '%s' уже существует и размер совпадает. Пропускаем.\n", fileName)
 			return true, nil
 		}
-		fmt.Printf("Файл '%s' существует, но размер отличается. Перезагрузка...\n", fileName)
+		report.Printf("Файл '%s' существует, но размер отличается. Перезагрузка...\n", fileName)
 	}
 
-	resp, err := c.Retr(ftpPath)
+	// Докачка возможна, только если файл на сервере тот же: совпадают размер и время изменения
+	state := partState{Source: "ftp://" + server.Host + ftpPath, Size: remoteSize}
+	if c.IsGetTimeSupported() {
+		if modTime, err := c.GetTime(ftpPath); err == nil {
+			state.LastModified = modTime.UTC().Format(http.TimeFormat)
+		}
+	}
+	part := partFor(localPath)
+	saved, offset := part.load()
+	if offset > 0 && (remoteSize <= 0 || saved != state || offset >= remoteSize) {
+		part.discard()
+		offset = 0
+	}
+
+	resp, err := c.RetrFrom(ftpPath, uint64(offset))
 	if err != nil {
 		return false, fmt.Errorf("не удалось начать скачивание с FTP: %w", err)
 	}
 	defer resp.Close()
+	// Клиент FTP не умеет прерывать чтение по контексту: закрываем соединение данных сами
+	stop := context.AfterFunc(ctx, func() { resp.Close() })
+	defer stop()
 
-	destFile, err := os.Create(localPath)
-	if err != nil {
-		return false, fmt.Errorf("не удалось создать локальный файл: %w", err)
+	if offset > 0 {
+		report.Printf("Продолжение скачивания '%s' с %d%%...\n", fileName, offset*100/remoteSize)
 	}
-	defer destFile.Close()
-
-	bar := CreateProgressBar(remoteSize, fileName)
-	if _, err := io.Copy(io.MultiWriter(destFile, bar), resp); err != nil {
-		os.Remove(localPath)
+	if err := part.write(ctx, resp, state, offset, fileName); err != nil {
 		return false, fmt.Errorf("ошибка во время копирования потока: %w", err)
 	}
-
-	return false, nil
+	return false, part.complete(localPath)
 }
 
 // DownloadHTTPWithProgress скачивает файл по HTTP с проверкой размера и прогресс-баром.
-func (m *Manager) DownloadHTTPWithProgress(httpURL, localPath string) (bool, error) {
-	fileName := filepath.Base(httpURL)
-
+// Файл пишется в localPath.part; при повторной попытке (см. download_retry) скачивание
+// продолжается запросом Range, если ETag или Last-Modified файла на сервере не изменились.
+func (m *Manager) DownloadHTTPWithProgress(ctx context.Context, httpURL, localPath string) (bool, error) {
 	// Убедимся, что директория для сохранения файла существует
 	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
 		return false, fmt.Errorf("не удалось создать директорию %s: %w", filepath.Dir(localPath), err)
 	}
+	return m.fetch(ctx, []source{httpSource(httpURL)}, localPath, m.retryPolicy(nil), "")
+}
+
+// downloadHTTP выполняет одну попытку скачивания по HTTP.
+func (m *Manager) downloadHTTP(ctx context.Context, httpURL, localPath string) (bool, error) {
+	fileName := filepath.Base(httpURL)
+	report := reporterFrom(ctx)
+
+	part := partFor(localPath)
+	saved, offset := part.load()
+	if offset > 0 && (saved.Source != httpURL || (saved.ETag == "" && saved.LastModified == "")) {
+		part.discard()
+		offset = 0
+	}
+
+	// Размер готового файла сверяется HEAD-запросом до скачивания, чтобы не запрашивать
+	// файл целиком. Если сервер не отвечает на HEAD, размер сверяется по ответу на GET.
+	existing := int64(-1)
+	if fi, err := os.Stat(localPath); err == nil {
+		existing = fi.Size()
+		if size, err := m.headSize(ctx, httpURL); err == nil && size > 0 {
+			if size == existing {
+				report.Printf("Файл '%s' уже существует и размер совпадает. Пропускаем.\n", fileName)
+				part.discard()
+				return true, nil
+			}
+			existing = -1
+			report.Printf("Файл '%s' существует, но размер отличается. Перезагрузка...\n", fileName)
+		}
+	}
 
-	req, err := http.NewRequest("GET", httpURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", httpURL, nil)
 	if err != nil {
-		return false, err
+		return false, permanent(err)
+	}
+	if offset > 0 {
+		// If-Range: если файл изменился, сервер вернет его целиком (200), а не часть
+		validator := saved.ETag
+		if validator == "" {
+			validator = saved.LastModified
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", validator)
 	}
 
 	resp, err := http.DefaultClient.Do(req)
@@ -186,36 +330,105 @@ func (m *Manager) DownloadHTTPWithProgress(httpURL, localPath string) (bool, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return false, fmt.Errorf("bad status: %s", resp.Status)
+	state, resumed, err := resumeFrom(resp, httpURL, saved, offset)
+	if errors.Is(err, errPartMismatch) {
+		part.discard()
+	}
+	if err != nil {
+		return false, err
+	}
+	if offset > 0 && resumed == 0 {
+		report.Printf("Файл '%s' на сервере изменился или сервер не поддерживает докачку. Скачивание заново...\n", fileName)
+	}
+	offset = resumed
+	if offset == 0 && existing >= 0 {
+		if state.Size > 0 && state.Size == existing {
+			report.Printf("Файл '%s' уже существует и размер совпадает. Пропускаем.\n", fileName)
+			part.discard()
+			return true, nil
+		}
+		report.Printf("Файл '%s' существует, но размер отличается. Перезагрузка...\n", fileName)
 	}
 
-	remoteSize := resp.ContentLength
+	if offset > 0 && state.Size > 0 {
+		report.Printf("Продолжение скачивания '%s' с %d%%...\n", fileName, offset*100/state.Size)
+	}
+	if err := part.write(ctx, resp.Body, state, offset, fileName); err != nil {
+		return false, err
+	}
+	return false, part.complete(localPath)
+}
 
-	if fi, err := os.Stat(localPath); err == nil {
-		if remoteSize > 0 && fi.Size() == remoteSize {
-			fmt.Printf("Файл '%s' уже существует и размер совпадает. Пропускаем.\n", fileName)
-			return true, nil
+// resumeFrom проверяет ответ на запрос файла (с Range, если offset > 0) и возвращает
+// состояние загрузки и смещение, с которого дописывается тело ответа: offset при
+// докачке или 0, если сервер отдал файл целиком. Ошибка errPartMismatch означает,
+// что недокачанную часть нужно удалить.
+func resumeFrom(resp *http.Response, source string, saved partState, offset int64) (partState, int64, error) {
+	state := partState{Source: source, ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		start, total, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset || (saved.ETag != "" && state.ETag != saved.ETag) {
+			return state, 0, fmt.Errorf("сервер вернул не ту часть файла (%s): %w", resp.Header.Get("Content-Range"), errPartMismatch)
 		}
-		fmt.Printf("Файл '%s' существует, но размер отличается. Перезагрузка...\n", fileName)
+		state.Size = total
+		return state, offset, nil
+	case resp.StatusCode == http.StatusOK:
+		state.Size = resp.ContentLength
+		return state, 0, nil
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		return state, 0, fmt.Errorf("сервер отклонил докачку (%s): %w", resp.Status, errPartMismatch)
+	case resp.StatusCode >= 500:
+		return state, 0, fmt.Errorf("bad status: %s", resp.Status)
+	default:
+		return state, 0, permanent(fmt.Errorf("bad status: %s", resp.Status))
 	}
+}
+
+// HTTPFileSize возвращает размер файла на HTTP-сервере без скачивания (-1, если сервер его не сообщил).
+func (m *Manager) HTTPFileSize(httpURL string) (int64, error) {
+	return m.headSize(context.Background(), httpURL)
+}
 
-	destFile, err := os.Create(localPath)
+// headSize запрашивает размер файла HEAD-запросом.
+func (m *Manager) headSize(ctx context.Context, httpURL string) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, httpURL, nil)
 	if err != nil {
-		return false, err
+		return -1, err
 	}
-	defer destFile.Close()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return -1, err
+	}
+	resp.Body.Close()
 
-	bar := CreateProgressBar(remoteSize, fileName)
-	if _, err := io.Copy(io.MultiWriter(destFile, bar), resp.Body); err != nil {
-		os.Remove(localPath)
-		return false, err
+	if resp.StatusCode != http.StatusOK {
+		return -1, fmt.Errorf("bad status: %s", resp.Status)
 	}
+	return resp.ContentLength, nil
+}
 
-	return false, nil
+// FTPFileSize возвращает размер файла на FTP-сервере без скачивания.
+func (m *Manager) FTPFileSize(ftpPath string) (int64, error) {
+	if err := m.cfg.SecretError(m.cfg.FTP.Pass); err != nil {
+		return -1, err
+	}
+	c, err := ftp.Dial(m.cfg.FTP.Host, ftp.DialWithTimeout(10*time.Second))
+	if err != nil {
+		return -1, fmt.Errorf("не удалось подключиться к FTP: %w", err)
+	}
+	defer c.Quit()
+
+	if err := c.Login(m.cfg.FTP.User, m.cfg.FTP.Pass); err != nil {
+		return -1, fmt.Errorf("ошибка входа на FTP: %w", err)
+	}
+	return c.FileSize(ftpPath)
 }
 
 func (m *Manager) ListFTP(path string) ([]core.FTPEntry, error) {
+	if err := m.cfg.SecretError(m.cfg.FTP.Pass); err != nil {
+		return nil, err
+	}
 	c, err := ftp.Dial(m.cfg.FTP.Host, ftp.DialWithTimeout(10*time.Second))
 	if err != nil {
 		return nil, err
@@ -244,6 +457,19 @@ func (m *Manager) ListFTP(path string) ([]core.FTPEntry, error) {
 
 // --- Вспомогательные функции ---
 
+// verifySHA256 сверяет хеш файла с ожидаемым. Файл хешируется каждый раз: хешу
+// из манифеста кэша доверять нельзя, файл могли подменить вместе с манифестом.
+func (m *Manager) verifySHA256(path, expected string) error {
+	sum, err := trust.FileSHA256(path)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(sum, expected) {
+		return fmt.Errorf("хеш файла %s не совпадает с каталогом (ожидался %s, получен %s)", filepath.Base(path), expected, sum)
+	}
+	return nil
+}
+
 // createProgressBar создает и настраивает общий прогресс-бар для скачиваний.
 func CreateProgressBar(totalSize int64, description string) *progressbar.ProgressBar {
 	return progressbar.NewOptions64(
@@ -261,7 +487,8 @@ func CreateProgressBar(totalSize int64, description string) *progressbar.Progres
 	)
 }
 
-// unzip распаковывает zip-архив.
+// unzip распаковывает zip-архив во временную директорию рядом с dest и переносит
+// в dest, только если архив распакован целиком (см. atomic.go).
 func unzip(src, dest string) error {
 	r, err := zip.OpenReader(src)
 	if err != nil {
@@ -269,10 +496,16 @@ func unzip(src, dest string) error {
 	}
 	defer r.Close()
 
+	staging, err := stagingDir(dest)
+	if err != nil {
+		return err
+	}
+
 	for _, f := range r.File {
-		fpath := filepath.Join(dest, f.Name)
-		if !strings.HasPrefix(fpath, filepath.Clean(dest)+string(os.PathSeparator)) {
-			return fmt.Errorf("небезопасный путь в архиве: %s", fpath)
+		fpath := filepath.Join(staging, f.Name)
+		if !strings.HasPrefix(fpath, filepath.Clean(staging)+string(os.PathSeparator)) {
+			os.RemoveAll(staging)
+			return fmt.Errorf("небезопасный путь в архиве: %s", f.Name)
 		}
 
 		if f.FileInfo().IsDir() {
@@ -281,17 +514,20 @@ func unzip(src, dest string) error {
 		}
 
 		if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+			os.RemoveAll(staging)
 			return err
 		}
 
 		outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
 		if err != nil {
+			os.RemoveAll(staging)
 			return err
 		}
 
 		rc, err := f.Open()
 		if err != nil {
 			outFile.Close()
+			os.RemoveAll(staging)
 			return err
 		}
 
@@ -300,13 +536,15 @@ func unzip(src, dest string) error {
 		rc.Close()
 
 		if err != nil {
+			os.RemoveAll(staging)
 			return err
 		}
 	}
-	return nil
+	return commitStaging(staging, dest)
 }
 
-// ExtractFile извлекает файл из zip-архива.
+// ExtractFile извлекает файл из zip-архива. Файл пишется через временный файл,
+// поэтому прежняя версия destPath заменяется только целиком.
 func (m *Manager) ExtractFile(zipPath, pathInZip, destPath string) error {
 	r, err := zip.OpenReader(zipPath)
 	if err != nil {
@@ -329,19 +567,38 @@ func (m *Manager) ExtractFile(zipPath, pathInZip, destPath string) error {
 				return err
 			}
 
-			outFile, err := os.Create(destPath)
-			if err != nil {
+			return writeAtomic(destPath, 0644, func(w io.Writer) error {
+				_, err := io.Copy(w, rc)
 				return err
-			}
-			defer outFile.Close()
-
-			_, err = io.Copy(outFile, rc)
-			return err
+			})
 		}
 	}
 	return fmt.Errorf("файл '%s' не найден в архиве '%s'", pathInZip, zipPath)
 }
 
+// FindInZip возвращает полный путь внутри архива первого файла, путь которого
+// заканчивается на targetSuffix (например, "win64/nssm.exe").
+func (m *Manager) FindInZip(zipPath, targetSuffix string) (string, error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	// Нормализуем разделители
+	targetSuffix = filepath.ToSlash(targetSuffix)
+
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if strings.HasSuffix(filepath.ToSlash(f.Name), targetSuffix) {
+			return f.Name, nil // Возвращаем полный путь файла в архиве
+		}
+	}
+	return "", fmt.Errorf("файл, заканчивающийся на '%s', не найден в архиве '%s'", targetSuffix, zipPath)
+}
+
 // PurgeAsset удаляет файл ассета из кэша и его конечную директорию.
 func (m *Manager) PurgeAsset(assetName string) error {
 	assetInfo, ok := m.cfg.AssetCatalog[assetName]
@@ -350,15 +607,15 @@ func (m *Manager) PurgeAsset(assetName string) error {
 		return nil
 	}
 
-	// 1. Удаляем файл из кэша
-	fileName := filepath.Base(assetInfo.URL)
-	localCachePath := filepath.Join(m.cfg.AssetsCachePath, fileName)
+	// 1. Удаляем файл из кэша и недокачанную часть, если она есть
+	localCachePath, _ := m.CachePath(assetName)
 	if _, err := os.Stat(localCachePath); err == nil {
 		fmt.Printf("Удаление файла из кэша: %s\n", localCachePath)
-		if err := os.Remove(localCachePath); err != nil {
-			// Не критичная ошибка, просто предупреждаем
-			fmt.Printf("Предупреждение: не удалось удалить файл из кэша %s: %v\n", localCachePath, err)
-		}
+	}
+	cached := []CacheEntry{{Path: localCachePath}, {Path: string(partFor(localCachePath))}}
+	if err := m.RemoveFromCache(cached); err != nil {
+		// Не критичная ошибка, просто предупреждаем
+		fmt.Printf("Предупреждение: не удалось удалить файл из кэша %s: %v\n", localCachePath, err)
 	}
 
 	// 2. Удаляем конечную директорию, если она указана
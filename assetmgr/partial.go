@@ -0,0 +1,142 @@
+package assetmgr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Недокачанный файл хранится рядом с целевым как <файл>.part, а в <файл>.part.json -
+// откуда он скачивается и по каким признакам проверить, что файл на сервере не изменился.
+const partSuffix = ".part"
+
+// errPartMismatch - сервер не может продолжить недокачанный файл, скачивание начнется заново.
+var errPartMismatch = errors.New("скачивание начнется заново")
+
+// partState описывает источник недокачанного файла.
+type partState struct {
+	Source       string `json:"source"`
+	Size         int64  `json:"size"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// partFile - путь к .part-файлу.
+type partFile string
+
+func partFor(localPath string) partFile {
+	return partFile(localPath + partSuffix)
+}
+
+func (p partFile) statePath() string {
+	return string(p) + ".json"
+}
+
+// load возвращает сохраненное состояние и размер уже скачанной части (0, если докачивать нечего).
+func (p partFile) load() (partState, int64) {
+	var state partState
+	data, err := os.ReadFile(p.statePath())
+	if err != nil || json.Unmarshal(data, &state) != nil {
+		p.discard()
+		return partState{}, 0
+	}
+	fi, err := os.Stat(string(p))
+	if err != nil {
+		return state, 0
+	}
+	return state, fi.Size()
+}
+
+// discard удаляет недокачанный файл и его состояние.
+func (p partFile) discard() {
+	os.Remove(string(p))
+	os.Remove(p.statePath())
+}
+
+// write дописывает поток в .part-файл начиная с offset (0 - файл пишется заново).
+// При ошибке или отмене ctx скачанная часть сохраняется для докачки.
+func (p partFile) write(ctx context.Context, src io.Reader, state partState, offset int64, description string) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(p.statePath(), data, 0644); err != nil {
+		return fmt.Errorf("не удалось сохранить состояние загрузки: %w", err)
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	destFile, err := os.OpenFile(string(p), flags, 0644)
+	if err != nil {
+		return fmt.Errorf("не удалось создать локальный файл: %w", err)
+	}
+
+	report := reporterFrom(ctx)
+	progress := report.Progress(description, state.Size, offset)
+	_, err = io.Copy(io.MultiWriter(destFile, progress), src)
+	if err == nil {
+		// данные должны попасть на диск до переименования в целевой файл (complete)
+		err = destFile.Sync()
+	}
+	closeErr := destFile.Close()
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil && state.Size > 0 {
+		// FTP при обрыве соединения данных может просто закрыть поток
+		if fi, statErr := os.Stat(string(p)); statErr == nil && fi.Size() != state.Size {
+			err = fmt.Errorf("поток оборвался: скачано %d из %d байт", fi.Size(), state.Size)
+		}
+	}
+	if errors.Is(err, context.Canceled) {
+		report.Printf("\nСкачивание %s прервано, скачанная часть сохранена и будет продолжена при следующем запуске.\n", description)
+	}
+	return err
+}
+
+// complete переименовывает скачанный файл в localPath.
+func (p partFile) complete(localPath string) error {
+	if err := os.Rename(string(p), localPath); err != nil {
+		return fmt.Errorf("не удалось сохранить скачанный файл %s: %w", localPath, err)
+	}
+	os.Remove(p.statePath())
+	return nil
+}
+
+// parseContentRange разбирает заголовок "bytes 100-199/1000" и возвращает начало и полный размер.
+// Полный размер -1, если сервер его не сообщил ("bytes 100-199/*").
+func parseContentRange(header string) (start, total int64, ok bool) {
+	rangeSpec, found := strings.CutPrefix(header, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	span, size, found := strings.Cut(rangeSpec, "/")
+	if !found {
+		return 0, 0, false
+	}
+	first, _, found := strings.Cut(span, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	total = -1
+	if size != "*" {
+		if total, err = strconv.ParseInt(size, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	return start, total, true
+}
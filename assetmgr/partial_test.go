@@ -0,0 +1,138 @@
+package assetmgr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		header       string
+		start, total int64
+		ok           bool
+	}{
+		{"bytes 100-199/1000", 100, 1000, true},
+		{"bytes 0-0/1", 0, 1, true},
+		{"bytes 100-199/*", 100, -1, true},
+		{"bytes */1000", 0, 0, false},
+		{"bytes 100-199", 0, 0, false},
+		{"items 100-199/1000", 0, 0, false},
+		{"bytes abc-199/1000", 0, 0, false},
+		{"bytes 100-199/xyz", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		start, total, ok := parseContentRange(tt.header)
+		if start != tt.start || total != tt.total || ok != tt.ok {
+			t.Errorf("parseContentRange(%q) = %d, %d, %v; want %d, %d, %v",
+				tt.header, start, total, ok, tt.start, tt.total, tt.ok)
+		}
+	}
+}
+
+func TestResumeFrom(t *testing.T) {
+	saved := partState{Source: "http://host/f.zip", Size: 1000, ETag: `"v1"`}
+	tests := []struct {
+		name         string
+		status       int
+		contentRange string
+		etag         string
+		offset       int64
+		wantStart    int64
+		wantSize     int64
+		wantDiscard  bool
+		wantErr      bool
+	}{
+		{"докачка", http.StatusPartialContent, "bytes 400-999/1000", `"v1"`, 400, 400, 1000, false, false},
+		{"докачка без размера", http.StatusPartialContent, "bytes 400-999/*", `"v1"`, 400, 400, -1, false, false},
+		{"другое начало", http.StatusPartialContent, "bytes 0-999/1000", `"v1"`, 400, 0, 0, true, true},
+		{"только размер", http.StatusPartialContent, "bytes */1000", `"v1"`, 400, 0, 0, true, true},
+		{"мусор в заголовке", http.StatusPartialContent, "garbage", `"v1"`, 400, 0, 0, true, true},
+		{"другой ETag", http.StatusPartialContent, "bytes 400-999/1000", `"v2"`, 400, 0, 0, true, true},
+		{"If-Range: файл изменился", http.StatusOK, "", `"v2"`, 400, 0, 1200, false, false},
+		{"новое скачивание", http.StatusOK, "", `"v1"`, 0, 0, 1200, false, false},
+		{"диапазон не удовлетворим", http.StatusRequestedRangeNotSatisfiable, "", "", 400, 0, 0, true, true},
+		{"ошибка сервера", http.StatusBadGateway, "", "", 400, 0, 0, false, true},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Status: http.StatusText(tt.status), Header: http.Header{}, ContentLength: 1200}
+		resp.Header.Set("ETag", tt.etag)
+		if tt.contentRange != "" {
+			resp.Header.Set("Content-Range", tt.contentRange)
+		}
+		state, start, err := resumeFrom(resp, saved.Source, saved, tt.offset)
+		if (err != nil) != tt.wantErr || errors.Is(err, errPartMismatch) != tt.wantDiscard {
+			t.Errorf("%s: err = %v, want ошибку %v, удаление части %v", tt.name, err, tt.wantErr, tt.wantDiscard)
+			continue
+		}
+		if err == nil && (start != tt.wantStart || state.Size != tt.wantSize) {
+			t.Errorf("%s: начало %d, размер %d; want %d, %d", tt.name, start, state.Size, tt.wantStart, tt.wantSize)
+		}
+	}
+}
+
+var fixedModTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// fileServer отдает content по /f.bin с поддержкой Range и If-Range и считает GET-запросы.
+func fileServer(t *testing.T, content string, gets *atomic.Int32) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			gets.Add(1)
+		}
+		w.Header().Set("ETag", `"current"`)
+		http.ServeContent(w, r, "f.bin", fixedModTime, strings.NewReader(content))
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/f.bin"
+}
+
+func TestDownloadHTTPRestartsChangedFile(t *testing.T) {
+	var gets atomic.Int32
+	url := fileServer(t, "новое содержимое файла", &gets)
+	localPath := filepath.Join(t.TempDir(), "f.bin")
+
+	// Недокачанная часть старой версии файла: сервер ответит 200 на If-Range
+	part := partFor(localPath)
+	if err := os.WriteFile(string(part), []byte("старое"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(part.statePath(), []byte(`{"source":"`+url+`","size":100,"etag":"\"old\""}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (&Manager{}).downloadHTTP(context.Background(), url, localPath); err != nil {
+		t.Fatalf("downloadHTTP: %v", err)
+	}
+	if data, _ := os.ReadFile(localPath); string(data) != "новое содержимое файла" {
+		t.Errorf("скачано %q, want файл целиком с начала", data)
+	}
+	if _, err := os.Stat(part.statePath()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("состояние загрузки не удалено: %v", err)
+	}
+}
+
+func TestDownloadHTTPChecksSizeBeforeGet(t *testing.T) {
+	var gets atomic.Int32
+	url := fileServer(t, "содержимое", &gets)
+	localPath := filepath.Join(t.TempDir(), "f.bin")
+	if err := os.WriteFile(localPath, []byte("содержимое"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	skipped, err := (&Manager{}).downloadHTTP(context.Background(), url, localPath)
+	if err != nil || !skipped {
+		t.Fatalf("downloadHTTP = %v, %v; want пропуск готового файла", skipped, err)
+	}
+	if n := gets.Load(); n != 0 {
+		t.Errorf("GET-запросов %d, want 0: размер сверяется HEAD-запросом", n)
+	}
+}
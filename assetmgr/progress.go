@@ -0,0 +1,46 @@
+package assetmgr
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Reporter выводит ход скачивания: сообщения и прогресс. По умолчанию сообщения печатаются
+// в консоль, а у каждого скачивания свой прогресс-бар; параллельные скачивания
+// (goMH assets prefetch) передают через контекст общий многострочный индикатор.
+type Reporter interface {
+	Printf(format string, args ...interface{})
+	// Progress возвращает writer, отображающий скачанные байты; offset байт уже скачано.
+	// total равен -1, если размер неизвестен.
+	Progress(description string, total, offset int64) io.Writer
+}
+
+type reporterKey struct{}
+
+// WithReporter возвращает контекст, скачивания в котором выводят ход через r.
+func WithReporter(ctx context.Context, r Reporter) context.Context {
+	return context.WithValue(ctx, reporterKey{}, r)
+}
+
+func reporterFrom(ctx context.Context) Reporter {
+	if r, ok := ctx.Value(reporterKey{}).(Reporter); ok {
+		return r
+	}
+	return consoleReporter{}
+}
+
+// consoleReporter - вывод по умолчанию: отдельный прогресс-бар для каждого скачивания.
+type consoleReporter struct{}
+
+func (consoleReporter) Printf(format string, args ...interface{}) {
+	fmt.Printf(format, args...)
+}
+
+func (consoleReporter) Progress(description string, total, offset int64) io.Writer {
+	bar := CreateProgressBar(total, description)
+	if offset > 0 {
+		bar.Add64(offset)
+	}
+	return bar
+}
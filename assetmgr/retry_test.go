@@ -0,0 +1,106 @@
+package assetmgr
+
+import (
+	"context"
+	"fmt"
+	"goMH/config"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	policy := config.RetryPolicy{InitialDelaySeconds: 4, MaxDelaySeconds: 30}
+	tests := []struct {
+		policy  config.RetryPolicy
+		attempt int
+		base    time.Duration
+	}{
+		{policy, 1, 4 * time.Second},
+		{policy, 2, 8 * time.Second},
+		{policy, 3, 16 * time.Second},
+		{policy, 4, 30 * time.Second}, // 32 с ограничено максимумом
+		{policy, 50, 30 * time.Second},
+		{config.RetryPolicy{}, 1, defaultInitialDelay},
+		{config.RetryPolicy{}, 2, 2 * defaultInitialDelay},
+		{config.RetryPolicy{}, 10, defaultMaxDelay},
+	}
+	for _, tt := range tests {
+		low, high := tt.base-tt.base/4, tt.base+tt.base/4
+		seen := make(map[time.Duration]bool)
+		for i := 0; i < 1000; i++ {
+			delay := backoff(tt.policy, tt.attempt)
+			if delay < low || delay > high {
+				t.Fatalf("backoff(%+v, %d) = %s, want от %s до %s", tt.policy, tt.attempt, delay, low, high)
+			}
+			seen[delay] = true
+		}
+		if len(seen) < 2 {
+			t.Errorf("backoff(%+v, %d): паузы не различаются, разброса нет", tt.policy, tt.attempt)
+		}
+	}
+}
+
+func TestFetchSkipsPermanentlyFailedMirror(t *testing.T) {
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	var requests atomic.Int32
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "содержимое")
+	}))
+	defer flaky.Close()
+
+	m := &Manager{cfg: &config.Config{AssetsCachePath: t.TempDir()}}
+	var attempts []string
+	m.OnAttempt = func(url string, attempt int, err error) {
+		host := "missing"
+		if strings.HasPrefix(url, flaky.URL) {
+			host = "flaky"
+		}
+		attempts = append(attempts, fmt.Sprintf("%s#%d:%v", host, attempt, err == nil))
+	}
+
+	sources := []source{httpSource(missing.URL + "/f.bin"), httpSource(flaky.URL + "/f.bin")}
+	localPath := filepath.Join(t.TempDir(), "f.bin")
+	policy := config.RetryPolicy{Attempts: 3, InitialDelaySeconds: 1}
+	if _, err := m.fetch(context.Background(), sources, localPath, policy, "Test"); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+
+	// 404 - неустранимая ошибка: во второй попытке основной адрес не запрашивается
+	want := "missing#1:false, flaky#1:false, flaky#2:true"
+	if got := strings.Join(attempts, ", "); got != want {
+		t.Errorf("попытки: %s, want %s", got, want)
+	}
+	if data, _ := os.ReadFile(localPath); string(data) != "содержимое" {
+		t.Errorf("скачано %q", data)
+	}
+}
+
+func TestFetchStopsWhenAllMirrorsFailPermanently(t *testing.T) {
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+
+	m := &Manager{cfg: &config.Config{AssetsCachePath: t.TempDir()}}
+	var attempts int
+	m.OnAttempt = func(url string, attempt int, err error) { attempts++ }
+
+	sources := []source{httpSource(missing.URL + "/a.bin"), httpSource(missing.URL + "/b.bin")}
+	policy := config.RetryPolicy{Attempts: 5, InitialDelaySeconds: 60}
+	_, err := m.fetch(context.Background(), sources, filepath.Join(t.TempDir(), "f.bin"), policy, "Test")
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("fetch: err = %v, want ошибку 404", err)
+	}
+	if attempts != 2 {
+		t.Errorf("попыток %d, want 2: без пауз и повторов после неустранимых ошибок", attempts)
+	}
+}
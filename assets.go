@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"goMH/config"
+	"goMH/trust"
+	"goMH/tui"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// cmdAssets обслуживает каталог и кэш ресурсов: goMH assets hash|prefetch|ls|gc|purge.
+func (a *App) cmdAssets(args []string) int {
+	if len(args) == 0 {
+		tui.Error("Укажите действие: goMH assets hash [ID ресурса|файл ...] | prefetch [--all | --profile имя | ID ...] | ls | gc | purge [ID ...]")
+		return exitUsage
+	}
+	switch args[0] {
+	case "hash":
+		return a.cmdAssetsHash(args[1:])
+	case "prefetch":
+		return a.cmdAssetsPrefetch(args[1:])
+	case "ls":
+		return a.cmdAssetsList(args[1:])
+	case "gc":
+		return a.cmdAssetsGC(args[1:])
+	case "purge":
+		return a.cmdAssetsPurge(args[1:])
+	default:
+		tui.Error(fmt.Sprintf("Неизвестное действие: %s", args[0]))
+		return exitUsage
+	}
+}
+
+// cmdAssetsHash выводит SHA-256 для поля sha256 каталога. Ресурс каталога скачивается
+// во временную директорию без проверки хеша; вместо ID можно указать путь к файлу.
+// Без аргументов выводятся хеши всех ресурсов каталога.
+func (a *App) cmdAssetsHash(targets []string) int {
+	if len(targets) == 0 {
+		for id := range a.Cfg.AssetCatalog {
+			targets = append(targets, id)
+		}
+		sort.Strings(targets)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "gomh-hash-")
+	if err != nil {
+		tui.Error(err.Error())
+		return exitError
+	}
+	defer os.RemoveAll(tmpDir)
+
+	ctx, done := a.operationContext()
+	defer done()
+
+	code := exitOK
+	for _, target := range targets {
+		asset, isAsset := a.Cfg.AssetCatalog[target]
+		path := target
+		if isAsset {
+			path = filepath.Join(tmpDir, target)
+			if err := a.downloadAsset(ctx, asset, path); err != nil {
+				if ctx.Err() != nil {
+					printOutcome(ctx.Err())
+					return exitInterrupted
+				}
+				tui.Error(fmt.Sprintf("%s: не удалось скачать: %v", target, err))
+				code = exitError
+				continue
+			}
+		} else if _, err := os.Stat(target); err != nil {
+			tui.Error(fmt.Sprintf("%s: нет ни ресурса в каталоге, ни файла с таким именем", target))
+			code = exitError
+			continue
+		}
+
+		sum, err := trust.FileSHA256(path)
+		if err != nil {
+			tui.Error(fmt.Sprintf("%s: %v", target, err))
+			code = exitError
+			continue
+		}
+		note := ""
+		if isAsset {
+			switch {
+			case asset.SHA256 == "":
+				note = "в каталоге не задан"
+			case strings.EqualFold(asset.SHA256, sum):
+				note = tui.ColorGreen + "совпадает с каталогом" + tui.ColorReset
+			default:
+				note = tui.ColorRed + "ОТЛИЧАЕТСЯ от каталога" + tui.ColorReset
+			}
+		}
+		fmt.Printf("%-28s %s  %s\n", target, sum, note)
+	}
+	return code
+}
+
+// downloadAsset скачивает ресурс каталога в localPath без проверки хеша.
+func (a *App) downloadAsset(ctx context.Context, asset config.AssetInfo, localPath string) error {
+	var err error
+	if strings.EqualFold(asset.DownloadMethod, "FTP") && len(asset.Mirrors) == 0 {
+		parsedURL, _ := url.Parse(asset.URL)
+		_, err = a.AM.DownloadFTPWithProgress(ctx, parsedURL.Path, localPath)
+	} else {
+		_, err = a.AM.DownloadMirrors(ctx, asset.URLs(), localPath)
+	}
+	return err
+}
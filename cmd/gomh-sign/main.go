@@ -0,0 +1,126 @@
+// Утилита сопровождающего для подписи релизов и конфигурации goMH.
+//
+//	gomh-sign keygen
+//	gomh-sign manifest -key release.key -version 1.4.0 -url https://.../goMH.exe [-notes "..."] goMH.exe
+//	gomh-sign file -key release.key config.json
+//
+// Закрытый ключ (файл release.key) хранится только у сопровождающего.
+// Открытый ключ, выведенный keygen, встраивается в goMH (trust.publicKeyBase64).
+// Хранение ключа и порядок его смены описаны в пакете trust.
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"goMH/selfupdate"
+	"goMH/trust"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "keygen":
+		err = keygen()
+	case "manifest":
+		err = manifest(os.Args[2:])
+	case "file":
+		err = signFile(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Использование:")
+	fmt.Fprintln(os.Stderr, "  gomh-sign keygen")
+	fmt.Fprintln(os.Stderr, "  gomh-sign manifest -key файл -version X -url URL [-notes текст] goMH.exe")
+	fmt.Fprintln(os.Stderr, "  gomh-sign file -key файл config.json         создать config.json.sig")
+	os.Exit(2)
+}
+
+func keygen() error {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Открытый ключ (для trust.publicKeyBase64):")
+	fmt.Println(base64.StdEncoding.EncodeToString(pub))
+	fmt.Println("Закрытый ключ (сохраните в файл и не передавайте):")
+	fmt.Println(base64.StdEncoding.EncodeToString(priv.Seed()))
+	return nil
+}
+
+func manifest(args []string) error {
+	fs := flag.NewFlagSet("manifest", flag.ExitOnError)
+	keyPath := fs.String("key", "", "Файл с закрытым ключом")
+	version := fs.String("version", "", "Версия сборки")
+	url := fs.String("url", "", "URL, по которому будет опубликован exe")
+	notes := fs.String("notes", "", "Описание изменений")
+	fs.Parse(args)
+	if *keyPath == "" || *version == "" || *url == "" || fs.NArg() != 1 {
+		usage()
+	}
+
+	seed, err := os.ReadFile(*keyPath)
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать закрытый ключ: %w", err)
+	}
+	sum, err := trust.FileSHA256(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	m := selfupdate.Manifest{Version: *version, URL: *url, SHA256: sum, Notes: *notes}
+	m.Signature, err = trust.Sign(m.SignedMessage(), string(seed))
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// signFile создает рядом с файлом подпись <файл>.sig, которую проверяет config.LoadConfig.
+// Публиковать нужно оба файла; после любой правки конфигурации подпись создается заново.
+func signFile(args []string) error {
+	fs := flag.NewFlagSet("file", flag.ExitOnError)
+	keyPath := fs.String("key", "", "Файл с закрытым ключом")
+	fs.Parse(args)
+	if *keyPath == "" || fs.NArg() != 1 {
+		usage()
+	}
+
+	seed, err := os.ReadFile(*keyPath)
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать закрытый ключ: %w", err)
+	}
+	path := fs.Arg(0)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	signature, err := trust.Sign(data, string(seed))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".sig", []byte(signature+"\n"), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Подпись записана в %s.sig\n", path)
+	return nil
+}
@@ -0,0 +1,410 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goMH/assetmgr"
+	"goMH/config"
+	"goMH/core"
+	"goMH/dryrun"
+	"goMH/journal"
+	"goMH/tui"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Коды завершения в неинтерактивном режиме.
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+	// exitInterrupted - выход по Ctrl+C (как у консольных программ: 128 + SIGINT).
+	exitInterrupted = 130
+)
+
+// App объединяет всё, что нужно подкомандам командной строки.
+type App struct {
+	Cfg     *config.Config
+	AM      core.AssetManager
+	WU      core.WinUtils
+	Modules map[string]core.Installer
+	// Cache - менеджер ресурсов без оберток, для обслуживания кэша (goMH assets ls|gc|purge).
+	Cache *assetmgr.Manager
+
+	// Answers - ответы из файла (--answers), может быть nil.
+	Answers *config.Answers
+	// AskMissing разрешает спрашивать в консоли ответы, которых нет в сценарии.
+	AskMissing bool
+	// Plan не nil в режиме dry-run: AM и WU тогда записывают действия в него.
+	Plan *dryrun.Plan
+	// Journal - журнал аудита текущей сессии, может быть nil.
+	Journal *journal.Journal
+	// Interrupts отменяет текущую операцию по Ctrl+C, может быть nil.
+	Interrupts *interruptHandler
+}
+
+// printUsage выводит справку по подкомандам.
+func printUsage() {
+	out := os.Stderr
+	fmt.Fprintln(out, "Использование:")
+	fmt.Fprintln(out, "  goMH [-config путь]                          интерактивное меню")
+	fmt.Fprintln(out, "  goMH [-config путь] list                     список доступных модулей")
+	fmt.Fprintln(out, "  goMH [-config путь] [-answers файл] [-ask-missing] [-dry-run] run <модуль> [--ключ значение ...]")
+	fmt.Fprintln(out, "  goMH [-answers файл] [-ask-missing] [-dry-run] profile <профиль> [--модуль.ключ значение ...]")
+	fmt.Fprintln(out, "  goMH profile                                 список профилей установки")
+	fmt.Fprintln(out, "  goMH status [--json]                         состояние всех модулей, служб и кэша")
+	fmt.Fprintln(out, "  goMH update [--check]                        проверить и установить новую версию goMH")
+	fmt.Fprintln(out, "  goMH version                                 версия программы")
+	fmt.Fprintln(out, "  goMH [-config путь] config validate          проверить конфигурацию")
+	fmt.Fprintln(out, "  goMH config show [--effective]               итоговая конфигурация (с источником каждого значения)")
+	fmt.Fprintln(out, "  goMH secret set <имя> | list | rm <имя>      хранилище секретов для ссылок \"secret:имя\"")
+	fmt.Fprintln(out, "  goMH assets hash [ID ресурса|файл ...]       SHA-256 ресурсов для поля sha256 каталога")
+	fmt.Fprintln(out, "  goMH assets prefetch [--all | --profile имя | ID ...] [--workers 3]")
+	fmt.Fprintln(out, "                                               заранее скачать ресурсы и дистрибутивы iiko в кэш")
+	fmt.Fprintln(out, "  goMH assets ls [--json]                      файлы кэша ресурсов (манифест кэша)")
+	fmt.Fprintln(out, "  goMH assets gc [--max-size МБ]               удалить лишнее и давно не использованное из кэша")
+	fmt.Fprintln(out, "  goMH assets purge [ID ресурса ...]           очистить кэш целиком или файлы указанных ресурсов")
+	fmt.Fprintln(out, "  goMH report [--json]                         отправить инвентаризацию машины (раздел reporting)")
+	fmt.Fprintln(out, "  goMH serve [--listen 127.0.0.1:7700]         HTTP API для удаленного запуска модулей")
+	fmt.Fprintln(out, "  goMH journal list                            список журналов аудита")
+	fmt.Fprintln(out, "  goMH journal show [номер|файл]               показать журнал (по умолчанию последний)")
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "Удаленная конфигурация и config.json рядом с exe должны быть подписаны (config.json.sig, см. gomh-sign file).")
+	fmt.Fprintln(out, "Флаг -allow-unsigned-config отключает проверку подписи (только для отладки).")
+	fmt.Fprintln(out, "Флаг -site <площадка> выбирает площадку (бренд) из раздела sites конфигурации; выбор запоминается.")
+	fmt.Fprintln(out, "Значения конфигурации переопределяются файлом config.local.json рядом с exe")
+	fmt.Fprintln(out, "и переменными окружения GOMH_<РАЗДЕЛ>__<КЛЮЧ>, например GOMH_FRPC_CONFIG__SERVER_CONFIG__HOST.")
+	fmt.Fprintln(out, "Пароли задаются ссылками \"secret:имя\": значение берется из GOMH_SECRET_<ИМЯ> или из хранилища")
+	fmt.Fprintln(out, "secrets.vault в root_path (пароль хранилища - GOMH_VAULT_PASSPHRASE или ввод с консоли).")
+	fmt.Fprintln(out, "HTTP API требует заголовок \"Authorization: Bearer <токен>\": токен берется из GOMH_API_TOKEN")
+	fmt.Fprintln(out, "или из файла api.token в root_path (создается при первом запуске goMH serve).")
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "Примеры:")
+	fmt.Fprintln(out, "  goMH run iiko --component Front --version 900")
+	fmt.Fprintln(out, "  goMH run FRPC --local-port 5985 --alias SRV-01")
+	fmt.Fprintln(out, "  goMH run RemoteAccess --components TeamViewer,Getad")
+	fmt.Fprintln(out, "  goMH -answers pos-terminal.json run VComCaster")
+	fmt.Fprintln(out, "  goMH profile pos-terminal --iiko.version 900 --frpc.alias SRV-01")
+}
+
+// commandNeedsAdmin сообщает, требуются ли права администратора для подкоманды.
+// Интерактивное меню (без подкоманды) всегда их требует.
+func commandNeedsAdmin(args []string) bool {
+	if len(args) == 0 {
+		return true
+	}
+	switch args[0] {
+	case "list", "help", "journal", "status", "version", "config":
+		return false
+	case "profile":
+		// Без имени профиля команда только выводит список
+		return len(args) > 1
+	}
+	return true
+}
+
+// commandWritesJournal сообщает, нужно ли вести журнал аудита для подкоманды.
+// Команды только на чтение новых журналов не создают.
+func commandWritesJournal(args []string) bool {
+	return commandNeedsAdmin(args)
+}
+
+// sessionMode возвращает режим сессии для журнала.
+func sessionMode(dryRun bool) string {
+	if dryRun {
+		return "dry-run"
+	}
+	return "ok"
+}
+
+// Close завершает сессию: закрывает журнал аудита.
+func (a *App) Close() {
+	if a.Journal != nil {
+		a.Journal.Close()
+		a.Journal = nil
+	}
+}
+
+// operationContext возвращает контекст операции, который отменяется по Ctrl+C.
+// done нужно вызвать по завершении операции.
+func (a *App) operationContext() (ctx context.Context, done func()) {
+	if a.Interrupts == nil {
+		return context.WithCancel(context.Background())
+	}
+	return a.Interrupts.operation()
+}
+
+// RunCommand выполняет подкоманду и возвращает код завершения процесса.
+func (a *App) RunCommand(args []string) int {
+	switch args[0] {
+	case "list":
+		return a.cmdList()
+	case "run":
+		return a.cmdRun(args[1:])
+	case "profile":
+		return a.cmdProfile(args[1:])
+	case "status":
+		return a.cmdStatus(args[1:])
+	case "update":
+		return a.cmdUpdate(args[1:])
+	case "version":
+		return a.cmdVersion()
+	case "journal":
+		return a.cmdJournal(args[1:])
+	case "config":
+		return a.cmdConfig(args[1:])
+	case "secret":
+		return a.cmdSecret(args[1:])
+	case "serve":
+		return a.cmdServe(args[1:])
+	case "report":
+		return a.cmdReport(args[1:])
+	case "assets":
+		return a.cmdAssets(args[1:])
+	case "help":
+		printUsage()
+		return exitOK
+	default:
+		tui.Error(fmt.Sprintf("Неизвестная команда: %s", args[0]))
+		printUsage()
+		return exitUsage
+	}
+}
+
+// availableModules возвращает зарегистрированные модули в порядке,
+// указанном в секции modules конфигурации.
+func (a *App) availableModules() []core.Installer {
+	var result []core.Installer
+	for _, modDef := range a.Cfg.Modules {
+		if module, ok := a.Modules[modDef.ID]; ok {
+			result = append(result, module)
+		}
+	}
+	return result
+}
+
+// findModule ищет доступный модуль по ID без учета регистра.
+func (a *App) findModule(id string) (core.Installer, bool) {
+	for _, module := range a.availableModules() {
+		if strings.EqualFold(module.ID(), id) {
+			return module, true
+		}
+	}
+	return nil, false
+}
+
+func (a *App) cmdList() int {
+	modules := a.availableModules()
+	if len(modules) == 0 {
+		tui.Warn("В конфигурации не определено ни одного доступного модуля.")
+		return exitError
+	}
+	for _, module := range modules {
+		state := "-"
+		if lc, ok := module.(core.Lifecycle); ok {
+			if status, err := lc.Detect(a.AM, a.WU); err == nil {
+				state = status.String()
+			}
+		}
+		fmt.Printf("%-14s %-45s %s\n", module.ID(), module.MenuText(), state)
+	}
+	return exitOK
+}
+
+func (a *App) cmdRun(args []string) int {
+	if len(args) == 0 {
+		tui.Error("Не указан модуль. Список модулей: goMH list")
+		return exitUsage
+	}
+
+	module, ok := a.findModule(args[0])
+	if !ok {
+		tui.Error(fmt.Sprintf("Модуль '%s' не найден или не включен в конфигурации.", args[0]))
+		return exitUsage
+	}
+
+	params, err := parseParams(args[1:])
+	if err != nil {
+		tui.Error(err.Error())
+		return exitUsage
+	}
+
+	tui.Title(fmt.Sprintf("\n--- Запуск модуля %s ---", module.ID()))
+	err = a.runModule(module, a.prompterFor(module.ID(), params, false))
+	printOutcome(err)
+	switch {
+	case core.IsCancelled(err):
+		return exitInterrupted
+	case err != nil:
+		return exitError
+	}
+	return exitOK
+}
+
+// printOutcome выводит итог запуска модуля.
+func printOutcome(err error) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		tui.Warn("\n--- Операция прервана пользователем (Ctrl+C). ---")
+	case core.IsCancelled(err):
+		tui.Warn("\n--- Операция отменена пользователем. ---")
+	case err != nil:
+		tui.Error(fmt.Sprintf("\n--- ОПЕРАЦИЯ ЗАВЕРШИЛАСЬ С ОШИБКОЙ ---\n%v\n---------------------------------------\n", err))
+	default:
+		tui.Success("\n--- Операция завершена успешно. ---")
+	}
+}
+
+// runModule запускает модуль, записывая начало и результат в журнал.
+// В режиме dry-run после модуля выводится план действий, иначе отправляется
+// отчет об инвентаризации (если настроен раздел reporting).
+func (a *App) runModule(module core.Installer, p core.Prompter) error {
+	if a.Journal != nil {
+		a.Journal.SetModule(module.ID())
+		a.Journal.Write(journal.Record{Event: journal.EventModuleStart, Message: module.MenuText()})
+	}
+
+	ctx, done := a.operationContext()
+	start := time.Now()
+	err := module.Run(ctx, a.AM, a.WU, p)
+	done()
+
+	if a.Journal != nil {
+		rec := journal.Record{Event: journal.EventModuleFinish, Status: "ok", DurationMs: time.Since(start).Milliseconds()}
+		switch {
+		case core.IsCancelled(err):
+			rec.Status = "cancelled"
+			rec.Error = err.Error()
+		case err != nil:
+			rec.Status = "error"
+			rec.Error = err.Error()
+		}
+		a.Journal.Write(rec)
+		a.Journal.SetModule("")
+	}
+	if a.Plan != nil {
+		a.Plan.Print()
+		a.Plan.Reset()
+	}
+	a.reportAfterRun(module.ID())
+	return err
+}
+
+// stateLabel определяет состояние модуля для главного меню.
+// Модули без Lifecycle (например, сервисные утилиты) метки не имеют.
+func (a *App) stateLabel(module core.Installer) string {
+	lc, ok := module.(core.Lifecycle)
+	if !ok {
+		return ""
+	}
+	status, err := lc.Detect(a.AM, a.WU)
+	if err != nil {
+		return tui.ColorYellow + "[состояние неизвестно]" + tui.ColorReset
+	}
+	return tui.StateLabel(status)
+}
+
+// prompterFor подбирает Prompter для запуска модуля. Ответы из файла дополняются
+// параметрами командной строки, которые имеют приоритет.
+// В интерактивном режиме недостающие ответы всегда спрашиваются в консоли,
+// в неинтерактивном - только с флагом -ask-missing, иначе запуск завершается ошибкой.
+func (a *App) prompterFor(moduleID string, params core.Params, interactive bool) core.Prompter {
+	return a.prompterWith(moduleID, nil, params, interactive)
+}
+
+// prompterWith - то же, что prompterFor, но с базовыми ответами (например, из профиля),
+// которые переопределяются файлом ответов и параметрами командной строки.
+func (a *App) prompterWith(moduleID string, base map[string]string, params core.Params, interactive bool) core.Prompter {
+	answers := core.Params{}
+	for key, value := range base {
+		answers[key] = value
+	}
+	for key, value := range a.Answers.ForModule(moduleID) {
+		answers[key] = value
+	}
+	for key, value := range params {
+		answers[key] = value
+	}
+
+	switch {
+	case interactive && len(answers) == 0:
+		return tui.NewTerminalPrompter()
+	case interactive || a.AskMissing:
+		return tui.NewFallbackPrompter(answers, interactive)
+	default:
+		return tui.NewScriptedPrompter(answers)
+	}
+}
+
+func (a *App) cmdJournal(args []string) int {
+	logsDir := filepath.Join(a.Cfg.RootPath, "logs")
+	files, err := journal.List(logsDir)
+	if err != nil || len(files) == 0 {
+		tui.Warn(fmt.Sprintf("Журналы аудита в %s не найдены.", logsDir))
+		return exitError
+	}
+
+	if len(args) == 0 {
+		tui.Error("Укажите действие: goMH journal list | goMH journal show [номер|файл]")
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "list":
+		for i, file := range files {
+			fmt.Printf(" %d. %s\n", i+1, filepath.Base(file))
+		}
+		return exitOK
+	case "show":
+		// По умолчанию - последний журнал; номер соответствует выводу journal list
+		path := files[0]
+		if len(args) > 1 {
+			if idx, err := strconv.Atoi(args[1]); err == nil && idx >= 1 && idx <= len(files) {
+				path = files[idx-1]
+			} else {
+				path = args[1]
+			}
+		}
+		if err := journal.Show(path); err != nil {
+			tui.Error(err.Error())
+			return exitError
+		}
+		return exitOK
+	default:
+		tui.Error(fmt.Sprintf("Неизвестное действие журнала: %s", args[0]))
+		return exitUsage
+	}
+}
+
+// parseParams разбирает аргументы вида "--ключ значение" или "--ключ=значение".
+// Ключи приводятся к нижнему регистру. Флаг без значения считается равным "true".
+func parseParams(args []string) (core.Params, error) {
+	params := core.Params{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			return nil, fmt.Errorf("неожиданный аргумент '%s': параметры задаются в виде --ключ значение", arg)
+		}
+		key := strings.TrimLeft(arg, "-")
+		if key == "" {
+			return nil, fmt.Errorf("пустое имя параметра в '%s'", arg)
+		}
+
+		if k, v, found := strings.Cut(key, "="); found {
+			params[strings.ToLower(k)] = v
+			continue
+		}
+
+		value := "true"
+		if i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+			value = args[i+1]
+			i++
+		}
+		params[strings.ToLower(key)] = value
+	}
+	return params, nil
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Answers содержит заранее подготовленные ответы на вопросы модулей для автоматической установки.
+// Ключ верхнего уровня - ID модуля, вложенный - ключ вопроса (например, "version" для iiko).
+//
+// Пример (JSON):
+//
+//	{
+//	  "modules": {
+//	    "iiko": {"component": "Front", "version": "900", "patches": ["all"]},
+//	    "FRPC": {"alias": "SRV-01", "local-port": 5985}
+//	  }
+//	}
+type Answers struct {
+	Modules map[string]map[string]string
+}
+
+// rawAnswers - формат файла до нормализации: значения могут быть числами, списками и т.д.
+type rawAnswers struct {
+	Modules map[string]map[string]interface{} `json:"modules" yaml:"modules"`
+}
+
+// LoadAnswers загружает файл ответов в формате JSON или YAML (по расширению .yaml/.yml).
+func LoadAnswers(path string) (*Answers, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать файл ответов: %w", err)
+	}
+
+	var raw rawAnswers
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	default:
+		err = json.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга файла ответов %s: %w", path, err)
+	}
+
+	answers := &Answers{Modules: make(map[string]map[string]string, len(raw.Modules))}
+	for moduleID, values := range raw.Modules {
+		normalized := make(map[string]string, len(values))
+		for key, value := range values {
+			str, err := answerToString(value)
+			if err != nil {
+				return nil, fmt.Errorf("модуль '%s', ключ '%s': %w", moduleID, key, err)
+			}
+			normalized[strings.ToLower(key)] = str
+		}
+		answers.Modules[moduleID] = normalized
+	}
+	return answers, nil
+}
+
+// ForModule возвращает ответы для модуля (ID сравнивается без учета регистра).
+// Если ответов нет, возвращается nil.
+func (a *Answers) ForModule(moduleID string) map[string]string {
+	if a == nil {
+		return nil
+	}
+	for id, values := range a.Modules {
+		if strings.EqualFold(id, moduleID) {
+			return values
+		}
+	}
+	return nil
+}
+
+// answerToString приводит значение из файла к строке: списки склеиваются через запятую,
+// логические значения превращаются в "yes"/"no".
+func answerToString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		if v {
+			return "yes", nil
+		}
+		return "no", nil
+	case int:
+		return strconv.Itoa(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			str, err := answerToString(item)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, str)
+		}
+		return strings.Join(parts, ","), nil
+	default:
+		return "", fmt.Errorf("неподдерживаемый тип значения %T", value)
+	}
+}
@@ -3,9 +3,9 @@ package config
 import (
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"goMH/secrets"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -28,12 +28,15 @@ type IikoComponent struct {
 }
 
 type FrpcConfig struct {
-	InstallPath     string           `json:"install_path"`
-	ServiceName     string           `json:"service_name"`
-	PortRange       string           `json:"port_range"`
-	FrpcDownloadURL string           `json:"frpc_download_url"`
-	NssmDownloadURL string           `json:"nssm_download_url"`
-	ServerConfig    FrpcServerConfig `json:"server_config"`
+	InstallPath     string `json:"install_path"`
+	ServiceName     string `json:"service_name"`
+	PortRange       string `json:"port_range"`
+	FrpcDownloadURL string `json:"frpc_download_url"`
+	NssmDownloadURL string `json:"nssm_download_url"`
+	// FrpcMirrors и NssmMirrors - запасные адреса архивов FRPC и NSSM.
+	FrpcMirrors  []string         `json:"frpc_mirrors,omitempty"`
+	NssmMirrors  []string         `json:"nssm_mirrors,omitempty"`
+	ServerConfig FrpcServerConfig `json:"server_config"`
 }
 
 type FrpcServerConfig struct {
@@ -54,14 +57,16 @@ type MaintenanceConfig struct {
 	LogCollectorPaths []string `json:"LogCollectorPaths"`
 }
 
-// DTOConfig содержит настройки для установщика драйверов АТОЛ
+// DTOConfig содержит настройки для установщика драйверов АТОЛ.
+// Устаревшая секция: преобразуется в пакет DTO (см. PackageDefs).
 type DTOConfig struct {
 	MenuText    string `json:"menu_text"`
 	AssetID     string `json:"asset_id"`
 	InstallArgs string `json:"install_args"`
 }
 
-// UTMConfig содержит настройки для установщика УТМ
+// UTMConfig содержит настройки для установщика УТМ.
+// Устаревшая секция: преобразуется в пакет UTM (см. PackageDefs).
 type UTMConfig struct {
 	MenuText    string `json:"menu_text"`
 	AssetID     string `json:"asset_id"`
@@ -69,17 +74,62 @@ type UTMConfig struct {
 }
 
 type Config struct {
-	RootPath          string               `json:"root_path"`
-	AssetsCachePath   string               `json:"assets_cache_path"`
-	FTP               FTPConfig            `json:"ftp_config"`
-	Modules           []ModuleDef          `json:"modules"`
-	FrpcConfig        FrpcConfig           `json:"frpc_config"`
-	IikoConfig        IikoConfig           `json:"iiko_config"`
-	AssetCatalog      map[string]AssetInfo `json:"asset_catalog"`
-	TeamViewerConfig  TeamViewerConfig     `json:"TeamViewerConfig"`
-	MaintenanceConfig MaintenanceConfig    `json:"MaintenanceConfig"`
-	DTOConfig         DTOConfig            `json:"dto_config"`
-	UTMConfig         UTMConfig            `json:"utm_config"`
+	RootPath          string                `json:"root_path"`
+	AssetsCachePath   string                `json:"assets_cache_path"`
+	AssetsCacheMaxMB  int64                 `json:"assets_cache_max_mb"`
+	FTP               FTPConfig             `json:"ftp_config"`
+	Modules           []ModuleDef           `json:"modules"`
+	FrpcConfig        FrpcConfig            `json:"frpc_config"`
+	IikoConfig        IikoConfig            `json:"iiko_config"`
+	AssetCatalog      map[string]AssetInfo  `json:"asset_catalog"`
+	TeamViewerConfig  TeamViewerConfig      `json:"TeamViewerConfig"`
+	MaintenanceConfig MaintenanceConfig     `json:"MaintenanceConfig"`
+	DTOConfig         DTOConfig             `json:"dto_config"`
+	UTMConfig         UTMConfig             `json:"utm_config"`
+	Packages          []PackageDef          `json:"packages"`
+	Profiles          map[string]ProfileDef `json:"profiles"`
+	Update            UpdateConfig          `json:"update"`
+	DownloadRetry     RetryPolicy           `json:"download_retry"`
+	RegimeConfig      RegimeConfig          `json:"regime_config"`
+	Reporting         ReportingConfig       `json:"reporting"`
+	Sites             map[string]SiteDef    `json:"sites"`
+
+	// Site - имя выбранной площадки (см. sites.go), пустое - без площадки.
+	Site string `json:"-"`
+
+	// baseSource - путь или URL базовой конфигурации, origins - источники
+	// переопределенных значений (см. layers.go и Origin).
+	baseSource string
+	origins    map[string]string
+	// secretValues - значения, полученные по ссылкам "secret:имя" (см. Secrets).
+	secretValues []string
+	// missingSecrets - секреты, которые не удалось получить при загрузке (см. SecretError).
+	missingSecrets map[string]error
+}
+
+// RegimeConfig содержит учетную запись администратора, которая задается при установке Regime.
+// Пароль следует задавать ссылкой на секрет: "admin_password": "secret:regime_admin".
+type RegimeConfig struct {
+	AdminUser     string `json:"admin_user"`
+	AdminPassword string `json:"admin_password"`
+}
+
+// ReportingConfig содержит настройки отправки инвентаризации машины на центральный сервер
+// (после каждого запуска модуля и по команде goMH report). Пустой URL - отправка отключена.
+type ReportingConfig struct {
+	URL string `json:"url"`
+	// Token передается в заголовке "Authorization: Bearer". Следует задавать ссылкой на секрет.
+	Token string `json:"token"`
+	// Retries - число повторов при ошибке сети или ответе 5xx (по умолчанию 3).
+	Retries int `json:"retries"`
+	// OutboxLimit - сколько неотправленных отчетов хранить (по умолчанию 20).
+	OutboxLimit int `json:"outbox_limit"`
+}
+
+// UpdateConfig содержит настройки канала обновлений goMH.
+type UpdateConfig struct {
+	// ManifestURL - адрес подписанного манифеста последней сборки. Пустой - проверка отключена.
+	ManifestURL string `json:"manifest_url"`
 }
 
 type FTPConfig struct {
@@ -92,40 +142,199 @@ type ModuleDef struct {
 	ID string `json:"id"`
 }
 
+// HasModule сообщает, указан ли модуль в списке "modules".
+func (c *Config) HasModule(id string) bool {
+	for _, m := range c.Modules {
+		if m.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
 type AssetInfo struct {
 	URL            string `json:"url"`
 	Type           string `json:"type"`
 	Destination    string `json:"destination"`
 	DownloadMethod string `json:"download_method"`
+	// SHA256 - ожидаемый хеш файла (hex). Если задан, файл проверяется после скачивания
+	// и при взятии из кэша (см. goMH assets hash).
+	SHA256 string `json:"sha256,omitempty"`
+	// Mirrors - запасные адреса (http://, https:// или ftp://), которые пробуются после URL.
+	Mirrors []string `json:"mirrors,omitempty"`
+	// Retry переопределяет download_retry для этого ресурса.
+	Retry *RetryPolicy `json:"retry,omitempty"`
+}
+
+// URLs возвращает основной адрес ресурса и зеркала.
+func (a AssetInfo) URLs() []string {
+	return append([]string{a.URL}, a.Mirrors...)
 }
 
-// LoadConfig загружает конфигурацию из файла или по URL
-func LoadConfig(pathOrURL string) (*Config, error) {
-	var data []byte
+// RetryPolicy задает повторы скачивания. Каждая попытка - проход по всем адресам ресурса,
+// между попытками пауза удваивается от initial_delay_seconds до max_delay_seconds
+// со случайным разбросом ±25%. Нулевые значения заменяются значениями по умолчанию.
+type RetryPolicy struct {
+	Attempts            int `json:"attempts"`
+	InitialDelaySeconds int `json:"initial_delay_seconds"`
+	MaxDelaySeconds     int `json:"max_delay_seconds"`
+}
+
+// LoadConfig загружает конфигурацию из файла или по URL и проверяет ее подпись (см. signed.go).
+// Удаленная конфигурация без действительной подписи отклоняется, если не задан opts.AllowUnsigned.
+// Если сервер недоступен, используется последняя проверенная копия из кэша.
+// Поверх базовой конфигурации накладываются переопределения площадки, локальные переопределения и переменные
+// окружения GOMH_* (см. layers.go). Итоговая конфигурация проверяется (см. Validate): предупреждения выводятся,
+// а при ошибках возвращается *ValidationError со списком всех проблем.
+func LoadConfig(pathOrURL string, opts LoadOptions) (*Config, error) {
+	var data, signature []byte
 	var err error
 
 	// Проверяем, является ли строка URL-адресом
-	if strings.HasPrefix(pathOrURL, "http://") || strings.HasPrefix(pathOrURL, "https://") {
-		fmt.Printf("Загрузка конфигурации с URL: %s\n", pathOrURL)
-		resp, errHttp := http.Get(pathOrURL)
-		if errHttp != nil {
-			return nil, fmt.Errorf("ошибка при загрузке конфигурации по HTTP: %w", errHttp)
-		}
-		defer resp.Body.Close()
-		data, err = io.ReadAll(resp.Body)
+	if isURL(pathOrURL) {
+		data, signature, err = loadRemote(pathOrURL, opts)
 	} else {
 		fmt.Printf("Чтение локального файла конфигурации: %s\n", pathOrURL)
-		data, err = os.ReadFile(pathOrURL)
+		data, err = loadLocal(pathOrURL, opts)
+	}
+	if err != nil {
+		return nil, err
 	}
 
+	layers, err := newLayeredDoc(data)
+	if err != nil {
+		return nil, err
+	}
+	site, err := layers.selectSite(opts)
 	if err != nil {
-		return nil, fmt.Errorf("не удалось получить данные конфигурации: %w", err)
+		return nil, err
 	}
+	if site != "" {
+		if err := layers.overlaySite(site); err != nil {
+			return nil, err
+		}
+	}
+	if opts.LocalOverridePath != "" {
+		if err := layers.overlayFile(opts.LocalOverridePath); err != nil {
+			return nil, err
+		}
+	}
+	layers.overlayEnv(os.Environ())
 
+	merged, err := json.Marshal(layers.doc)
+	if err != nil {
+		return nil, err
+	}
 	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("ошибка парсинга JSON конфигурации: %w", err)
+	if err := json.Unmarshal(merged, &cfg); err != nil {
+		return nil, fmt.Errorf("ошибка разбора итоговой конфигурации: %w", err)
 	}
 
+	if !opts.SkipValidation {
+		problems := cfg.Validate()
+		for _, p := range problems {
+			if p.Warning {
+				fmt.Printf("Предупреждение конфигурации: %s\n", p)
+			}
+		}
+		if HasErrors(problems) {
+			return nil, &ValidationError{Problems: problems}
+		}
+	}
+
+	// Ссылки на секреты подставляются после проверки: проверка видит "secret:имя",
+	// а не сами пароли
+	if opts.Secrets != nil {
+		if opts.Secrets.VaultPath == "" {
+			opts.Secrets.VaultPath = filepath.Join(cfg.RootPath, secrets.VaultFileName)
+		}
+		// Секрет, который не удалось получить, не мешает запуску: ссылка остается как есть,
+		// а модуль, которому он нужен, завершится ошибкой (см. SecretError)
+		missing := make(map[string]error)
+		resolveSecretRefs(layers.doc, "", opts.Secrets, missing)
+		merged, _ := json.Marshal(layers.doc)
+		cfg = Config{}
+		if err := json.Unmarshal(merged, &cfg); err != nil {
+			return nil, fmt.Errorf("ошибка разбора итоговой конфигурации: %w", err)
+		}
+		cfg.secretValues = opts.Secrets.Values()
+		cfg.missingSecrets = missing
+	}
+	cfg.Site = site
+	cfg.baseSource = pathOrURL
+
+	// В кэш попадает только проверенная подписью конфигурация. Кэш хранится не в итоговом
+	// root_path (его может задать площадка), а там, где его найдет запуск без сервера
+	if signature != nil {
+		if err := saveCached(bootstrapRoot(opts), data, signature); err != nil {
+			fmt.Printf("Предупреждение: не удалось сохранить копию конфигурации: %v\n", err)
+		}
+	}
+	cfg.origins = layers.origins
+
 	return &cfg, nil
 }
+
+// Secrets возвращает значения, подставленные вместо ссылок "secret:имя".
+// Их нужно маскировать в выводе и журналах.
+func (c *Config) Secrets() []string {
+	return c.secretValues
+}
+
+// SecretError возвращает ошибку, если value осталось ссылкой "secret:имя": секрет не удалось
+// получить при загрузке или команда запущена без доступа к секретам. Модули проверяют
+// пароль перед использованием.
+func (c *Config) SecretError(value string) error {
+	if !secrets.IsRef(value) {
+		return nil
+	}
+	name := strings.TrimPrefix(value, secrets.RefPrefix)
+	if err, ok := c.missingSecrets[name]; ok {
+		return err
+	}
+	return fmt.Errorf("секрет '%s' не подставлен: команда запущена без доступа к секретам", name)
+}
+
+// resolveSecretRefs заменяет в дереве конфигурации строки "secret:имя" значениями секретов.
+// Ссылки на секреты, которые не удалось получить, остаются в дереве, а ошибки попадают
+// в missing (по имени секрета) и выводятся предупреждением.
+func resolveSecretRefs(node interface{}, path string, resolver *secrets.Resolver, missing map[string]error) interface{} {
+	switch v := node.(type) {
+	case string:
+		if !secrets.IsRef(v) {
+			return v
+		}
+		name := strings.TrimPrefix(v, secrets.RefPrefix)
+		if _, failed := missing[name]; failed {
+			return v
+		}
+		value, err := resolver.Resolve(name)
+		if err != nil {
+			missing[name] = err
+			fmt.Printf("Предупреждение: %s: %v. Действия, которым нужен этот секрет, завершатся ошибкой.\n", path, err)
+			return v
+		}
+		return value
+	case map[string]interface{}:
+		for key, child := range v {
+			// Секреты других площадок не нужны: выбранная уже наложена на конфигурацию
+			if path == "" && strings.EqualFold(key, "sites") {
+				continue
+			}
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			v[key] = resolveSecretRefs(child, childPath, resolver, missing)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = resolveSecretRefs(child, fmt.Sprintf("%s[%d]", path, i), resolver, missing)
+		}
+	}
+	return node
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
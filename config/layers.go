@@ -0,0 +1,182 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"goMH/secrets"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Конфигурация собирается из слоев, каждый следующий переопределяет предыдущий:
+//
+//  1. базовая конфигурация (удаленная или указанная в -config);
+//  2. переопределения выбранной площадки из раздела sites (см. sites.go);
+//  3. локальный config.local.json рядом с exe (LoadOptions.LocalOverridePath);
+//  4. переменные окружения GOMH_*.
+//
+// Объекты сливаются по ключам, остальные значения (включая массивы) заменяются целиком.
+// Имя переменной окружения - путь в JSON через "__" после префикса GOMH_, без учета
+// регистра: GOMH_FRPC_CONFIG__SERVER_CONFIG__HOST, GOMH_TEAMVIEWERCONFIG__SHORTURL.
+// Для нестроковых полей значение разбирается как JSON:
+// GOMH_MAINTENANCECONFIG__TEMPPATHS=["C:\\Temp"].
+
+const envPrefix = "GOMH_"
+
+// LocalOverrideName - имя файла локальных переопределений рядом с exe.
+const LocalOverrideName = "config.local.json"
+
+// layeredDoc - конфигурация в виде JSON-дерева и источники переопределенных значений.
+type layeredDoc struct {
+	doc map[string]interface{}
+	// origins хранит источник для путей (в нижнем регистре), значения которых переопределены.
+	origins map[string]string
+}
+
+// newLayeredDoc разбирает базовую конфигурацию. Она накладывается на пустую Config,
+// чтобы в дереве были все известные поля: по ним определяется тип значений из окружения.
+func newLayeredDoc(data []byte) (*layeredDoc, error) {
+	var base map[string]interface{}
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга JSON конфигурации: %w", err)
+	}
+	empty, _ := json.Marshal(Config{})
+	var doc map[string]interface{}
+	json.Unmarshal(empty, &doc)
+	mergeObject(doc, base, "", "", make(map[string]string))
+	return &layeredDoc{doc: doc, origins: make(map[string]string)}, nil
+}
+
+// overlayFile накладывает файл переопределений, если он существует.
+func (l *layeredDoc) overlayFile(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать %s: %w", path, err)
+	}
+	var overlay map[string]interface{}
+	if err := json.Unmarshal(data, &overlay); err != nil {
+		return fmt.Errorf("ошибка парсинга JSON %s: %w", path, err)
+	}
+	fmt.Printf("Применены локальные переопределения: %s\n", path)
+	mergeObject(l.doc, overlay, "", path, l.origins)
+	return nil
+}
+
+// overlayEnv применяет переменные окружения GOMH_*.
+func (l *layeredDoc) overlayEnv(environ []string) {
+	sort.Strings(environ)
+	for _, entry := range environ {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || !strings.HasPrefix(strings.ToUpper(name), envPrefix) || secrets.IsEnvName(name) {
+			continue
+		}
+		segments := strings.Split(name[len(envPrefix):], "__")
+		if _, ok := l.doc[matchKey(l.doc, segments[0])]; !ok {
+			fmt.Printf("Предупреждение: переменная окружения %s не соответствует разделу конфигурации и пропущена.\n", name)
+			continue
+		}
+		if err := l.setPath(segments, value, "env "+name); err != nil {
+			fmt.Printf("Предупреждение: переменная окружения %s пропущена: %v\n", name, err)
+		}
+	}
+}
+
+// setPath записывает значение по пути, создавая недостающие объекты.
+func (l *layeredDoc) setPath(segments []string, raw, origin string) error {
+	node := l.doc
+	var path []string
+	for i, segment := range segments {
+		if segment == "" {
+			return fmt.Errorf("пустой элемент пути")
+		}
+		key := matchKey(node, segment)
+		path = append(path, key)
+		if i == len(segments)-1 {
+			value, err := parseEnvValue(node[key], raw)
+			if err != nil {
+				return err
+			}
+			node[key] = value
+			l.origins[strings.ToLower(strings.Join(path, "."))] = origin
+			return nil
+		}
+		child, ok := node[key].(map[string]interface{})
+		if !ok {
+			if _, exists := node[key]; exists {
+				return fmt.Errorf("'%s' не является объектом", strings.Join(path, "."))
+			}
+			child = make(map[string]interface{})
+			node[key] = child
+		}
+		node = child
+	}
+	return nil
+}
+
+// Origin возвращает источник значения по пути JSON: базовую конфигурацию,
+// файл переопределений или переменную окружения.
+func (c *Config) Origin(path string) string {
+	path = strings.ToLower(path)
+	for {
+		if origin, ok := c.origins[path]; ok {
+			return origin
+		}
+		cut := strings.LastIndexAny(path, ".[")
+		if cut < 0 {
+			return c.baseSource
+		}
+		path = path[:cut]
+	}
+}
+
+// mergeObject сливает src в dst. Ключи сравниваются без учета регистра, как при
+// разборе JSON в структуру, чтобы "teamviewerconfig" переопределял "TeamViewerConfig".
+func mergeObject(dst, src map[string]interface{}, prefix, origin string, origins map[string]string) {
+	for srcKey, srcValue := range src {
+		key := matchKey(dst, srcKey)
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		srcObject, srcIsObject := srcValue.(map[string]interface{})
+		dstObject, dstIsObject := dst[key].(map[string]interface{})
+		if srcIsObject && dstIsObject {
+			mergeObject(dstObject, srcObject, path, origin, origins)
+			continue
+		}
+		dst[key] = srcValue
+		origins[strings.ToLower(path)] = origin
+	}
+}
+
+// matchKey возвращает существующий ключ объекта, совпадающий с name без учета регистра,
+// или name в нижнем регистре, если такого ключа нет.
+func matchKey(object map[string]interface{}, name string) string {
+	if _, ok := object[name]; ok {
+		return name
+	}
+	for key := range object {
+		if strings.EqualFold(key, name) {
+			return key
+		}
+	}
+	return strings.ToLower(name)
+}
+
+// parseEnvValue приводит значение переменной окружения к типу текущего значения:
+// строки берутся как есть, числа, флаги, массивы и объекты разбираются как JSON.
+func parseEnvValue(current interface{}, raw string) (interface{}, error) {
+	switch current.(type) {
+	case nil, string:
+		return raw, nil
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return nil, fmt.Errorf("ожидается значение в формате JSON: %w", err)
+	}
+	return value, nil
+}
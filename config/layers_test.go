@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigLayers(t *testing.T) {
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "config.json")
+	base := `{
+		"root_path": "C:\\MH",
+		"assets_cache_path": "C:\\MH\\cache",
+		"assets_cache_max_mb": 100,
+		"ftp_config": {"host": "ftp.example.com", "user": "base"},
+		"frpc_config": {"server_config": {"host": "frps.example.com", "user": "admin", "api_port": 7500}},
+		"TeamViewerConfig": {"ShortURL": "https://tv.example.com"}
+	}`
+	localPath := filepath.Join(dir, LocalOverrideName)
+	local := `{"ftp_config": {"host": "ftp.local", "user": "local"}, "frpc_config": {"server_config": {"api_port": 7600}}}`
+	if err := os.WriteFile(basePath, []byte(base), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(localPath, []byte(local), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOMH_FTP_CONFIG__USER", "env")
+	t.Setenv("GOMH_FRPC_CONFIG__SERVER_CONFIG__HOST", "frps.env")
+	t.Setenv("GOMH_ASSETS_CACHE_MAX_MB", "512")
+	t.Setenv("gomh_teamviewerconfig__shorturl", "https://tv.env")
+
+	cfg, err := LoadConfig(basePath, LoadOptions{LocalOverridePath: localPath})
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	tests := []struct {
+		path   string
+		got    interface{}
+		want   interface{}
+		origin string
+	}{
+		// Переопределено локальным файлом
+		{"ftp_config.host", cfg.FTP.Host, "ftp.local", localPath},
+		{"frpc_config.server_config.api_port", cfg.FrpcConfig.ServerConfig.APIPort, 7600, localPath},
+		// Переменная окружения важнее локального файла
+		{"ftp_config.user", cfg.FTP.User, "env", "env GOMH_FTP_CONFIG__USER"},
+		// Вложенный ключ, число в JSON и имя переменной в другом регистре
+		{"frpc_config.server_config.host", cfg.FrpcConfig.ServerConfig.Host, "frps.env", "env GOMH_FRPC_CONFIG__SERVER_CONFIG__HOST"},
+		{"assets_cache_max_mb", cfg.AssetsCacheMaxMB, int64(512), "env GOMH_ASSETS_CACHE_MAX_MB"},
+		{"TeamViewerConfig.ShortURL", cfg.TeamViewerConfig.ShortURL, "https://tv.env", "env gomh_teamviewerconfig__shorturl"},
+		// Не переопределено: значение и источник из базовой конфигурации
+		{"root_path", cfg.RootPath, `C:\MH`, basePath},
+		{"frpc_config.server_config.user", cfg.FrpcConfig.ServerConfig.User, "admin", basePath},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.path, tt.got, tt.want)
+		}
+		if origin := cfg.Origin(tt.path); origin != tt.origin {
+			t.Errorf("Origin(%s) = %q, want %q", tt.path, origin, tt.origin)
+		}
+	}
+}
+
+func TestOverlayEnvSkipsUnknownAndInvalid(t *testing.T) {
+	layers, err := newLayeredDoc([]byte(`{"root_path": "C:\\MH", "assets_cache_max_mb": 100}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	layers.overlayEnv([]string{
+		"GOMH_ASSETS_CACHE_MAX_MB=много",
+		"GOMH_NO_SUCH_SECTION=1",
+		"GOMH_SECRET_FTP=pass",
+		"GOMH_ROOT_PATH__SUB=x",
+		"PATH=/usr/bin",
+	})
+
+	if got := layers.doc["assets_cache_max_mb"]; got != float64(100) {
+		t.Errorf("assets_cache_max_mb = %v, want прежнее значение при неверном JSON", got)
+	}
+	if got := layers.doc["root_path"]; got != `C:\MH` {
+		t.Errorf("root_path = %v, want прежнее значение: строка не является объектом", got)
+	}
+	if len(layers.origins) != 0 {
+		t.Errorf("origins = %v, want пусто", layers.origins)
+	}
+	for _, key := range []string{"no_such_section", "secret_ftp"} {
+		if _, ok := layers.doc[key]; ok {
+			t.Errorf("в конфигурацию добавлен раздел %s", key)
+		}
+	}
+}
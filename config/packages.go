@@ -0,0 +1,102 @@
+package config
+
+import "strings"
+
+// Типы действий после установки пакета
+const (
+	PostStartService       = "start_service"
+	PostDefenderExclusion  = "defender_exclusion"
+	PostScheduledTask      = "scheduled_task"
+	defaultPackageExitCode = 0
+)
+
+// PackageDef описывает пакет - установщик стороннего производителя, который
+// скачивается из каталога ассетов и запускается с заданными аргументами.
+// Для таких установщиков не нужен отдельный Go-пакет: достаточно записи в "packages".
+type PackageDef struct {
+	ID          string `json:"id"`
+	MenuText    string `json:"menu_text"`
+	AssetID     string `json:"asset_id"`
+	InstallArgs string `json:"install_args"`
+	// SuccessExitCodes - коды завершения установщика, которые считаются успехом. Пустой - только 0.
+	SuccessExitCodes []int        `json:"success_exit_codes,omitempty"`
+	Detect           *DetectRule  `json:"detect,omitempty"`
+	PostInstall      []PostAction `json:"post_install,omitempty"`
+}
+
+// DetectRule задает, как определить, что пакет установлен. Достаточно одного поля;
+// если заполнено несколько, пакет считается установленным при выполнении любого.
+type DetectRule struct {
+	// Service - имя службы Windows.
+	Service string `json:"service,omitempty"`
+	// File - путь к файлу; относительный путь считается от root_path.
+	File string `json:"file,omitempty"`
+	// RegistryUninstall - начало DisplayName программы в ключах Uninstall реестра.
+	RegistryUninstall string `json:"registry_uninstall,omitempty"`
+}
+
+// PostAction - действие, выполняемое после успешной установки пакета.
+type PostAction struct {
+	Type string `json:"type"`
+	// Service - имя службы для start_service.
+	Service string `json:"service,omitempty"`
+	// Path - путь для defender_exclusion и исполняемый файл для scheduled_task.
+	Path string `json:"path,omitempty"`
+	// TaskName и WorkingDir - параметры задачи для scheduled_task.
+	TaskName   string `json:"task_name,omitempty"`
+	WorkingDir string `json:"working_dir,omitempty"`
+}
+
+// IsSuccessCode сообщает, считается ли код завершения установщика успешным.
+func (d PackageDef) IsSuccessCode(code int) bool {
+	if len(d.SuccessExitCodes) == 0 {
+		return code == defaultPackageExitCode
+	}
+	for _, c := range d.SuccessExitCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// PackageDefs возвращает все пакеты из конфигурации. Старые секции dto_config и
+// utm_config преобразуются в пакеты DTO и UTM, если такие ID не объявлены в "packages".
+func (c *Config) PackageDefs() []PackageDef {
+	defs := append([]PackageDef(nil), c.Packages...)
+	declared := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		declared[def.ID] = true
+	}
+
+	legacy := []PackageDef{
+		{ID: "DTO", MenuText: c.DTOConfig.MenuText, AssetID: c.DTOConfig.AssetID, InstallArgs: c.DTOConfig.InstallArgs},
+		{ID: "UTM", MenuText: c.UTMConfig.MenuText, AssetID: c.UTMConfig.AssetID, InstallArgs: c.UTMConfig.InstallArgs},
+	}
+	defaultMenuText := map[string]string{
+		"DTO": "Установить ДТО",
+		"UTM": "Установить УТМ (ЕГАИС)",
+	}
+	for _, def := range legacy {
+		if declared[def.ID] || def.AssetID == "" {
+			continue
+		}
+		if strings.TrimSpace(def.MenuText) == "" {
+			def.MenuText = defaultMenuText[def.ID]
+		}
+		defs = append(defs, def)
+	}
+	return defs
+}
+
+// ModuleAssets возвращает ресурсы каталога, которые использует модуль: ресурсы
+// встроенных модулей (см. builtinModuleAssets) и asset_id пакетов.
+func (c *Config) ModuleAssets(moduleID string) []string {
+	assets := append([]string(nil), builtinModuleAssets[moduleID]...)
+	for _, def := range c.PackageDefs() {
+		if def.ID == moduleID && def.AssetID != "" {
+			assets = append(assets, def.AssetID)
+		}
+	}
+	return assets
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProfileDef описывает профиль установки: набор модулей, которые ставятся за один запуск.
+//
+// Пример (JSON):
+//
+//	"profiles": {
+//	  "pos-terminal": {
+//	    "description": "Новая касса",
+//	    "modules": [
+//	      {"id": "iiko", "answers": {"component": "Front", "version": "900"}},
+//	      {"id": "VComCaster", "depends_on": ["iiko"]}
+//	    ]
+//	  }
+//	}
+type ProfileDef struct {
+	Description string          `json:"description"`
+	Modules     []ProfileModule `json:"modules"`
+}
+
+// ProfileModule - модуль в профиле с готовыми ответами и зависимостями.
+type ProfileModule struct {
+	ID        string                 `json:"id"`
+	Answers   map[string]interface{} `json:"answers"`
+	DependsOn []string               `json:"depends_on"`
+}
+
+// Params возвращает ответы модуля в том же виде, что и файл ответов (ключи в нижнем регистре).
+func (pm ProfileModule) Params() (map[string]string, error) {
+	params := make(map[string]string, len(pm.Answers))
+	for key, value := range pm.Answers {
+		str, err := answerToString(value)
+		if err != nil {
+			return nil, fmt.Errorf("модуль '%s', ключ '%s': %w", pm.ID, key, err)
+		}
+		params[strings.ToLower(key)] = str
+	}
+	return params, nil
+}
+
+// FindProfile ищет профиль по имени без учета регистра.
+func (c *Config) FindProfile(name string) (string, ProfileDef, bool) {
+	for profileName, profile := range c.Profiles {
+		if strings.EqualFold(profileName, name) {
+			return profileName, profile, true
+		}
+	}
+	return "", ProfileDef{}, false
+}
+
+// Order возвращает модули профиля в порядке установки: каждый модуль идет после своих зависимостей.
+// Модули без взаимных зависимостей сохраняют порядок из конфигурации.
+func (p ProfileDef) Order() ([]ProfileModule, error) {
+	index := make(map[string]int, len(p.Modules))
+	for i, module := range p.Modules {
+		key := strings.ToLower(module.ID)
+		if _, exists := index[key]; exists {
+			return nil, fmt.Errorf("модуль '%s' указан в профиле дважды", module.ID)
+		}
+		index[key] = i
+	}
+	for _, module := range p.Modules {
+		for _, dep := range module.DependsOn {
+			if _, ok := index[strings.ToLower(dep)]; !ok {
+				return nil, fmt.Errorf("модуль '%s' зависит от '%s', которого нет в профиле", module.ID, dep)
+			}
+		}
+	}
+
+	// Обход в глубину: 1 - модуль в обработке, 2 - уже добавлен в результат
+	state := make([]int, len(p.Modules))
+	var ordered []ProfileModule
+	var visit func(i int, path []string) error
+	visit = func(i int, path []string) error {
+		module := p.Modules[i]
+		switch state[i] {
+		case 1:
+			return fmt.Errorf("циклическая зависимость: %s -> %s", strings.Join(path, " -> "), module.ID)
+		case 2:
+			return nil
+		}
+		state[i] = 1
+		for _, dep := range module.DependsOn {
+			if err := visit(index[strings.ToLower(dep)], append(path, module.ID)); err != nil {
+				return err
+			}
+		}
+		state[i] = 2
+		ordered = append(ordered, module)
+		return nil
+	}
+
+	for i := range p.Modules {
+		if err := visit(i, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
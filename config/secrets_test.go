@@ -0,0 +1,40 @@
+package config
+
+import (
+	"goMH/secrets"
+	"path/filepath"
+	"testing"
+)
+
+func TestMissingSecretKeepsReference(t *testing.T) {
+	t.Setenv(secrets.EnvName("frps_admin"), "Passw0rd!")
+	doc := map[string]interface{}{
+		"ftp_config":    map[string]interface{}{"pass": "secret:ftp"},
+		"frpc_config":   map[string]interface{}{"server_config": map[string]interface{}{"pass": "secret:frps_admin"}},
+		"regime_config": map[string]interface{}{"admin_password": "secret:ftp"},
+	}
+	resolver := &secrets.Resolver{VaultPath: filepath.Join(t.TempDir(), secrets.VaultFileName)}
+	missing := make(map[string]error)
+	resolveSecretRefs(doc, "", resolver, missing)
+
+	if got := doc["frpc_config"].(map[string]interface{})["server_config"].(map[string]interface{})["pass"]; got != "Passw0rd!" {
+		t.Errorf("frps_admin = %v, want значение из окружения", got)
+	}
+	if got := doc["ftp_config"].(map[string]interface{})["pass"]; got != "secret:ftp" {
+		t.Errorf("ftp = %v, want ссылку secret:ftp без изменений", got)
+	}
+	if len(missing) != 1 || missing["ftp"] == nil {
+		t.Fatalf("missing = %v, want только ftp", missing)
+	}
+
+	cfg := &Config{missingSecrets: missing}
+	if err := cfg.SecretError("secret:ftp"); err != missing["ftp"] {
+		t.Errorf("SecretError(secret:ftp) = %v, want ошибку получения секрета", err)
+	}
+	if err := cfg.SecretError("secret:other"); err == nil {
+		t.Error("SecretError для неподставленной ссылки должен вернуть ошибку")
+	}
+	if err := cfg.SecretError("Passw0rd!"); err != nil {
+		t.Errorf("SecretError для значения = %v, want nil", err)
+	}
+}
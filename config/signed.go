@@ -0,0 +1,228 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"goMH/secrets"
+	"goMH/trust"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Конфигурация определяет, что goMH скачивает и запускает с правами администратора,
+// поэтому удаленный config.json подписывается ключом сопровождающих (gomh-sign file).
+// Подпись - отдельный файл с тем же именем и суффиксом ".sig" (config.json.sig),
+// содержащий base64 ed25519-подписи байтов конфигурации.
+//
+// Локальный файл, явно указанный в -config, считается доверенным: его выбрал тот, кто
+// запускает goMH. Если рядом с ним лежит .sig, подпись все равно проверяется. config.json
+// рядом с exe, найденный без флага, должен быть подписан (иначе нужен -allow-unsigned-config):
+// подброшенный туда файл не должен молча запускать установщики с правами администратора.
+
+// DefaultRootPath - корневая директория, если root_path не задан.
+const DefaultRootPath = `C:\MH`
+
+const (
+	signatureSuffix = ".sig"
+	cacheFileName   = "config.last-good.json"
+)
+
+// ErrUnsigned возвращается, если у конфигурации нет подписи.
+var ErrUnsigned = errors.New("конфигурация не подписана")
+
+// LoadOptions задает правила проверки при загрузке конфигурации.
+type LoadOptions struct {
+	// AllowUnsigned разрешает использовать конфигурацию без действительной подписи
+	// (флаг -allow-unsigned-config). Такая конфигурация не попадает в кэш.
+	AllowUnsigned bool
+	// RequireLocalSignature требует подпись .sig у локального файла: он найден неявно,
+	// а не указан в -config.
+	RequireLocalSignature bool
+	// SkipValidation отключает проверку содержимого: ее выполняет команда "config validate",
+	// которой нужен полный отчет, а не отказ в загрузке.
+	SkipValidation bool
+	// LocalOverridePath - путь к config.local.json. Пустой - локальные переопределения не используются.
+	LocalOverridePath string
+	// Secrets получает значения для ссылок "secret:имя". nil - ссылки остаются как есть
+	// (команды, которым пароли не нужны, не спрашивают пароль хранилища).
+	Secrets *secrets.Resolver
+	// Site - площадка, заданная флагом -site. Пустая - запомненная ранее или выбранная через PickSite.
+	Site string
+	// PickSite предлагает выбрать площадку, если она не задана и не запомнена. nil - без выбора.
+	PickSite func(sites []SiteDef) (string, error)
+}
+
+// loadRemote скачивает конфигурацию и ее подпись. Для проверенной конфигурации возвращается
+// и подпись: LoadConfig сохраняет их в кэш (см. saveCached и bootstrapRoot). При недоступности
+// сервера используется кэш, подпись тогда не возвращается.
+func loadRemote(url string, opts LoadOptions) (data, signature []byte, err error) {
+	fmt.Printf("Загрузка конфигурации с URL: %s\n", url)
+	data, err = fetch(url)
+	if err != nil {
+		fmt.Printf("Предупреждение: не удалось загрузить конфигурацию: %v\n", err)
+		data, err = loadCached(opts)
+		return data, nil, err
+	}
+
+	signature, sigErr := fetch(url + signatureSuffix)
+	if sigErr != nil {
+		sigErr = fmt.Errorf("%w: не удалось получить %s: %v", ErrUnsigned, url+signatureSuffix, sigErr)
+	} else {
+		sigErr = trust.Verify(data, string(signature))
+	}
+	if sigErr != nil {
+		if !opts.AllowUnsigned {
+			return nil, nil, fmt.Errorf("удаленная конфигурация отклонена: %w. Для запуска без проверки используйте -allow-unsigned-config", sigErr)
+		}
+		fmt.Printf("ВНИМАНИЕ: подпись конфигурации не проверена (%v). Используется -allow-unsigned-config.\n", sigErr)
+		return data, nil, nil
+	}
+
+	fmt.Println("Подпись конфигурации проверена.")
+	return data, signature, nil
+}
+
+// loadLocal читает локальный файл. Подпись проверяется, если рядом есть .sig;
+// без .sig файл принимается, только если подпись не требуется (opts.RequireLocalSignature).
+func loadLocal(path string, opts LoadOptions) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить данные конфигурации: %w", err)
+	}
+	signature, err := os.ReadFile(path + signatureSuffix)
+	if err != nil {
+		if !opts.RequireLocalSignature {
+			return data, nil
+		}
+		if !opts.AllowUnsigned {
+			return nil, fmt.Errorf("конфигурация %s отклонена: %w (нет %s). Подпишите ее (gomh-sign file), укажите файл явно в -config или используйте -allow-unsigned-config",
+				path, ErrUnsigned, filepath.Base(path)+signatureSuffix)
+		}
+		fmt.Printf("ВНИМАНИЕ: конфигурация %s не подписана. Используется -allow-unsigned-config.\n", path)
+		return data, nil
+	}
+	if err := trust.Verify(data, string(signature)); err != nil {
+		if !opts.AllowUnsigned {
+			return nil, fmt.Errorf("конфигурация %s не прошла проверку подписи: %w", path, err)
+		}
+		fmt.Printf("ВНИМАНИЕ: подпись %s недействительна. Используется -allow-unsigned-config.\n", path)
+	}
+	return data, nil
+}
+
+// loadCached читает последнюю проверенную конфигурацию и заново проверяет ее подпись,
+// чтобы подмена файла в кэше не прошла незамеченной.
+func loadCached(opts LoadOptions) ([]byte, error) {
+	path := findCached(opts)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("сервер конфигурации недоступен, а сохраненной копии нет (%s)", path)
+	}
+	signature, err := os.ReadFile(path + signatureSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("у сохраненной копии конфигурации нет подписи: %w", err)
+	}
+	if err := trust.Verify(data, string(signature)); err != nil {
+		return nil, fmt.Errorf("сохраненная копия конфигурации %s не прошла проверку подписи: %w", path, err)
+	}
+
+	info, _ := os.Stat(path)
+	fmt.Printf("Используется сохраненная копия конфигурации от %s.\n", info.ModTime().Format("02.01.2006 15:04"))
+	return data, nil
+}
+
+// saveCached сохраняет проверенную конфигурацию и подпись в rootPath. Оба файла пишутся
+// через временные: после сбоя остается либо прежняя пара, либо конфигурация с чужой
+// подписью, которую loadCached отклонит, но не обрезанный файл.
+func saveCached(rootPath string, data, signature []byte) error {
+	path := cachePath(rootPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, data, 0644); err != nil {
+		return err
+	}
+	return writeFileAtomic(path+signatureSuffix, signature, 0644)
+}
+
+func cachePath(rootPath string) string {
+	return filepath.Join(rootPath, cacheFileName)
+}
+
+// bootstrapRoots возвращает директории, известные до загрузки конфигурации: root_path
+// из локальных переопределений, GOMH_ROOT_PATH и DefaultRootPath. В первой из них хранятся
+// кэш конфигурации и выбранная площадка: root_path итоговой конфигурации (с учетом
+// площадки) без сервера конфигурации не узнать.
+func bootstrapRoots(opts LoadOptions) []string {
+	var roots []string
+	if opts.LocalOverridePath != "" {
+		var local struct {
+			RootPath string `json:"root_path"`
+		}
+		if data, err := os.ReadFile(opts.LocalOverridePath); err == nil && json.Unmarshal(data, &local) == nil && local.RootPath != "" {
+			roots = append(roots, local.RootPath)
+		}
+	}
+	if root := os.Getenv(envPrefix + "ROOT_PATH"); root != "" {
+		roots = append(roots, root)
+	}
+	return append(roots, DefaultRootPath)
+}
+
+// bootstrapRoot - директория для кэша конфигурации и выбора площадки (см. bootstrapRoots).
+func bootstrapRoot(opts LoadOptions) string {
+	return bootstrapRoots(opts)[0]
+}
+
+// findCached ищет кэш, когда конфигурация еще не загружена, по bootstrapRoots. Если кэша
+// нет нигде, возвращается путь в bootstrapRoot (для сообщения об ошибке).
+func findCached(opts LoadOptions) string {
+	for _, root := range bootstrapRoots(opts) {
+		if _, err := os.Stat(cachePath(root)); err == nil {
+			return cachePath(root)
+		}
+	}
+	return cachePath(bootstrapRoot(opts))
+}
+
+// writeFileAtomic записывает файл через временный файл в той же директории.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmpFile.Write(data)
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpFile.Name(), perm)
+	}
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+	}
+	return err
+}
+
+func fetch(url string) ([]byte, error) {
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("сервер вернул ошибку: %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
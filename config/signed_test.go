@@ -0,0 +1,210 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"goMH/trust"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testConfigData = []byte(`{"root_path": "C:\\MH"}`)
+
+// signWithTestKey подменяет встроенный ключ ключом теста и подписывает data.
+func signWithTestKey(t *testing.T, data []byte) []byte {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(trust.SetPublicKey(pub))
+	sig, err := trust.Sign(data, base64.StdEncoding.EncodeToString(priv.Seed()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []byte(sig)
+}
+
+// configServer отдает config.json и, если signature не nil, config.json.sig.
+func configServer(t *testing.T, data, signature []byte) string {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/config.json", func(w http.ResponseWriter, r *http.Request) { w.Write(data) })
+	if signature != nil {
+		mux.HandleFunc("/config.json.sig", func(w http.ResponseWriter, r *http.Request) { w.Write(signature) })
+	}
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv.URL + "/config.json"
+}
+
+// localRoot возвращает LoadOptions, в которых root_path (для поиска кэша) задан
+// локальными переопределениями.
+func localRoot(t *testing.T, root string) LoadOptions {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), LocalOverrideName)
+	data, _ := json.Marshal(map[string]string{"root_path": root})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return LoadOptions{LocalOverridePath: path}
+}
+
+func TestLoadRemoteSigned(t *testing.T) {
+	sig := signWithTestKey(t, testConfigData)
+	data, signature, err := loadRemote(configServer(t, testConfigData, sig), LoadOptions{})
+	if err != nil {
+		t.Fatalf("loadRemote: %v", err)
+	}
+	if string(data) != string(testConfigData) || string(signature) != string(sig) {
+		t.Errorf("loadRemote вернул %q и подпись %q", data, signature)
+	}
+}
+
+func TestLoadRemoteRejectsTampered(t *testing.T) {
+	sig := signWithTestKey(t, testConfigData)
+	tampered := []byte(`{"root_path": "D:\\Evil"}`)
+	url := configServer(t, tampered, sig)
+
+	if _, _, err := loadRemote(url, LoadOptions{}); !errors.Is(err, trust.ErrBadSignature) {
+		t.Errorf("loadRemote: err = %v, want ErrBadSignature", err)
+	}
+	// С -allow-unsigned-config конфигурация принимается, но без подписи - в кэш она не попадет
+	data, signature, err := loadRemote(url, LoadOptions{AllowUnsigned: true})
+	if err != nil || string(data) != string(tampered) || signature != nil {
+		t.Errorf("loadRemote(AllowUnsigned) = %q, %q, %v; want данные без подписи", data, signature, err)
+	}
+}
+
+func TestLoadRemoteRejectsUnsigned(t *testing.T) {
+	signWithTestKey(t, testConfigData)
+	url := configServer(t, testConfigData, nil)
+
+	if _, _, err := loadRemote(url, LoadOptions{}); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("loadRemote: err = %v, want ErrUnsigned", err)
+	}
+	if _, signature, err := loadRemote(url, LoadOptions{AllowUnsigned: true}); err != nil || signature != nil {
+		t.Errorf("loadRemote(AllowUnsigned): подпись %q, err %v; want nil, nil", signature, err)
+	}
+}
+
+func TestLoadRemoteFallsBackToCache(t *testing.T) {
+	sig := signWithTestKey(t, testConfigData)
+	root := t.TempDir()
+	if err := saveCached(root, testConfigData, sig); err != nil {
+		t.Fatalf("saveCached: %v", err)
+	}
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL + "/config.json"
+	srv.Close() // сервер недоступен
+
+	data, signature, err := loadRemote(url, localRoot(t, root))
+	if err != nil {
+		t.Fatalf("loadRemote: %v", err)
+	}
+	if string(data) != string(testConfigData) || signature != nil {
+		t.Errorf("loadRemote вернул %q, подпись %q; want копию из кэша без подписи", data, signature)
+	}
+
+	// Подмененная копия в кэше не принимается
+	if err := os.WriteFile(cachePath(root), []byte(`{"root_path": "D:\\Evil"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := loadRemote(url, localRoot(t, root)); !errors.Is(err, trust.ErrBadSignature) {
+		t.Errorf("loadRemote с подмененным кэшем: err = %v, want ErrBadSignature", err)
+	}
+}
+
+func TestSaveCachedLeavesNoTempFiles(t *testing.T) {
+	root := t.TempDir()
+	if err := saveCached(root, testConfigData, []byte("sig")); err != nil {
+		t.Fatalf("saveCached: %v", err)
+	}
+	entries, _ := os.ReadDir(root)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if len(names) != 2 || names[0] != cacheFileName || names[1] != cacheFileName+signatureSuffix {
+		t.Errorf("файлы в root_path: %v, want только %s и подпись", names, cacheFileName)
+	}
+}
+
+func TestOfflineStartWithSiteRootPath(t *testing.T) {
+	// DefaultRootPath (на Linux - относительный путь) создается во временной директории
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	data := []byte(`{"root_path": "base-root", "sites": {"brand-a": {"overrides": {"root_path": "site-root"}}}}`)
+	sig := signWithTestKey(t, data)
+	srv := httptest.NewServer(http.FileServer(http.Dir(writeSigned(t, data, sig))))
+	url := srv.URL + "/config.json"
+
+	cfg, err := LoadConfig(url, LoadOptions{Site: "brand-a", SkipValidation: true})
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.RootPath != "site-root" {
+		t.Fatalf("root_path = %s, want site-root", cfg.RootPath)
+	}
+
+	// Без сервера площадка и конфигурация берутся оттуда же, где их ищет запуск
+	srv.Close()
+	cfg, err = LoadConfig(url, LoadOptions{SkipValidation: true})
+	if err != nil {
+		t.Fatalf("LoadConfig без сервера: %v", err)
+	}
+	if cfg.Site != "brand-a" || cfg.RootPath != "site-root" {
+		t.Errorf("без сервера: площадка %q, root_path %s; want brand-a и site-root", cfg.Site, cfg.RootPath)
+	}
+}
+
+// writeSigned записывает config.json и подпись во временную директорию.
+func writeSigned(t *testing.T, data, signature []byte) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.json"+signatureSuffix), signature, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLoadLocalRequiresSignature(t *testing.T) {
+	sig := signWithTestKey(t, testConfigData)
+	unsigned := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(unsigned, testConfigData, 0644); err != nil {
+		t.Fatal(err)
+	}
+	signed := filepath.Join(writeSigned(t, testConfigData, sig), "config.json")
+
+	tests := []struct {
+		name string
+		path string
+		opts LoadOptions
+		ok   bool
+	}{
+		{"указан в -config", unsigned, LoadOptions{}, true},
+		{"найден рядом с exe", unsigned, LoadOptions{RequireLocalSignature: true}, false},
+		{"найден рядом с exe, -allow-unsigned-config", unsigned, LoadOptions{RequireLocalSignature: true, AllowUnsigned: true}, true},
+		{"найден рядом с exe, подписан", signed, LoadOptions{RequireLocalSignature: true}, true},
+	}
+	for _, tt := range tests {
+		_, err := loadLocal(tt.path, tt.opts)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want принят %v", tt.name, err, tt.ok)
+		}
+		if err != nil && !errors.Is(err, ErrUnsigned) {
+			t.Errorf("%s: err = %v, want ErrUnsigned", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SiteDef описывает площадку - бренд или сеть со своим сервером FRPS, учетными данными FTP,
+// модулем TeamViewer и т.п. Overrides накладываются на базовую конфигурацию так же,
+// как config.local.json (объекты сливаются, остальные значения заменяются).
+//
+// Пример (JSON):
+//
+//	"sites": {
+//	  "brand-a": {
+//	    "description": "Сеть A",
+//	    "overrides": {
+//	      "frpc_config": {"server_config": {"host": "frps.brand-a.ru", "pass": "secret:brand_a_frps"}},
+//	      "TeamViewerConfig": {"ShortURL": "https://get.teamviewer.com/brand-a"}
+//	    }
+//	  }
+//	}
+type SiteDef struct {
+	Name        string                 `json:"-"`
+	Description string                 `json:"description"`
+	Overrides   map[string]interface{} `json:"overrides"`
+}
+
+// siteFileName - файл в bootstrapRoot, в котором запоминается выбранная площадка.
+const siteFileName = "site.txt"
+
+// siteNamePattern - допустимое имя площадки: оно попадает в имена секций frpc.ini и файлов.
+var siteNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// selectSite определяет площадку: из opts.Site, затем запомненную ранее, затем через
+// opts.PickSite. Выбор через флаг или список запоминается рядом с кэшем конфигурации
+// (см. bootstrapRoot), чтобы площадку можно было определить и без сервера конфигурации.
+// Пустая строка означает работу без площадки.
+func (l *layeredDoc) selectSite(opts LoadOptions) (string, error) {
+	sites, err := l.sites()
+	if err != nil {
+		return "", err
+	}
+	if len(sites) == 0 {
+		if opts.Site != "" {
+			return "", fmt.Errorf("площадка '%s' не найдена: в конфигурации нет раздела sites", opts.Site)
+		}
+		return "", nil
+	}
+
+	rememberPath := filepath.Join(bootstrapRoot(opts), siteFileName)
+	if opts.Site != "" {
+		name, ok := findSite(sites, opts.Site)
+		if !ok {
+			return "", fmt.Errorf("площадка '%s' не найдена. Доступные: %s", opts.Site, strings.Join(siteNames(sites), ", "))
+		}
+		rememberSite(rememberPath, name)
+		return name, nil
+	}
+
+	if data, err := os.ReadFile(rememberPath); err == nil {
+		if name, ok := findSite(sites, strings.TrimSpace(string(data))); ok {
+			fmt.Printf("Площадка: %s (выбрана ранее, сменить можно флагом -site)\n", name)
+			return name, nil
+		}
+		fmt.Printf("Предупреждение: запомненной площадки '%s' больше нет в конфигурации.\n", strings.TrimSpace(string(data)))
+	}
+
+	if opts.PickSite == nil {
+		fmt.Println("Предупреждение: площадка не выбрана (флаг -site), используется базовая конфигурация.")
+		return "", nil
+	}
+	var list []SiteDef
+	for _, name := range siteNames(sites) {
+		site := sites[name]
+		site.Name = name
+		list = append(list, site)
+	}
+	name, err := opts.PickSite(list)
+	if err != nil {
+		return "", err
+	}
+	rememberSite(rememberPath, name)
+	return name, nil
+}
+
+// overlaySite накладывает переопределения площадки.
+func (l *layeredDoc) overlaySite(name string) error {
+	sites, err := l.sites()
+	if err != nil {
+		return err
+	}
+	mergeObject(l.doc, sites[name].Overrides, "", "site "+name, l.origins)
+	return nil
+}
+
+func (l *layeredDoc) sites() (map[string]SiteDef, error) {
+	raw, ok := l.doc[matchKey(l.doc, "sites")]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	data, _ := json.Marshal(raw)
+	var sites map[string]SiteDef
+	if err := json.Unmarshal(data, &sites); err != nil {
+		return nil, fmt.Errorf("ошибка в разделе sites: %w", err)
+	}
+	return sites, nil
+}
+
+func rememberSite(path, name string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+		err = os.WriteFile(path, []byte(name+"\n"), 0644)
+		if err == nil {
+			return
+		}
+	}
+	fmt.Printf("Предупреждение: не удалось запомнить выбор площадки в %s\n", path)
+}
+
+func findSite(sites map[string]SiteDef, name string) (string, bool) {
+	for siteName := range sites {
+		if strings.EqualFold(siteName, name) {
+			return siteName, true
+		}
+	}
+	return "", false
+}
+
+func siteNames(sites map[string]SiteDef) []string {
+	names := make([]string, 0, len(sites))
+	for name := range sites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
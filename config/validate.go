@@ -0,0 +1,374 @@
+package config
+
+import (
+	"fmt"
+	"goMH/secrets"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Problem - найденная при проверке ошибка или предупреждение конфигурации.
+// Path указывает место в JSON, например "packages[0].asset_id".
+type Problem struct {
+	Path    string
+	Message string
+	Warning bool
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s", p.Path, p.Message)
+}
+
+// ValidationError возвращается LoadConfig, если конфигурация содержит ошибки.
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	var lines []string
+	for _, p := range e.Problems {
+		if !p.Warning {
+			lines = append(lines, "  "+p.String())
+		}
+	}
+	return fmt.Sprintf("конфигурация содержит ошибки (%d):\n%s", len(lines), strings.Join(lines, "\n"))
+}
+
+// HasErrors сообщает, есть ли среди проблем ошибки (а не только предупреждения).
+func HasErrors(problems []Problem) bool {
+	for _, p := range problems {
+		if !p.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// builtinModuleAssets - ресурсы каталога, которые встроенные модули запрашивают по имени.
+// Проверяются, только если модуль указан в "modules".
+var builtinModuleAssets = map[string][]string{
+	"VComCaster":   {"VComCaster_Package", "Com0Com_Installer"},
+	"Regime":       {"Regime_Installer"},
+	"RemoteAccess": {"LiteManager_Installer"},
+}
+
+// sha256Pattern - хеш SHA-256 в шестнадцатеричном виде.
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// validator накапливает проблемы по мере обхода конфигурации.
+type validator struct {
+	problems []Problem
+}
+
+func (v *validator) errorf(path, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warnf(path, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{Path: path, Message: fmt.Sprintf(format, args...), Warning: true})
+}
+
+// Validate проверяет конфигурацию целиком, кроме соответствия ID модулей реализациям
+// (см. ValidateModules): ссылки на каталог ассетов, методы загрузки, диапазон портов FRPC,
+// описания пакетов и профилей. Пароли в открытом виде и адреса http:// дают предупреждения.
+func (c *Config) Validate() []Problem {
+	v := &validator{}
+	if c.RootPath == "" {
+		v.errorf("root_path", "не указан")
+	}
+	if c.AssetsCachePath == "" {
+		v.errorf("assets_cache_path", "не указан")
+	}
+	if c.AssetsCacheMaxMB < 0 {
+		v.errorf("assets_cache_max_mb", "не может быть отрицательным (0 - без ограничения)")
+	}
+	v.checkPassword("ftp_config.pass", c.FTP.Pass)
+	c.validateFrpc(v)
+	c.validateRegime(v)
+	c.validateReporting(v)
+	c.validateSites(v)
+	c.validatePackages(v)
+	c.validateProfiles(v)
+	c.validateAssets(v)
+	v.checkURL("TeamViewerConfig.ShortURL", c.TeamViewerConfig.ShortURL)
+	v.checkURL("TeamViewerConfig.ApiURL", c.TeamViewerConfig.ApiURL)
+	v.checkURL("update.manifest_url", c.Update.ManifestURL)
+	v.checkRetry("download_retry", c.DownloadRetry)
+	return v.problems
+}
+
+// ValidateModules проверяет, что для каждого ID из "modules" и профилей есть
+// зарегистрированная реализация. registered - ID встроенных модулей и пакетов.
+func (c *Config) ValidateModules(registered []string) []Problem {
+	v := &validator{}
+	known := make(map[string]bool, len(registered))
+	for _, id := range registered {
+		known[strings.ToLower(id)] = true
+	}
+
+	seen := make(map[string]bool)
+	for i, m := range c.Modules {
+		path := fmt.Sprintf("modules[%d].id", i)
+		switch {
+		case m.ID == "":
+			v.errorf(path, "не указан")
+		case !known[strings.ToLower(m.ID)]:
+			v.errorf(path, "модуль '%s' не реализован в этой версии goMH", m.ID)
+		case seen[m.ID]:
+			v.warnf(path, "модуль '%s' указан повторно", m.ID)
+		}
+		seen[m.ID] = true
+	}
+
+	for _, name := range sortedKeys(c.Profiles) {
+		for i, m := range c.Profiles[name].Modules {
+			if m.ID != "" && !known[strings.ToLower(m.ID)] {
+				v.errorf(fmt.Sprintf("profiles.%s.modules[%d].id", name, i), "модуль '%s' не реализован в этой версии goMH", m.ID)
+			}
+		}
+	}
+	return v.problems
+}
+
+func (c *Config) validateAssets(v *validator) {
+	for _, name := range sortedKeys(c.AssetCatalog) {
+		asset := c.AssetCatalog[name]
+		path := "asset_catalog." + name
+		if asset.URL == "" {
+			v.errorf(path+".url", "не указан")
+		}
+		switch strings.ToUpper(asset.DownloadMethod) {
+		case "", "HTTP":
+			v.checkURL(path+".url", asset.URL)
+		case "FTP":
+			if asset.URL != "" && !strings.HasPrefix(asset.URL, "ftp://") {
+				v.warnf(path+".url", "для метода FTP ожидается адрес ftp://")
+			}
+		default:
+			v.errorf(path+".download_method", "неизвестный метод загрузки '%s' (допустимо HTTP или FTP)", asset.DownloadMethod)
+		}
+		v.checkMirrors(path+".mirrors", asset.Mirrors)
+		if asset.Retry != nil {
+			v.checkRetry(path+".retry", *asset.Retry)
+		}
+		if asset.SHA256 != "" && !sha256Pattern.MatchString(asset.SHA256) {
+			v.errorf(path+".sha256", "ожидается SHA-256 из 64 шестнадцатеричных символов")
+		}
+		switch asset.Type {
+		case "file":
+		case "zip":
+			if asset.Destination == "" {
+				v.errorf(path+".destination", "не указан: архив распаковывался бы прямо в root_path")
+			}
+		default:
+			v.errorf(path+".type", "неизвестный тип ресурса '%s' (допустимо file или zip)", asset.Type)
+		}
+	}
+
+	for _, moduleID := range sortedKeys(builtinModuleAssets) {
+		if !c.HasModule(moduleID) {
+			continue
+		}
+		for _, asset := range builtinModuleAssets[moduleID] {
+			if _, ok := c.AssetCatalog[asset]; !ok {
+				v.errorf("asset_catalog", "нет ресурса '%s', необходимого модулю %s", asset, moduleID)
+			}
+		}
+	}
+}
+
+func (c *Config) validatePackages(v *validator) {
+	seen := make(map[string]bool)
+	for i, def := range c.Packages {
+		path := fmt.Sprintf("packages[%d]", i)
+		if def.ID == "" {
+			v.errorf(path+".id", "не указан")
+		} else if seen[def.ID] {
+			v.errorf(path+".id", "пакет '%s' объявлен повторно", def.ID)
+		}
+		seen[def.ID] = true
+		c.checkAssetRef(v, path+".asset_id", def.AssetID)
+
+		for j, action := range def.PostInstall {
+			actionPath := fmt.Sprintf("%s.post_install[%d]", path, j)
+			switch action.Type {
+			case PostStartService:
+				if action.Service == "" {
+					v.errorf(actionPath+".service", "не указано имя службы")
+				}
+			case PostDefenderExclusion:
+				if action.Path == "" {
+					v.errorf(actionPath+".path", "не указан путь")
+				}
+			case PostScheduledTask:
+				if action.TaskName == "" || action.Path == "" {
+					v.errorf(actionPath, "не указаны task_name или path")
+				}
+			default:
+				v.errorf(actionPath+".type", "неизвестный тип действия '%s'", action.Type)
+			}
+		}
+	}
+
+	// Устаревшие секции проверяются, только если используются
+	if c.DTOConfig.AssetID != "" {
+		c.checkAssetRef(v, "dto_config.asset_id", c.DTOConfig.AssetID)
+	}
+	if c.UTMConfig.AssetID != "" {
+		c.checkAssetRef(v, "utm_config.asset_id", c.UTMConfig.AssetID)
+	}
+}
+
+func (c *Config) validateFrpc(v *validator) {
+	if !c.HasModule("FRPC") {
+		return
+	}
+	if _, _, err := ParsePortRange(c.FrpcConfig.PortRange); err != nil {
+		v.errorf("frpc_config.port_range", "%v", err)
+	}
+	if c.FrpcConfig.FrpcDownloadURL == "" {
+		v.errorf("frpc_config.frpc_download_url", "не указан")
+	}
+	if c.FrpcConfig.NssmDownloadURL == "" {
+		v.errorf("frpc_config.nssm_download_url", "не указан")
+	}
+	v.checkURL("frpc_config.frpc_download_url", c.FrpcConfig.FrpcDownloadURL)
+	v.checkURL("frpc_config.nssm_download_url", c.FrpcConfig.NssmDownloadURL)
+	v.checkMirrors("frpc_config.frpc_mirrors", c.FrpcConfig.FrpcMirrors)
+	v.checkMirrors("frpc_config.nssm_mirrors", c.FrpcConfig.NssmMirrors)
+	v.checkPassword("frpc_config.server_config.pass", c.FrpcConfig.ServerConfig.Pass)
+}
+
+func (c *Config) validateRegime(v *validator) {
+	if !c.HasModule("Regime") {
+		return
+	}
+	if c.RegimeConfig.AdminUser == "" {
+		v.errorf("regime_config.admin_user", "не указан")
+	}
+	if c.RegimeConfig.AdminPassword == "" {
+		v.errorf("regime_config.admin_password", "не указан")
+	}
+	v.checkPassword("regime_config.admin_password", c.RegimeConfig.AdminPassword)
+}
+
+func (c *Config) validateReporting(v *validator) {
+	r := c.Reporting
+	if r.URL == "" {
+		return
+	}
+	if !strings.HasPrefix(r.URL, "https://") && !strings.HasPrefix(r.URL, "http://") {
+		v.errorf("reporting.url", "ожидается адрес http:// или https://")
+	}
+	v.checkURL("reporting.url", r.URL)
+	v.checkPassword("reporting.token", r.Token)
+	if r.Retries < 0 {
+		v.errorf("reporting.retries", "не может быть отрицательным")
+	}
+	if r.OutboxLimit < 0 {
+		v.errorf("reporting.outbox_limit", "не может быть отрицательным")
+	}
+}
+
+func (c *Config) validateProfiles(v *validator) {
+	for _, name := range sortedKeys(c.Profiles) {
+		profile := c.Profiles[name]
+		if len(profile.Modules) == 0 {
+			v.warnf("profiles."+name+".modules", "профиль не содержит модулей")
+		}
+		if _, err := profile.Order(); err != nil {
+			v.errorf("profiles."+name, "%v", err)
+		}
+		for i, module := range profile.Modules {
+			if _, err := module.Params(); err != nil {
+				v.errorf(fmt.Sprintf("profiles.%s.modules[%d].answers", name, i), "%v", err)
+			}
+		}
+	}
+}
+
+func (c *Config) validateSites(v *validator) {
+	for _, name := range sortedKeys(c.Sites) {
+		path := "sites." + name
+		if !siteNamePattern.MatchString(name) {
+			v.errorf(path, "имя площадки может содержать только латинские буквы, цифры, '-' и '_'")
+		}
+		if len(c.Sites[name].Overrides) == 0 {
+			v.warnf(path+".overrides", "площадка ничего не переопределяет")
+		}
+	}
+}
+
+func (c *Config) checkAssetRef(v *validator, path, assetID string) {
+	if assetID == "" {
+		v.errorf(path, "не указан")
+		return
+	}
+	if _, ok := c.AssetCatalog[assetID]; !ok {
+		v.errorf(path, "ресурс '%s' не найден в asset_catalog", assetID)
+	}
+}
+
+// checkPassword предупреждает о пароле, заданном в конфигурации открытым текстом.
+func (v *validator) checkPassword(path, value string) {
+	if value != "" && !secrets.IsRef(value) {
+		v.warnf(path, "пароль хранится в открытом виде, используйте ссылку \"secret:имя\"")
+	}
+}
+
+func (v *validator) checkURL(path, url string) {
+	if strings.HasPrefix(url, "http://") {
+		v.warnf(path, "адрес использует http:// без шифрования, рекомендуется https://")
+	}
+}
+
+// checkMirrors проверяет запасные адреса: допустимы http://, https:// и ftp://.
+func (v *validator) checkMirrors(path string, mirrors []string) {
+	for i, mirror := range mirrors {
+		mirrorPath := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case strings.HasPrefix(mirror, "https://"), strings.HasPrefix(mirror, "ftp://"):
+		case strings.HasPrefix(mirror, "http://"):
+			v.checkURL(mirrorPath, mirror)
+		default:
+			v.errorf(mirrorPath, "ожидается адрес http://, https:// или ftp://")
+		}
+	}
+}
+
+func (v *validator) checkRetry(path string, policy RetryPolicy) {
+	if policy.Attempts < 0 || policy.InitialDelaySeconds < 0 || policy.MaxDelaySeconds < 0 {
+		v.errorf(path, "значения не могут быть отрицательными")
+	}
+	if policy.MaxDelaySeconds > 0 && policy.InitialDelaySeconds > policy.MaxDelaySeconds {
+		v.warnf(path, "initial_delay_seconds больше max_delay_seconds")
+	}
+}
+
+// ParsePortRange разбирает диапазон портов вида "50500-50600".
+func ParsePortRange(s string) (start, end int, err error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("диапазон портов '%s' должен иметь вид начало-конец", s)
+	}
+	start, err1 := strconv.Atoi(strings.TrimSpace(parts[0]))
+	end, err2 := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err1 != nil || err2 != nil {
+		return 0, 0, fmt.Errorf("диапазон портов '%s' содержит не число", s)
+	}
+	if start < 1 || end > 65535 || start > end {
+		return 0, 0, fmt.Errorf("диапазон портов '%s' вне допустимых значений 1-65535", s)
+	}
+	return start, end, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+// errorPaths возвращает отсортированные пути ошибок (без предупреждений).
+func errorPaths(problems []Problem) string {
+	var paths []string
+	for _, p := range problems {
+		if !p.Warning {
+			paths = append(paths, p.Path)
+		}
+	}
+	sort.Strings(paths)
+	return strings.Join(paths, ", ")
+}
+
+func TestValidateReportsProblemPaths(t *testing.T) {
+	cfg := &Config{
+		RootPath:        `C:\MH`,
+		AssetsCachePath: `C:\MH\cache`,
+		Modules:         []ModuleDef{{ID: "FRPC"}, {ID: "Packages"}, {ID: "Telepathy"}},
+		FrpcConfig: FrpcConfig{
+			PortRange:       "50600-50500",
+			FrpcDownloadURL: "https://example.com/frpc.zip",
+			NssmDownloadURL: "https://example.com/nssm.zip",
+		},
+		AssetCatalog: map[string]AssetInfo{
+			"Tool":  {URL: "https://example.com/tool.exe", Type: "file", DownloadMethod: "SCP"},
+			"Agent": {URL: "https://example.com/agent.zip", Type: "zip"},
+		},
+		Packages: []PackageDef{
+			{ID: "Tool", AssetID: "Tool"},
+			{ID: "DTO", AssetID: "DTO_Installer"},
+		},
+	}
+
+	want := "asset_catalog.Agent.destination, asset_catalog.Tool.download_method, frpc_config.port_range, packages[1].asset_id"
+	if got := errorPaths(cfg.Validate()); got != want {
+		t.Errorf("Validate: ошибки в %s, want %s", got, want)
+	}
+	if got := errorPaths(cfg.ValidateModules([]string{"FRPC", "Packages"})); got != "modules[2].id" {
+		t.Errorf("ValidateModules: ошибки в %s, want modules[2].id", got)
+	}
+}
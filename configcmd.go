@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"goMH/config"
+	"goMH/core"
+	"goMH/secrets"
+	"goMH/tui"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// cmdConfig обрабатывает "goMH config <действие>".
+func (a *App) cmdConfig(args []string) int {
+	if len(args) == 0 {
+		tui.Error("Укажите действие: goMH config validate | goMH config show [--effective]")
+		return exitUsage
+	}
+	switch args[0] {
+	case "validate":
+		return a.cmdConfigValidate()
+	case "show":
+		params, err := parseParams(args[1:])
+		if err != nil {
+			tui.Error(err.Error())
+			return exitUsage
+		}
+		return a.cmdConfigShow(params["effective"] != "")
+	default:
+		tui.Error(fmt.Sprintf("Неизвестное действие конфигурации: %s", args[0]))
+		return exitUsage
+	}
+}
+
+// cmdConfigValidate выводит все ошибки и предупреждения конфигурации с путями в JSON.
+// Код завершения 1, если найдена хотя бы одна ошибка.
+func (a *App) cmdConfigValidate() int {
+	problems := a.Cfg.Validate()
+	problems = append(problems, a.Cfg.ValidateModules(moduleIDs(a.Modules))...)
+
+	if len(problems) == 0 {
+		tui.Success("Конфигурация проверена, проблем не найдено.")
+		return exitOK
+	}
+
+	errorCount := 0
+	for _, problem := range problems {
+		if problem.Warning {
+			tui.Warn(fmt.Sprintf("предупреждение  %s", problem))
+		} else {
+			errorCount++
+			tui.Error(fmt.Sprintf("ошибка          %s", problem))
+		}
+	}
+	fmt.Printf("\nОшибок: %d, предупреждений: %d\n", errorCount, len(problems)-errorCount)
+	if config.HasErrors(problems) {
+		return exitError
+	}
+	return exitOK
+}
+
+// cmdConfigShow выводит итоговую конфигурацию после наложения всех слоев. С effective
+// выводится каждое значение отдельной строкой с указанием источника: базовая
+// конфигурация, config.local.json или переменная окружения. Пароли скрываются.
+func (a *App) cmdConfigShow(effective bool) int {
+	data, err := json.Marshal(a.Cfg)
+	if err != nil {
+		tui.Error(err.Error())
+		return exitError
+	}
+	var doc interface{}
+	json.Unmarshal(data, &doc)
+	doc = maskPasswords(doc)
+
+	if !effective {
+		out, _ := json.MarshalIndent(doc, "", "  ")
+		fmt.Println(string(out))
+		return exitOK
+	}
+
+	values := make(map[string]string)
+	flattenJSON(doc, "", values)
+	paths := make([]string, 0, len(values))
+	width := 0
+	for path, value := range values {
+		paths = append(paths, path)
+		if len(path)+len(value) > width {
+			width = len(path) + len(value)
+		}
+	}
+	sort.Strings(paths)
+	if width > 90 {
+		width = 90
+	}
+	for _, path := range paths {
+		line := fmt.Sprintf("%s = %s", path, values[path])
+		fmt.Printf("%-*s  [%s]\n", width+3, line, a.Cfg.Origin(path))
+	}
+	return exitOK
+}
+
+// flattenJSON раскладывает JSON-дерево в пары "путь = значение".
+// Пустые строки, объекты и массивы не выводятся.
+func flattenJSON(node interface{}, path string, out map[string]string) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			flattenJSON(child, childPath, out)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flattenJSON(child, fmt.Sprintf("%s[%d]", path, i), out)
+		}
+	case nil:
+	case string:
+		if v != "" {
+			out[path] = strconv.Quote(v)
+		}
+	default:
+		data, _ := json.Marshal(v)
+		out[path] = string(data)
+	}
+}
+
+// maskPasswords заменяет значения паролей (ключи с "pass") на звездочки. Ссылки "secret:имя" выводятся как есть.
+func maskPasswords(node interface{}) interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if str, ok := child.(string); ok && strings.Contains(strings.ToLower(key), "pass") && str != "" && !secrets.IsRef(str) {
+				v[key] = "***"
+				continue
+			}
+			v[key] = maskPasswords(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = maskPasswords(child)
+		}
+	}
+	return node
+}
+
+// moduleIDs возвращает отсортированные ID зарегистрированных модулей.
+func moduleIDs(modules map[string]core.Installer) []string {
+	ids := make([]string, 0, len(modules))
+	for id := range modules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
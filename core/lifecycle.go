@@ -0,0 +1,183 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// State описывает, в каком состоянии компонент модуля находится на машине.
+type State int
+
+const (
+	StateUnknown      State = iota // Состояние определить нельзя (например, нет правила обнаружения)
+	StateNotInstalled              // Компонент не установлен
+	StateInstalled                 // Компонент установлен
+)
+
+// MarshalText задает стабильное представление состояния в JSON (goMH status --json).
+func (s State) MarshalText() ([]byte, error) {
+	switch s {
+	case StateNotInstalled:
+		return []byte("not_installed"), nil
+	case StateInstalled:
+		return []byte("installed"), nil
+	default:
+		return []byte("unknown"), nil
+	}
+}
+
+func (s State) String() string {
+	switch s {
+	case StateNotInstalled:
+		return "не установлен"
+	case StateInstalled:
+		return "установлен"
+	default:
+		return "неизвестно"
+	}
+}
+
+// Status — результат Detect.
+type Status struct {
+	State   State
+	Version string // Пустая строка, если версию определить нельзя
+	Details string // Короткое пояснение, например "установлено 2 из 3"
+}
+
+func (s Status) String() string {
+	text := s.State.String()
+	if s.Version != "" {
+		text += ", версия " + s.Version
+	}
+	if s.Details != "" {
+		text += " (" + s.Details + ")"
+	}
+	return text
+}
+
+// ErrNotSupported возвращается, если модуль не поддерживает операцию жизненного цикла.
+var ErrNotSupported = errors.New("операция не поддерживается модулем")
+
+// Lifecycle — расширенный контракт для модулей, которые устанавливают компонент на машину.
+// Run у таких модулей остается совместимой оберткой над методами ниже (см. RunLifecycle).
+type Lifecycle interface {
+	Installer
+	// Detect определяет, установлен ли компонент и какой версии. Систему не меняет.
+	Detect(am AssetManager, wu WinUtils) (Status, error)
+	Install(ctx context.Context, am AssetManager, wu WinUtils, p Prompter) error
+	// Upgrade обновляет или переустанавливает уже установленный компонент.
+	Upgrade(ctx context.Context, am AssetManager, wu WinUtils, p Prompter) error
+	Uninstall(ctx context.Context, am AssetManager, wu WinUtils, p Prompter) error
+	// Verify проверяет установленный компонент и возвращает список найденных проблем.
+	Verify(am AssetManager, wu WinUtils) ([]string, error)
+}
+
+// Check — один пункт подробного отчета о состоянии модуля (служба, процесс, туннель и т.п.).
+type Check struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Value string `json:"value,omitempty"`
+}
+
+// Inspector может реализовать модуль, чтобы показать подробности состояния в "goMH status".
+// Как и Detect, Inspect систему не меняет.
+type Inspector interface {
+	Inspect(am AssetManager, wu WinUtils) ([]Check, error)
+}
+
+// ServiceCheck формирует пункт отчета о службе Windows: установлена ли она и запущена ли.
+func ServiceCheck(wu WinUtils, serviceName string) Check {
+	check := Check{Name: "служба " + serviceName}
+	exists, err := wu.ServiceExists(serviceName)
+	switch {
+	case err != nil:
+		check.Value = "ошибка проверки: " + err.Error()
+	case !exists:
+		check.Value = "не установлена"
+	default:
+		running, err := wu.ServiceRunning(serviceName)
+		switch {
+		case err != nil:
+			check.Value = "ошибка проверки: " + err.Error()
+		case running:
+			check.OK = true
+			check.Value = "работает"
+		default:
+			check.Value = "остановлена"
+		}
+	}
+	return check
+}
+
+// ProcessCheck формирует пункт отчета о процессе.
+func ProcessCheck(wu WinUtils, processName string) Check {
+	check := Check{Name: "процесс " + processName}
+	running, err := wu.IsProcessRunning(processName)
+	switch {
+	case err != nil:
+		check.Value = "ошибка проверки: " + err.Error()
+	case running:
+		check.OK = true
+		check.Value = "запущен"
+	default:
+		check.Value = "не запущен"
+	}
+	return check
+}
+
+// Action — дополнительное действие модуля в меню установленного компонента
+// (например, "Добавить порт" у FRPC).
+type Action struct {
+	Option
+	Do func() error
+}
+
+// RunLifecycle — типовая реализация Run для модулей с Lifecycle.
+// Если компонент не установлен (или состояние неизвестно), выполняется Install.
+// Иначе выводятся результаты Verify и предлагается выбор действия по ключу "action":
+// дополнительные действия модуля, "reinstall" (Upgrade) или "uninstall" (Uninstall).
+func RunLifecycle(ctx context.Context, m Lifecycle, am AssetManager, wu WinUtils, p Prompter, extra ...Action) error {
+	status, err := m.Detect(am, wu)
+	if err != nil {
+		return fmt.Errorf("не удалось определить состояние модуля %s: %w", m.ID(), err)
+	}
+	if status.State != StateInstalled {
+		return m.Install(ctx, am, wu, p)
+	}
+
+	fmt.Printf("\nОбнаружена существующая установка: %s\n", status)
+	problems, err := m.Verify(am, wu)
+	switch {
+	case errors.Is(err, ErrNotSupported):
+	case err != nil:
+		fmt.Printf("[ДИАГНОСТИКА] Проверка не выполнена: %v\n", err)
+	case len(problems) == 0:
+		fmt.Println("[ДИАГНОСТИКА] Проблем не обнаружено. Система выглядит настроенной.")
+	default:
+		fmt.Println("[ДИАГНОСТИКА] Обнаружены следующие проблемы:")
+		for _, problem := range problems {
+			fmt.Println("x " + problem)
+		}
+	}
+
+	actions := append([]Action{}, extra...)
+	actions = append(actions,
+		Action{Option: Option{Value: "reinstall", Label: "Переустановить / обновить"}, Do: func() error { return m.Upgrade(ctx, am, wu, p) }},
+		Action{Option: Option{Value: "uninstall", Label: "Удалить"}, Do: func() error { return m.Uninstall(ctx, am, wu, p) }},
+	)
+	options := make([]Option, len(actions))
+	for i, action := range actions {
+		options[i] = action.Option
+	}
+
+	choice, err := p.Choose("action", "Выберите действие", options)
+	if err != nil {
+		if errors.Is(err, ErrCancelled) {
+			fmt.Println("Операция отменена. Возврат в главное меню.")
+			return nil
+		}
+		return err
+	}
+	return actions[choice].Do()
+}
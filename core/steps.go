@@ -0,0 +1,94 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Step — шаг установки, который умеет откатывать свои изменения.
+type Step struct {
+	Name string
+	Do   func(ctx context.Context) error
+	// Undo отменяет изменения шага. nil означает, что откатывать нечего
+	// (например, шаг только задает вопрос пользователю). Undo вызывается и для шага,
+	// завершившегося ошибкой, поэтому должен убирать частично сделанную работу и не
+	// считать ошибкой отсутствие того, что шаг не успел создать.
+	Undo func(ctx context.Context) error
+}
+
+// RunSteps выполняет шаги по порядку. Если шаг завершается ошибкой, откатываются он сам
+// и уже выполненные шаги в обратном порядке. Перед откатом задается вопрос с ключом "rollback":
+// ответ "нет" оставляет систему в частичном состоянии для отладки. Если в сценарии ответа
+// нет, откат выполняется.
+// Возвращается ошибка упавшего шага.
+//
+// Отмена ctx прерывает текущий шаг, а следующие не запускаются. Откат выполняется
+// с контекстом без отмены, чтобы Ctrl+C не оставлял систему в частичном состоянии.
+func RunSteps(ctx context.Context, p Prompter, steps ...Step) error {
+	for i, step := range steps {
+		err := ctx.Err()
+		if err == nil {
+			err = step.Do(ctx)
+		}
+		if err == nil {
+			continue
+		}
+
+		stepErr := fmt.Errorf("шаг '%s': %w", step.Name, err)
+		done := steps[:i+1]
+		if !hasUndo(done) {
+			return stepErr
+		}
+
+		fmt.Printf("\nШаг '%s' завершился ошибкой: %v\n", step.Name, err)
+		rollback, promptErr := p.Confirm("rollback", "Откатить изменения, сделанные установкой?", true)
+		if errors.Is(promptErr, ErrNoAnswer) {
+			// Без ответа в сценарии откат выполняется: частичное состояние - только по явному "нет"
+			rollback, promptErr = true, nil
+		}
+		if promptErr != nil {
+			fmt.Println("Откат не выполнялся: система оставлена в частичном состоянии.")
+			return fmt.Errorf("%w (откат не выполнен: %v)", stepErr, promptErr)
+		}
+		if !rollback {
+			fmt.Println("Откат не выполнялся: система оставлена в частичном состоянии.")
+			return stepErr
+		}
+
+		if failed := undoSteps(context.WithoutCancel(ctx), done); len(failed) > 0 {
+			return fmt.Errorf("%w (не удалось откатить: %s)", stepErr, strings.Join(failed, "; "))
+		}
+		fmt.Println("Откат завершен.")
+		return stepErr
+	}
+	return nil
+}
+
+// undoSteps откатывает шаги в обратном порядке и возвращает описания неудачных откатов.
+// Ошибка отката одного шага не останавливает откат остальных.
+func undoSteps(ctx context.Context, steps []Step) []string {
+	var failed []string
+	for i := len(steps) - 1; i >= 0; i-- {
+		step := steps[i]
+		if step.Undo == nil {
+			continue
+		}
+		fmt.Printf("Откат: %s...\n", step.Name)
+		if err := step.Undo(ctx); err != nil {
+			fmt.Printf("Предупреждение: не удалось откатить шаг '%s': %v\n", step.Name, err)
+			failed = append(failed, fmt.Sprintf("%s: %v", step.Name, err))
+		}
+	}
+	return failed
+}
+
+func hasUndo(steps []Step) bool {
+	for _, step := range steps {
+		if step.Undo != nil {
+			return true
+		}
+	}
+	return false
+}
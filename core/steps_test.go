@@ -0,0 +1,75 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// confirmPrompter отвечает на Confirm заданным значением.
+type confirmPrompter struct {
+	Prompter
+	answer bool
+	err    error
+}
+
+func (p confirmPrompter) Confirm(key, question string, def bool) (bool, error) {
+	return p.answer, p.err
+}
+
+// recordSteps возвращает шаги, которые пишут в log выполнение и откат; шаг failAt падает.
+func recordSteps(log *[]string, failAt int, names ...string) []Step {
+	var steps []Step
+	for i, name := range names {
+		steps = append(steps, Step{
+			Name: name,
+			Do: func(ctx context.Context) error {
+				*log = append(*log, "do "+name)
+				if i == failAt {
+					return errors.New("сбой")
+				}
+				return nil
+			},
+			Undo: func(ctx context.Context) error {
+				*log = append(*log, "undo "+name)
+				return nil
+			},
+		})
+	}
+	return steps
+}
+
+func TestRunStepsUndoesFailedStep(t *testing.T) {
+	var log []string
+	err := RunSteps(context.Background(), confirmPrompter{answer: true}, recordSteps(&log, 1, "a", "b", "c")...)
+	if err == nil || !strings.Contains(err.Error(), "шаг 'b'") {
+		t.Fatalf("RunSteps: err = %v, want ошибку шага 'b'", err)
+	}
+	want := "do a, do b, undo b, undo a"
+	if got := strings.Join(log, ", "); got != want {
+		t.Errorf("порядок: %s, want %s", got, want)
+	}
+}
+
+func TestRunStepsRollbackAnswer(t *testing.T) {
+	tests := []struct {
+		name     string
+		prompter confirmPrompter
+		want     string
+	}{
+		{"ответ да", confirmPrompter{answer: true}, "do a, do b, undo b, undo a"},
+		{"ответ нет", confirmPrompter{answer: false}, "do a, do b"},
+		{"нет ответа", confirmPrompter{err: ErrNoAnswer}, "do a, do b, undo b, undo a"},
+	}
+	for _, tt := range tests {
+		var log []string
+		err := RunSteps(context.Background(), tt.prompter, recordSteps(&log, 1, "a", "b")...)
+		if err == nil {
+			t.Fatalf("%s: RunSteps должен вернуть ошибку шага", tt.name)
+		}
+		if got := strings.Join(log, ", "); got != tt.want {
+			t.Errorf("%s: %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
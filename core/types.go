@@ -1,6 +1,11 @@
 package core
 
-import "goMH/config"
+import (
+	"context"
+	"errors"
+	"goMH/config"
+	"os"
+)
 
 // ScannerInfo содержит информацию о найденном устройстве-сканере.
 type ScannerInfo struct {
@@ -9,41 +14,107 @@ type ScannerInfo struct {
 	PNPDeviceID string // Аппаратный ID, например, "USB\VID_2912&PID_0005&MI_00\..."
 }
 
+// InstalledProgram - программа из списка установленных (ключи Uninstall реестра).
+type InstalledProgram struct {
+	Name            string
+	Version         string
+	UninstallString string
+}
+
 // WinUtils определяет контракт для утилит, специфичных для Windows.
 // Модули будут зависеть от этого интерфейса, а не от конкретного пакета winutils.
+// Отмена ctx у RunCommand/RunCommandWithEnv завершает запущенный процесс.
+// QueryCommand - для команд, которые только читают состояние системы (schtasks /Query
+// и т.п.): в режиме dry-run они выполняются по-настоящему.
 type WinUtils interface {
-	RunCommand(name string, args ...string) (string, error)
-	RunCommandWithEnv(env map[string]string, name string, args ...string) (string, error)
+	RunCommand(ctx context.Context, name string, args ...string) (string, error)
+	QueryCommand(ctx context.Context, name string, args ...string) (string, error)
+	RunCommandWithEnv(ctx context.Context, env map[string]string, name string, args ...string) (string, error)
 	ServiceExists(serviceName string) (bool, error)
+	ServiceRunning(serviceName string) (bool, error)
 	AddDefenderExclusion(path string) error
 	SetServiceTriggers(serviceName string, triggers []string) error
 	Is64BitOS() bool
 	GetComPorts() ([]string, error)
 	GetScanners() ([]ScannerInfo, error)
 	IsProcessRunning(processName string) (bool, error)
+	FindInstalledProgram(namePrefix string) (*InstalledProgram, error)
 	CreateScheduledTask(taskName, executablePath, workingDir string) error
+	StartProcess(executablePath, workingDir string) error
+	WriteFile(path string, data []byte, perm os.FileMode) error
+	RemoveAll(path string) error
+	MkdirAll(path string, perm os.FileMode) error
+	Rename(oldPath, newPath string) error
 }
 
 // AssetManager определяет контракт для менеджера ресурсов.
+// Отмена ctx прерывает скачивание; недокачанный файл удаляется.
 type AssetManager interface {
-	Get(assetName string) (string, error)
-	DownloadHTTPWithProgress(httpURL, localPath string) (bool, error)
-	DownloadFTPWithProgress(ftpPath, localPath string) (bool, error)
+	Get(ctx context.Context, assetName string) (string, error)
+	DownloadHTTPWithProgress(ctx context.Context, httpURL, localPath string) (bool, error)
+	DownloadFTPWithProgress(ctx context.Context, ftpPath, localPath string) (bool, error)
+	DownloadMirrors(ctx context.Context, urls []string, localPath string) (bool, error)
+	HTTPFileSize(httpURL string) (int64, error)
+	FTPFileSize(ftpPath string) (int64, error)
 	ExtractFile(zipPath, pathInZip, destPath string) error
+	FindInZip(zipPath, targetSuffix string) (string, error)
 	ListFTP(path string) ([]FTPEntry, error)
-	DownloadToCache(assetName string) (string, error)
+	DownloadToCache(ctx context.Context, assetName string) (string, error)
+	CachePath(assetName string) (string, error)
 	ProcessFromCache(assetName, cachePath string) error
 	PurgeAsset(assetName string) error
 	Cfg() *config.Config
 }
 
+// Params содержит заранее известные ответы на вопросы модуля (ключ вопроса -> ответ).
+// Например, "goMH run iiko --version 900" превращается в Params{"version": "900"}.
+type Params map[string]string
+
+// ErrCancelled возвращается Prompter'ом, когда пользователь выбрал "Назад".
+var ErrCancelled = errors.New("пользователь выбрал возврат в предыдущее меню")
+
+// IsCancelled сообщает, что операция прервана пользователем: Ctrl+C (отмена
+// контекста) или выбор "Назад".
+func IsCancelled(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, ErrCancelled)
+}
+
+// ErrNoAnswer возвращается сценарным Prompter'ом, если на вопрос нет заранее заданного ответа.
+var ErrNoAnswer = errors.New("не задан ответ на вопрос")
+
+// Option — вариант выбора в меню.
+type Option struct {
+	Value string // Стабильное значение для командной строки и файлов ответов, например "Front"
+	Label string // Текст для пользователя, например "iiko 900 Front"
+}
+
+// Prompter определяет контракт для общения модуля с пользователем.
+// Каждый вопрос имеет ключ (key), по которому сценарные реализации находят готовый ответ.
+type Prompter interface {
+	// Choose предлагает выбрать один вариант и возвращает его индекс.
+	Choose(key, title string, options []Option) (int, error)
+	// MultiChoose предлагает выбрать несколько вариантов. Пустой результат означает пропуск.
+	MultiChoose(key, title string, options []Option) ([]int, error)
+	// Confirm задает вопрос "да/нет". def используется при пустом ответе.
+	Confirm(key, question string, def bool) (bool, error)
+	// Input запрашивает строку. def подставляется при пустом ответе,
+	// validate (может быть nil) проверяет введенное значение.
+	Input(key, question, def string, validate func(string) error) (string, error)
+	// Pause ждет подтверждения пользователя перед продолжением.
+	Pause(message string)
+	// Interactive сообщает, отвечает ли на вопросы живой пользователь.
+	// Модули не показывают меню в цикле, если это не так.
+	Interactive() bool
+}
+
 // Installer — это единый интерфейс для всех устанавливаемых модулей.
 // Мы переносим его сюда, чтобы он был доступен всем.
 type Installer interface {
 	ID() string
 	MenuText() string
 	// Сигнатура Run теперь принимает интерфейсы, а не конкретные типы.
-	Run(am AssetManager, wu WinUtils) error
+	// Отмена ctx (Ctrl+C) должна прервать текущую операцию и вернуть управление в меню.
+	Run(ctx context.Context, am AssetManager, wu WinUtils, p Prompter) error
 }
 
 type FTPEntry struct {
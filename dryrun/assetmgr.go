@@ -0,0 +1,138 @@
+package dryrun
+
+import (
+	"context"
+	"fmt"
+	"goMH/config"
+	"goMH/core"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AssetManager записывает скачивания и распаковки в план. Размеры файлов
+// и списки на FTP запрашиваются по-настоящему.
+type AssetManager struct {
+	inner core.AssetManager
+	plan  *Plan
+}
+
+// NewAssetManager оборачивает реальный менеджер ресурсов.
+func NewAssetManager(inner core.AssetManager, plan *Plan) *AssetManager {
+	return &AssetManager{inner: inner, plan: plan}
+}
+
+func (a *AssetManager) Cfg() *config.Config {
+	return a.inner.Cfg()
+}
+
+func (a *AssetManager) Get(ctx context.Context, assetName string) (string, error) {
+	cachePath, err := a.DownloadToCache(ctx, assetName)
+	if err != nil {
+		return "", err
+	}
+	if err := a.ProcessFromCache(assetName, cachePath); err != nil {
+		return "", err
+	}
+	return filepath.Join(a.Cfg().RootPath, a.Cfg().AssetCatalog[assetName].Destination), nil
+}
+
+func (a *AssetManager) DownloadHTTPWithProgress(ctx context.Context, httpURL, localPath string) (bool, error) {
+	size, _ := a.inner.HTTPFileSize(httpURL)
+	a.recordDownload(httpURL, localPath, size)
+	return false, nil
+}
+
+func (a *AssetManager) DownloadFTPWithProgress(ctx context.Context, ftpPath, localPath string) (bool, error) {
+	size, err := a.inner.FTPFileSize(ftpPath)
+	if err != nil {
+		size = -1
+	}
+	a.recordDownload("ftp:"+ftpPath, localPath, size)
+	return false, nil
+}
+
+func (a *AssetManager) DownloadMirrors(ctx context.Context, urls []string, localPath string) (bool, error) {
+	if len(urls) == 0 {
+		return false, nil
+	}
+	size := int64(-1)
+	if !strings.HasPrefix(urls[0], "ftp://") {
+		size, _ = a.inner.HTTPFileSize(urls[0])
+	}
+	source := urls[0]
+	if len(urls) > 1 {
+		source += fmt.Sprintf(" (зеркал: %d)", len(urls)-1)
+	}
+	a.recordDownload(source, localPath, size)
+	return false, nil
+}
+
+func (a *AssetManager) DownloadToCache(ctx context.Context, assetName string) (string, error) {
+	cachePath, err := a.inner.CachePath(assetName)
+	if err != nil {
+		return "", err
+	}
+	assetInfo := a.Cfg().AssetCatalog[assetName]
+	if strings.EqualFold(assetInfo.DownloadMethod, "FTP") && len(assetInfo.Mirrors) == 0 {
+		parsedURL, _ := url.Parse(assetInfo.URL)
+		_, err = a.DownloadFTPWithProgress(ctx, parsedURL.Path, cachePath)
+	} else {
+		_, err = a.DownloadMirrors(ctx, assetInfo.URLs(), cachePath)
+	}
+	return cachePath, err
+}
+
+func (a *AssetManager) ProcessFromCache(assetName, cachePath string) error {
+	assetInfo := a.Cfg().AssetCatalog[assetName]
+	if assetInfo.Type == "zip" {
+		a.plan.Add("распаковка", "%s -> %s", cachePath, filepath.Join(a.Cfg().RootPath, assetInfo.Destination))
+	}
+	return nil
+}
+
+func (a *AssetManager) ExtractFile(zipPath, pathInZip, destPath string) error {
+	a.plan.Add("распаковка", "%s из %s -> %s", pathInZip, zipPath, destPath)
+	return nil
+}
+
+func (a *AssetManager) PurgeAsset(assetName string) error {
+	a.plan.Add("кэш", "удалить ресурс '%s' из кэша и директории назначения", assetName)
+	return nil
+}
+
+// --- Запросы только на чтение выполняются по-настоящему ---
+
+func (a *AssetManager) HTTPFileSize(httpURL string) (int64, error) {
+	return a.inner.HTTPFileSize(httpURL)
+}
+
+func (a *AssetManager) FTPFileSize(ftpPath string) (int64, error) {
+	return a.inner.FTPFileSize(ftpPath)
+}
+
+func (a *AssetManager) ListFTP(path string) ([]core.FTPEntry, error) {
+	return a.inner.ListFTP(path)
+}
+
+func (a *AssetManager) CachePath(assetName string) (string, error) {
+	return a.inner.CachePath(assetName)
+}
+
+// FindInZip ищет файл в архиве, если архив уже есть в кэше. Иначе возвращает
+// искомый путь как есть, чтобы план мог продолжиться.
+func (a *AssetManager) FindInZip(zipPath, targetSuffix string) (string, error) {
+	if _, err := os.Stat(zipPath); err != nil {
+		return filepath.ToSlash(targetSuffix), nil
+	}
+	return a.inner.FindInZip(zipPath, targetSuffix)
+}
+
+func (a *AssetManager) recordDownload(source, localPath string, size int64) {
+	if fi, err := os.Stat(localPath); err == nil && size > 0 && fi.Size() == size {
+		a.plan.Add("скачивание", "%s уже в кэше (%s), будет пропущено", localPath, formatSize(size))
+		return
+	}
+	a.plan.Add("скачивание", "%s -> %s (%s)", source, localPath, formatSize(size))
+}
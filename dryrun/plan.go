@@ -0,0 +1,74 @@
+// Package dryrun содержит обертки над core.WinUtils и core.AssetManager, которые
+// вместо изменения системы записывают запланированные действия в план.
+// Запросы только на чтение (проверка служб, список портов, размеры файлов) выполняются по-настоящему,
+// чтобы план был точным.
+package dryrun
+
+import (
+	"fmt"
+	"goMH/tui"
+	"sync"
+)
+
+// Step - одно запланированное действие.
+type Step struct {
+	Kind        string // Категория: "команда", "скачивание", "файл" и т.д.
+	Description string
+}
+
+// Plan накапливает запланированные действия в порядке их появления.
+type Plan struct {
+	mu    sync.Mutex
+	steps []Step
+}
+
+// NewPlan создает пустой план.
+func NewPlan() *Plan {
+	return &Plan{}
+}
+
+// Add добавляет действие в план.
+func (p *Plan) Add(kind, format string, a ...interface{}) {
+	step := Step{Kind: kind, Description: fmt.Sprintf(format, a...)}
+	tui.InfoF("[DRY-RUN] %s: %s", step.Kind, step.Description)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.steps = append(p.steps, step)
+}
+
+// Steps возвращает копию накопленных действий.
+func (p *Plan) Steps() []Step {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Step(nil), p.steps...)
+}
+
+// Reset очищает план перед запуском следующего модуля.
+func (p *Plan) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.steps = nil
+}
+
+// Print выводит план целиком.
+func (p *Plan) Print() {
+	steps := p.Steps()
+	tui.Title("\n=== ПЛАН ИЗМЕНЕНИЙ (режим dry-run, ничего не выполнено) ===")
+	if len(steps) == 0 {
+		tui.Info("Изменений в системе не запланировано.")
+		return
+	}
+	for i, step := range steps {
+		fmt.Printf(" %3d. [%s] %s\n", i+1, step.Kind, tui.Mask(step.Description))
+	}
+	tui.Warn("Шаги, которые зависят от результата пропущенных действий (например, от скачанных файлов), могли не попасть в план.")
+}
+
+// formatSize выводит размер в удобочитаемом виде.
+func formatSize(size int64) string {
+	if size < 0 {
+		return "размер неизвестен"
+	}
+	return fmt.Sprintf("%.2f MB", float64(size)/1024/1024)
+}
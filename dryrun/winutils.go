@@ -0,0 +1,126 @@
+package dryrun
+
+import (
+	"context"
+	"fmt"
+	"goMH/core"
+	"os"
+	"sort"
+	"strings"
+)
+
+// WinUtils записывает изменяющие систему вызовы в план и пропускает остальные к реальной реализации.
+type WinUtils struct {
+	inner core.WinUtils
+	plan  *Plan
+}
+
+// NewWinUtils оборачивает реальные утилиты.
+func NewWinUtils(inner core.WinUtils, plan *Plan) *WinUtils {
+	return &WinUtils{inner: inner, plan: plan}
+}
+
+func (w *WinUtils) RunCommand(ctx context.Context, name string, args ...string) (string, error) {
+	w.plan.Add("команда", "%s", formatCommand(name, args))
+	return "", nil
+}
+
+func (w *WinUtils) RunCommandWithEnv(ctx context.Context, env map[string]string, name string, args ...string) (string, error) {
+	var pairs []string
+	for key, value := range env {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(pairs)
+	w.plan.Add("команда", "%s (окружение: %s)", formatCommand(name, args), strings.Join(pairs, ", "))
+	return "", nil
+}
+
+func (w *WinUtils) AddDefenderExclusion(path string) error {
+	w.plan.Add("defender", "добавить исключение %s", path)
+	return nil
+}
+
+func (w *WinUtils) SetServiceTriggers(serviceName string, triggers []string) error {
+	w.plan.Add("служба", "установить триггеры службы %s: %s", serviceName, strings.Join(triggers, " "))
+	return nil
+}
+
+func (w *WinUtils) CreateScheduledTask(taskName, executablePath, workingDir string) error {
+	w.plan.Add("планировщик", "создать задачу '%s' для %s (рабочая папка %s)", taskName, executablePath, workingDir)
+	return nil
+}
+
+func (w *WinUtils) StartProcess(executablePath, workingDir string) error {
+	w.plan.Add("процесс", "запустить %s", executablePath)
+	return nil
+}
+
+func (w *WinUtils) WriteFile(path string, data []byte, perm os.FileMode) error {
+	w.plan.Add("файл", "записать %s (%d байт)", path, len(data))
+	return nil
+}
+
+func (w *WinUtils) RemoveAll(path string) error {
+	w.plan.Add("файл", "удалить %s", path)
+	return nil
+}
+
+func (w *WinUtils) MkdirAll(path string, perm os.FileMode) error {
+	w.plan.Add("файл", "создать директорию %s", path)
+	return nil
+}
+
+func (w *WinUtils) Rename(oldPath, newPath string) error {
+	w.plan.Add("файл", "переместить %s -> %s", oldPath, newPath)
+	return nil
+}
+
+// --- Запросы только на чтение выполняются по-настоящему ---
+
+func (w *WinUtils) QueryCommand(ctx context.Context, name string, args ...string) (string, error) {
+	return w.inner.QueryCommand(ctx, name, args...)
+}
+
+func (w *WinUtils) ServiceExists(serviceName string) (bool, error) {
+	return w.inner.ServiceExists(serviceName)
+}
+
+func (w *WinUtils) ServiceRunning(serviceName string) (bool, error) {
+	return w.inner.ServiceRunning(serviceName)
+}
+
+func (w *WinUtils) Is64BitOS() bool {
+	return w.inner.Is64BitOS()
+}
+
+func (w *WinUtils) GetComPorts() ([]string, error) {
+	return w.inner.GetComPorts()
+}
+
+func (w *WinUtils) GetScanners() ([]core.ScannerInfo, error) {
+	return w.inner.GetScanners()
+}
+
+func (w *WinUtils) IsProcessRunning(processName string) (bool, error) {
+	return w.inner.IsProcessRunning(processName)
+}
+
+func (w *WinUtils) FindInstalledProgram(namePrefix string) (*core.InstalledProgram, error) {
+	return w.inner.FindInstalledProgram(namePrefix)
+}
+
+// formatCommand собирает командную строку для вывода, беря в кавычки аргументы с пробелами.
+func formatCommand(name string, args []string) string {
+	parts := []string{quoteArg(name)}
+	for _, arg := range args {
+		parts = append(parts, quoteArg(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+func quoteArg(arg string) string {
+	if strings.ContainsAny(arg, " \t") {
+		return `"` + arg + `"`
+	}
+	return arg
+}
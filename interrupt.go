@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"goMH/tui"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// interruptHandler перехватывает Ctrl+C. Во время операции первое нажатие отменяет
+// ее контекст: скачивание удаляет недокачанный файл, запущенный процесс завершается,
+// и управление возвращается в меню. Повторное нажатие, как и нажатие вне операции,
+// завершает программу.
+type interruptHandler struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	// onExit вызывается перед выходом из программы (закрывает журнал).
+	onExit func()
+}
+
+// watchInterrupts начинает перехват Ctrl+C.
+func watchInterrupts(onExit func()) *interruptHandler {
+	h := &interruptHandler{onExit: onExit}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for range signals {
+			h.handle()
+		}
+	}()
+	return h
+}
+
+func (h *interruptHandler) handle() {
+	h.mu.Lock()
+	cancel := h.cancel
+	h.cancel = nil
+	h.mu.Unlock()
+
+	if cancel == nil {
+		fmt.Println()
+		tui.Info("Выход из программы.")
+		if h.onExit != nil {
+			h.onExit()
+		}
+		os.Exit(exitInterrupted)
+	}
+	fmt.Println()
+	tui.Warn("Прерывание операции... Нажмите Ctrl+C еще раз, чтобы выйти из программы.")
+	cancel()
+}
+
+// operation возвращает контекст для очередной операции. done нужно вызвать по ее
+// завершении, после этого Ctrl+C снова завершает программу.
+func (h *interruptHandler) operation() (ctx context.Context, done func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h.mu.Lock()
+	h.cancel = cancel
+	h.mu.Unlock()
+	return ctx, func() {
+		h.mu.Lock()
+		h.cancel = nil
+		h.mu.Unlock()
+		cancel()
+	}
+}
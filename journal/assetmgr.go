@@ -0,0 +1,137 @@
+package journal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"goMH/config"
+	"goMH/core"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AssetManager записывает в журнал скачивания (URL, размер, хеш) и распаковки.
+type AssetManager struct {
+	inner   core.AssetManager
+	journal *Journal
+}
+
+// NewAssetManager оборачивает менеджер ресурсов (реальный или dry-run) записью в журнал.
+func NewAssetManager(inner core.AssetManager, j *Journal) *AssetManager {
+	return &AssetManager{inner: inner, journal: j}
+}
+
+func (a *AssetManager) Cfg() *config.Config {
+	return a.inner.Cfg()
+}
+
+func (a *AssetManager) Get(ctx context.Context, assetName string) (string, error) {
+	cachePath, err := a.DownloadToCache(ctx, assetName)
+	if err != nil {
+		return "", err
+	}
+	if err := a.ProcessFromCache(assetName, cachePath); err != nil {
+		return "", err
+	}
+	return filepath.Join(a.Cfg().RootPath, a.Cfg().AssetCatalog[assetName].Destination), nil
+}
+
+func (a *AssetManager) DownloadHTTPWithProgress(ctx context.Context, httpURL, localPath string) (bool, error) {
+	skipped, err := a.inner.DownloadHTTPWithProgress(ctx, httpURL, localPath)
+	a.recordDownload(httpURL, localPath, skipped, err)
+	return skipped, err
+}
+
+func (a *AssetManager) DownloadFTPWithProgress(ctx context.Context, ftpPath, localPath string) (bool, error) {
+	skipped, err := a.inner.DownloadFTPWithProgress(ctx, ftpPath, localPath)
+	a.recordDownload("ftp://"+a.Cfg().FTP.Host+ftpPath, localPath, skipped, err)
+	return skipped, err
+}
+
+func (a *AssetManager) DownloadMirrors(ctx context.Context, urls []string, localPath string) (bool, error) {
+	skipped, err := a.inner.DownloadMirrors(ctx, urls, localPath)
+	a.recordDownload(strings.Join(urls, " "), localPath, skipped, err)
+	return skipped, err
+}
+
+func (a *AssetManager) DownloadToCache(ctx context.Context, assetName string) (string, error) {
+	cachePath, err := a.inner.DownloadToCache(ctx, assetName)
+	rec := withError(Record{Event: EventDownload, URL: a.Cfg().AssetCatalog[assetName].URL, Path: cachePath, Message: "ресурс " + assetName}, err)
+	fillFileInfo(&rec)
+	a.journal.Write(rec)
+	return cachePath, err
+}
+
+func (a *AssetManager) ProcessFromCache(assetName, cachePath string) error {
+	err := a.inner.ProcessFromCache(assetName, cachePath)
+	destPath := filepath.Join(a.Cfg().RootPath, a.Cfg().AssetCatalog[assetName].Destination)
+	a.journal.Write(withError(Record{Event: EventExtract, Path: destPath, Message: "ресурс " + assetName + " из " + cachePath}, err))
+	return err
+}
+
+func (a *AssetManager) ExtractFile(zipPath, pathInZip, destPath string) error {
+	err := a.inner.ExtractFile(zipPath, pathInZip, destPath)
+	rec := withError(Record{Event: EventExtract, Path: destPath, Message: pathInZip + " из " + zipPath}, err)
+	fillFileInfo(&rec)
+	a.journal.Write(rec)
+	return err
+}
+
+func (a *AssetManager) PurgeAsset(assetName string) error {
+	err := a.inner.PurgeAsset(assetName)
+	a.journal.Write(withError(Record{Event: EventFileRemove, Message: "очистка ресурса " + assetName}, err))
+	return err
+}
+
+// --- Запросы только на чтение в журнал не пишутся ---
+
+func (a *AssetManager) HTTPFileSize(httpURL string) (int64, error) {
+	return a.inner.HTTPFileSize(httpURL)
+}
+
+func (a *AssetManager) FTPFileSize(ftpPath string) (int64, error) {
+	return a.inner.FTPFileSize(ftpPath)
+}
+
+func (a *AssetManager) ListFTP(path string) ([]core.FTPEntry, error) {
+	return a.inner.ListFTP(path)
+}
+
+func (a *AssetManager) CachePath(assetName string) (string, error) {
+	return a.inner.CachePath(assetName)
+}
+
+func (a *AssetManager) FindInZip(zipPath, targetSuffix string) (string, error) {
+	return a.inner.FindInZip(zipPath, targetSuffix)
+}
+
+func (a *AssetManager) recordDownload(url, localPath string, skipped bool, err error) {
+	rec := withError(Record{Event: EventDownload, URL: url, Path: localPath}, err)
+	if skipped {
+		rec.Message = "файл уже в кэше"
+	}
+	fillFileInfo(&rec)
+	a.journal.Write(rec)
+}
+
+// fillFileInfo дописывает в запись размер и SHA-256 файла, если он существует.
+func fillFileInfo(rec *Record) {
+	if rec.Status != "ok" || rec.Path == "" {
+		return
+	}
+	file, err := os.Open(rec.Path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return
+	}
+	rec.Size = size
+	rec.SHA256 = hex.EncodeToString(hash.Sum(nil))
+}
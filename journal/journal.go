@@ -0,0 +1,201 @@
+// Package journal ведет журнал аудита: один JSON-объект на строку для каждого события сессии
+// (запуск модуля, выполненная команда, скачанный, записанный или удаленный файл).
+// По журналу поддержка восстанавливает, что именно goMH сделал на машине.
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Типы событий журнала.
+const (
+	EventSessionStart = "session_start"
+	EventSessionEnd   = "session_end"
+	EventModuleStart  = "module_start"
+	EventModuleFinish = "module_finish"
+	EventCommand      = "command"
+	EventDownload     = "download"
+	EventAttempt      = "download_attempt"
+	EventExtract      = "extract"
+	EventFileWrite    = "file_write"
+	EventFileRemove   = "file_remove"
+	EventFileRename   = "file_rename"
+	EventSystem       = "system_change"
+)
+
+// maxOutputLen - сколько символов вывода команды сохраняется в журнал.
+const maxOutputLen = 2000
+
+// Record - одна запись журнала. Пустые поля в JSON не попадают.
+type Record struct {
+	Time       time.Time `json:"time"`
+	Session    string    `json:"session"`
+	Event      string    `json:"event"`
+	Module     string    `json:"module,omitempty"`
+	Status     string    `json:"status,omitempty"` // "ok", "error" или "cancelled" (прервано по Ctrl+C)
+	Command    string    `json:"command,omitempty"`
+	Args       []string  `json:"args,omitempty"`
+	ExitCode   *int      `json:"exit_code,omitempty"`
+	DurationMs int64     `json:"duration_ms,omitempty"`
+	Output     string    `json:"output,omitempty"`
+	URL        string    `json:"url,omitempty"`
+	Path       string    `json:"path,omitempty"`
+	Size       int64     `json:"size,omitempty"`
+	SHA256     string    `json:"sha256,omitempty"`
+	Message    string    `json:"message,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Journal пишет записи в файл сессии. Методы безопасны для вызова из нескольких горутин.
+type Journal struct {
+	mu      sync.Mutex
+	file    *os.File
+	path    string
+	session string
+	module  string
+	secrets []string
+	// subscribers получают каждую записанную запись (см. Subscribe).
+	subscribers map[int]func(Record)
+	nextID      int
+}
+
+// secretPattern находит пары ключ=значение, похожие на пароли и токены.
+var secretPattern = regexp.MustCompile(`(?i)((?:pass(?:word)?|pwd|token|secret)[a-z_]*\s*[=:]\s*)("[^"]*"|[^\s,;&]+)`)
+
+// Open создает файл журнала новой сессии в директории dir.
+func Open(dir string) (*Journal, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("не удалось создать директорию журнала %s: %w", dir, err)
+	}
+	session := time.Now().Format("20060102_150405")
+	path := filepath.Join(dir, fmt.Sprintf("journal_%s.jsonl", session))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть файл журнала: %w", err)
+	}
+	return &Journal{file: file, path: path, session: session}, nil
+}
+
+// Path возвращает путь к файлу журнала текущей сессии.
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// AddSecret регистрирует значение, которое нужно маскировать во всех записях (например, пароль FTP).
+func (j *Journal) AddSecret(value string) {
+	if strings.TrimSpace(value) == "" {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.secrets = append(j.secrets, value)
+	// Длинные значения заменяем первыми, чтобы короткий секрет не разрезал длинный
+	sort.Slice(j.secrets, func(a, b int) bool { return len(j.secrets[a]) > len(j.secrets[b]) })
+}
+
+// SetModule задает модуль, к которому будут относиться следующие записи.
+func (j *Journal) SetModule(moduleID string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.module = moduleID
+}
+
+// Write дополняет запись служебными полями, маскирует секреты и добавляет ее в журнал.
+// Ошибки записи не прерывают работу модуля: журнал не должен ломать установку.
+func (j *Journal) Write(rec Record) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	rec.Session = j.session
+	if rec.Module == "" {
+		rec.Module = j.module
+	}
+	rec.Output = truncate(j.mask(rec.Output), maxOutputLen)
+	rec.Command = j.mask(rec.Command)
+	rec.URL = j.mask(rec.URL)
+	rec.Message = j.mask(rec.Message)
+	rec.Error = truncate(j.mask(rec.Error), maxOutputLen)
+	if len(rec.Args) > 0 {
+		masked := make([]string, len(rec.Args))
+		for i, arg := range rec.Args {
+			masked[i] = j.mask(arg)
+		}
+		rec.Args = masked
+	}
+
+	for _, fn := range j.subscribers {
+		fn(rec)
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	_, _ = j.file.Write(append(data, '\n'))
+}
+
+// DownloadAttempt записывает попытку скачивания; подходит для assetmgr.Manager.OnAttempt.
+func (j *Journal) DownloadAttempt(url string, attempt int, err error) {
+	j.Write(withError(Record{Event: EventAttempt, URL: url, Message: fmt.Sprintf("попытка %d", attempt)}, err))
+}
+
+// Subscribe регистрирует получателя записей: fn вызывается для каждой новой записи
+// уже после маскировки секретов. fn не должен обращаться к журналу.
+// Возвращает функцию отписки.
+func (j *Journal) Subscribe(fn func(Record)) (unsubscribe func()) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.subscribers == nil {
+		j.subscribers = make(map[int]func(Record))
+	}
+	id := j.nextID
+	j.nextID++
+	j.subscribers[id] = fn
+	return func() {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		delete(j.subscribers, id)
+	}
+}
+
+// Close завершает сессию.
+func (j *Journal) Close() error {
+	j.Write(Record{Event: EventSessionEnd})
+	return j.file.Close()
+}
+
+// Mask скрывает в строке зарегистрированные секреты и значения параметров, похожих на пароли.
+func (j *Journal) Mask(s string) string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.mask(s)
+}
+
+func (j *Journal) mask(s string) string {
+	if s == "" {
+		return s
+	}
+	for _, secret := range j.secrets {
+		s = strings.ReplaceAll(s, secret, "***")
+	}
+	return secretPattern.ReplaceAllString(s, "${1}***")
+}
+
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit]) + fmt.Sprintf("... (обрезано, всего %d символов)", len(runes))
+}
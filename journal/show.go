@@ -0,0 +1,111 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"goMH/tui"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// List возвращает файлы журналов в директории, от новых к старым.
+func List(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "journal_*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	// Имя файла содержит время начала сессии, поэтому достаточно сортировки по имени
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+	return files, nil
+}
+
+// ReadFile читает все записи из файла журнала. Поврежденные строки пропускаются.
+func ReadFile(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// Show выводит журнал сессии в читаемом виде.
+func Show(path string) error {
+	records, err := ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать журнал %s: %w", path, err)
+	}
+
+	tui.Title(fmt.Sprintf("\n=== Журнал: %s (%d записей) ===", path, len(records)))
+	for _, rec := range records {
+		line := fmt.Sprintf("%s  %-13s %s", rec.Time.Format("2006-01-02 15:04:05"), rec.Event, describe(rec))
+		switch {
+		case rec.Status == "error":
+			tui.Error(line)
+		case rec.Status == "cancelled":
+			tui.Warn(line)
+		case rec.Event == EventModuleStart || rec.Event == EventModuleFinish:
+			tui.Title(line)
+		default:
+			fmt.Println(line)
+		}
+	}
+	return nil
+}
+
+// describe формирует краткое описание записи для вывода.
+func describe(rec Record) string {
+	var parts []string
+	if rec.Module != "" {
+		parts = append(parts, "["+rec.Module+"]")
+	}
+	if rec.Command != "" {
+		parts = append(parts, strings.TrimSpace(rec.Command+" "+strings.Join(rec.Args, " ")))
+	}
+	if rec.URL != "" {
+		parts = append(parts, rec.URL)
+	}
+	if rec.Path != "" {
+		parts = append(parts, rec.Path)
+	}
+	if rec.Message != "" {
+		parts = append(parts, rec.Message)
+	}
+	if rec.ExitCode != nil {
+		parts = append(parts, fmt.Sprintf("код=%d", *rec.ExitCode))
+	}
+	if rec.DurationMs > 0 {
+		parts = append(parts, fmt.Sprintf("%.1fс", float64(rec.DurationMs)/1000))
+	}
+	if rec.Size > 0 {
+		parts = append(parts, fmt.Sprintf("%d байт", rec.Size))
+	}
+	if rec.SHA256 != "" {
+		parts = append(parts, "sha256="+rec.SHA256[:12]+"...")
+	}
+	if rec.Error != "" {
+		parts = append(parts, "ошибка: "+firstLine(rec.Error))
+	}
+	return strings.Join(parts, " ")
+}
+
+func firstLine(s string) string {
+	if idx := strings.IndexByte(s, '\n'); idx >= 0 {
+		return s[:idx] + " ..."
+	}
+	return s
+}
@@ -0,0 +1,167 @@
+package journal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goMH/core"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WinUtils записывает в журнал каждое изменяющее систему действие и передает вызов дальше.
+type WinUtils struct {
+	inner   core.WinUtils
+	journal *Journal
+}
+
+// NewWinUtils оборачивает утилиты (реальные или dry-run) записью в журнал.
+func NewWinUtils(inner core.WinUtils, j *Journal) *WinUtils {
+	return &WinUtils{inner: inner, journal: j}
+}
+
+func (w *WinUtils) RunCommand(ctx context.Context, name string, args ...string) (string, error) {
+	start := time.Now()
+	output, err := w.inner.RunCommand(ctx, name, args...)
+	w.journal.Write(commandRecord(name, args, output, err, time.Since(start)))
+	return output, err
+}
+
+func (w *WinUtils) RunCommandWithEnv(ctx context.Context, env map[string]string, name string, args ...string) (string, error) {
+	start := time.Now()
+	output, err := w.inner.RunCommandWithEnv(ctx, env, name, args...)
+	rec := commandRecord(name, args, output, err, time.Since(start))
+
+	var keys []string
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	rec.Message = "переменные окружения: " + strings.Join(keys, ", ")
+	w.journal.Write(rec)
+	return output, err
+}
+
+func (w *WinUtils) AddDefenderExclusion(path string) error {
+	err := w.inner.AddDefenderExclusion(path)
+	w.journal.Write(withError(Record{Event: EventSystem, Message: "исключение Defender", Path: path}, err))
+	return err
+}
+
+func (w *WinUtils) SetServiceTriggers(serviceName string, triggers []string) error {
+	err := w.inner.SetServiceTriggers(serviceName, triggers)
+	w.journal.Write(withError(Record{
+		Event:   EventSystem,
+		Message: fmt.Sprintf("триггеры службы %s: %s", serviceName, strings.Join(triggers, " ")),
+	}, err))
+	return err
+}
+
+func (w *WinUtils) CreateScheduledTask(taskName, executablePath, workingDir string) error {
+	err := w.inner.CreateScheduledTask(taskName, executablePath, workingDir)
+	w.journal.Write(withError(Record{
+		Event:   EventSystem,
+		Message: fmt.Sprintf("задача планировщика '%s'", taskName),
+		Path:    executablePath,
+	}, err))
+	return err
+}
+
+func (w *WinUtils) StartProcess(executablePath, workingDir string) error {
+	err := w.inner.StartProcess(executablePath, workingDir)
+	w.journal.Write(withError(Record{Event: EventSystem, Message: "запуск процесса", Path: executablePath}, err))
+	return err
+}
+
+func (w *WinUtils) WriteFile(path string, data []byte, perm os.FileMode) error {
+	err := w.inner.WriteFile(path, data, perm)
+	w.journal.Write(withError(Record{Event: EventFileWrite, Path: path, Size: int64(len(data))}, err))
+	return err
+}
+
+func (w *WinUtils) RemoveAll(path string) error {
+	err := w.inner.RemoveAll(path)
+	w.journal.Write(withError(Record{Event: EventFileRemove, Path: path}, err))
+	return err
+}
+
+func (w *WinUtils) MkdirAll(path string, perm os.FileMode) error {
+	err := w.inner.MkdirAll(path, perm)
+	w.journal.Write(withError(Record{Event: EventFileWrite, Path: path, Message: "создание директории"}, err))
+	return err
+}
+
+func (w *WinUtils) Rename(oldPath, newPath string) error {
+	err := w.inner.Rename(oldPath, newPath)
+	w.journal.Write(withError(Record{Event: EventFileRename, Path: newPath, Message: "перемещение из " + oldPath}, err))
+	return err
+}
+
+// --- Запросы только на чтение в журнал не пишутся ---
+
+func (w *WinUtils) QueryCommand(ctx context.Context, name string, args ...string) (string, error) {
+	return w.inner.QueryCommand(ctx, name, args...)
+}
+
+func (w *WinUtils) ServiceExists(serviceName string) (bool, error) {
+	return w.inner.ServiceExists(serviceName)
+}
+
+func (w *WinUtils) ServiceRunning(serviceName string) (bool, error) {
+	return w.inner.ServiceRunning(serviceName)
+}
+
+func (w *WinUtils) Is64BitOS() bool {
+	return w.inner.Is64BitOS()
+}
+
+func (w *WinUtils) GetComPorts() ([]string, error) {
+	return w.inner.GetComPorts()
+}
+
+func (w *WinUtils) GetScanners() ([]core.ScannerInfo, error) {
+	return w.inner.GetScanners()
+}
+
+func (w *WinUtils) IsProcessRunning(processName string) (bool, error) {
+	return w.inner.IsProcessRunning(processName)
+}
+
+func (w *WinUtils) FindInstalledProgram(namePrefix string) (*core.InstalledProgram, error) {
+	return w.inner.FindInstalledProgram(namePrefix)
+}
+
+// commandRecord формирует запись о выполненной команде. Код завершения
+// берется из *exec.ExitError, если команда запустилась, но завершилась с ошибкой.
+func commandRecord(name string, args []string, output string, err error, duration time.Duration) Record {
+	rec := Record{
+		Event:      EventCommand,
+		Command:    name,
+		Args:       args,
+		DurationMs: duration.Milliseconds(),
+		Output:     output,
+	}
+	exitCode := 0
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitCode = exitErr.ExitCode()
+	}
+	if err == nil || exitCode != 0 {
+		rec.ExitCode = &exitCode
+	}
+	return withError(rec, err)
+}
+
+// withError проставляет статус записи по результату операции.
+func withError(rec Record, err error) Record {
+	if err != nil {
+		rec.Status = "error"
+		rec.Error = err.Error()
+	} else {
+		rec.Status = "ok"
+	}
+	return rec
+}
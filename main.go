@@ -1,36 +1,47 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"goMH/assetmgr"
 	"goMH/config"
 	"goMH/core"
-	"goMH/modules/dto"
+	"goMH/dryrun"
+	"goMH/journal"
 	"goMH/modules/frpc"
 	"goMH/modules/iiko"
+	"goMH/modules/packages"
 	"goMH/modules/regime"
 	"goMH/modules/remoteaccess"
 	"goMH/modules/serviceutils"
-	"goMH/modules/utm"
 	"goMH/modules/vcomcaster"
+	"goMH/secrets"
+	"goMH/selfupdate"
 	"goMH/tui"
 	"goMH/winutils"
-	"io"
-	"net/http"
 
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 type RealWinUtils struct{}
 
-func (rw *RealWinUtils) RunCommand(name string, args ...string) (string, error) {
-	return winutils.RunCommand(name, args...)
+func (rw *RealWinUtils) RunCommand(ctx context.Context, name string, args ...string) (string, error) {
+	return winutils.RunCommandContext(ctx, name, args...)
+}
+func (rw *RealWinUtils) QueryCommand(ctx context.Context, name string, args ...string) (string, error) {
+	return winutils.RunCommandContext(ctx, name, args...)
 }
 func (rw *RealWinUtils) ServiceExists(serviceName string) (bool, error) {
 	return winutils.ServiceExists(serviceName)
 }
+func (rw *RealWinUtils) ServiceRunning(serviceName string) (bool, error) {
+	return winutils.ServiceRunning(serviceName)
+}
 func (rw *RealWinUtils) AddDefenderExclusion(path string) error {
 	return winutils.AddDefenderExclusion(path)
 }
@@ -61,18 +72,49 @@ func (rw *RealWinUtils) GetScanners() ([]core.ScannerInfo, error) {
 func (rw *RealWinUtils) IsProcessRunning(processName string) (bool, error) {
 	return winutils.IsProcessRunning(processName)
 }
+func (rw *RealWinUtils) FindInstalledProgram(namePrefix string) (*core.InstalledProgram, error) {
+	program, err := winutils.FindInstalledProgram(namePrefix)
+	if err != nil || program == nil {
+		return nil, err
+	}
+	uninstall := program.QuietUninstallString
+	if uninstall == "" {
+		uninstall = program.UninstallString
+	}
+	return &core.InstalledProgram{
+		Name:            program.DisplayName,
+		Version:         program.DisplayVersion,
+		UninstallString: uninstall,
+	}, nil
+}
 func (rw *RealWinUtils) CreateScheduledTask(taskName, executablePath, workingDir string) error {
 	return winutils.CreateScheduledTask(taskName, executablePath, workingDir)
 }
-func (rw *RealWinUtils) RunCommandWithEnv(env map[string]string, name string, args ...string) (string, error) {
-	return winutils.RunCommandWithEnv(env, name, args...)
+func (rw *RealWinUtils) RunCommandWithEnv(ctx context.Context, env map[string]string, name string, args ...string) (string, error) {
+	return winutils.RunCommandWithEnv(ctx, env, name, args...)
+}
+func (rw *RealWinUtils) StartProcess(executablePath, workingDir string) error {
+	return winutils.StartProcess(executablePath, workingDir)
+}
+func (rw *RealWinUtils) WriteFile(path string, data []byte, perm os.FileMode) error {
+	return winutils.WriteFile(path, data, perm)
+}
+func (rw *RealWinUtils) RemoveAll(path string) error {
+	return winutils.RemoveAll(path)
+}
+func (rw *RealWinUtils) MkdirAll(path string, perm os.FileMode) error {
+	return winutils.MkdirAll(path, perm)
+}
+func (rw *RealWinUtils) Rename(oldPath, newPath string) error {
+	return winutils.Rename(oldPath, newPath)
 }
 
 // getConfigPath определяет, какой путь к конфигурации использовать:
-// из флага, локальный или удаленный.
-func getConfigPath(configFlag *string) (string, error) {
+// из флага, config.json рядом с exe или удаленный (URL). implicit - путь выбран без флага:
+// у локального файла тогда требуется подпись (см. config.LoadOptions.RequireLocalSignature).
+func getConfigPath(configFlag *string) (path string, implicit bool, err error) {
 	const defaultConfigName = "config.json"
-	const remoteConfigURL = "http://f.serty.top/distr/installer/config.json" // Используем http, если https недоступен
+	const remoteConfigURL = "https://f.serty.top/distr/installer/config.json"
 
 	// Проверяем, был ли флаг изменен пользователем
 	flagWasSet := false
@@ -85,77 +127,169 @@ func getConfigPath(configFlag *string) (string, error) {
 	// Если флаг был явно задан (даже если он равен "config.json"), используем его значение
 	if flagWasSet {
 		tui.InfoF("Используется конфигурация, указанная в аргументе: %s", *configFlag)
-		return *configFlag, nil
+		return *configFlag, false, nil
 	}
 
-	// Флаг не был задан, проверяем наличие config.json рядом с exe
-	if _, err := os.Stat(defaultConfigName); err == nil {
-		tui.InfoF("Найден локальный файл конфигурации: %s", defaultConfigName)
-		return defaultConfigName, nil
+	// Флаг не был задан, проверяем наличие config.json рядом с exe (не в текущей директории)
+	localPath := filepath.Join(exeDir(), defaultConfigName)
+	if _, err := os.Stat(localPath); err == nil {
+		tui.InfoF("Найден локальный файл конфигурации: %s", localPath)
+		return localPath, true, nil
 	}
 
-	// Локального файла нет, скачиваем с удаленного ресурса
-	tui.Warn(fmt.Sprintf("Локальный %s не найден. Попытка загрузить конфигурацию с %s", defaultConfigName, remoteConfigURL))
+	// Локального файла нет - используем удаленную конфигурацию. Ее подпись проверяет config.LoadConfig.
+	tui.Warn(fmt.Sprintf("Локальный %s не найден. Будет использована конфигурация с %s", defaultConfigName, remoteConfigURL))
+	return remoteConfigURL, true, nil
+}
 
-	resp, err := http.Get(remoteConfigURL)
+// exeDir возвращает директорию exe (текущую, если путь к exe определить не удалось).
+func exeDir() string {
+	exePath, err := os.Executable()
 	if err != nil {
-		return "", fmt.Errorf("не удалось выполнить запрос на скачивание конфигурации: %w", err)
+		return "."
 	}
-	defer resp.Body.Close()
+	return filepath.Dir(exePath)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("сервер вернул ошибку при скачивании конфигурации: %s", resp.Status)
+// localOverridePath возвращает путь к config.local.json рядом с exe.
+func localOverridePath() string {
+	return filepath.Join(exeDir(), config.LocalOverrideName)
+}
+
+// secretsResolver возвращает источник секретов для команд, которые что-то устанавливают.
+// Для команд только на чтение ссылки "secret:имя" не подставляются и пароль хранилища не нужен.
+// Секрет, который не удалось получить, не мешает запуску меню: ошибку вернет модуль,
+// которому он нужен (см. config.Config.SecretError).
+func secretsResolver(args []string) *secrets.Resolver {
+	if !commandNeedsAdmin(args) || (len(args) > 0 && args[0] == "secret") {
+		return nil
 	}
+	return &secrets.Resolver{Passphrase: vaultPassphrase}
+}
 
-	// Создаем временный файл для хранения конфигурации
-	tempFile, err := os.CreateTemp("", "config-*.json")
-	if err != nil {
-		return "", fmt.Errorf("не удалось создать временный файл для конфигурации: %w", err)
+// vaultPassphrase берет пароль хранилища из GOMH_VAULT_PASSPHRASE или спрашивает его.
+func vaultPassphrase() (string, error) {
+	if passphrase := os.Getenv(secrets.EnvPassphrase); passphrase != "" {
+		return passphrase, nil
 	}
-	defer tempFile.Close()
+	return tui.ReadSecret("Пароль хранилища секретов")
+}
 
-	_, err = io.Copy(tempFile, resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("не удалось записать конфигурацию во временный файл: %w", err)
+// sitePicker возвращает выбор площадки из списка для интерактивного режима.
+func sitePicker(interactive bool) func([]config.SiteDef) (string, error) {
+	if !interactive {
+		return nil
+	}
+	return func(sites []config.SiteDef) (string, error) {
+		options := make([]core.Option, len(sites))
+		for i, site := range sites {
+			label := site.Name
+			if site.Description != "" {
+				label = fmt.Sprintf("%s - %s", site.Name, site.Description)
+			}
+			options[i] = core.Option{Value: site.Name, Label: label}
+		}
+		for {
+			idx, err := tui.NewTerminalPrompter().Choose("site", "Выберите площадку", options)
+			if errors.Is(err, core.ErrCancelled) {
+				tui.Warn("Площадку нужно выбрать, чтобы продолжить.")
+				continue
+			}
+			if err != nil {
+				return "", err
+			}
+			return sites[idx].Name, nil
+		}
 	}
+}
 
-	tui.Success("Конфигурация успешно загружена с удаленного сервера.")
-	return tempFile.Name(), nil
+// sensitiveValues возвращает значения, которые маскируются в выводе и журнале:
+// полученные секреты и пароли, заданные в конфигурации открытым текстом.
+func sensitiveValues(cfg *config.Config) []string {
+	values := append([]string{}, cfg.Secrets()...)
+	for _, value := range []string{cfg.FTP.Pass, cfg.FrpcConfig.ServerConfig.Pass, cfg.RegimeConfig.AdminPassword, cfg.Reporting.Token} {
+		if !secrets.IsRef(value) {
+			values = append(values, value)
+		}
+	}
+	return values
 }
 
 func main() {
 	// 0. Обработка аргументов командной строки
 	configPathFlag := flag.String("config", "config.json", "Путь к файлу конфигурации (локальный или URL)")
+	answersPathFlag := flag.String("answers", "", "Путь к файлу ответов (JSON или YAML) для автоматической установки")
+	dryRunFlag := flag.Bool("dry-run", false, "Не изменять систему, а только показать план действий")
+	askMissingFlag := flag.Bool("ask-missing", false, "Спрашивать в консоли ответы, которых нет в файле ответов (по умолчанию - ошибка)")
+	siteFlag := flag.String("site", "", "Площадка (бренд) из раздела sites конфигурации; выбор запоминается")
+	allowUnsignedFlag := flag.Bool("allow-unsigned-config", false, "Разрешить конфигурацию без действительной подписи (только для отладки)")
+	flag.Usage = printUsage
 	flag.Parse()
+	args := flag.Args()
+	interactive := len(args) == 0
+
+	// Удаляем файл, оставшийся от предыдущего самообновления
+	if exePath, err := os.Executable(); err == nil {
+		selfupdate.CleanupOld(exePath)
+	}
 
 	// 1. Проверка прав администратора
-	if !winutils.IsAdmin() {
+	isAdmin := winutils.IsAdmin()
+	if !isAdmin && commandNeedsAdmin(args) {
 		tui.Error("Ошибка: Для выполнения требуются права администратора.")
 		tui.Error("Пожалуйста, запустите эту программу от имени Администратора.")
-		fmt.Println("\nНажмите Enter для выхода...")
-		fmt.Scanln()
+		if interactive {
+			fmt.Println("\nНажмите Enter для выхода...")
+			fmt.Scanln()
+		}
 		os.Exit(1)
 	}
-	tui.Success("Приложение запущено с правами администратора.")
+	if isAdmin {
+		tui.Success("Приложение запущено с правами администратора.")
+	}
 
 	// 2. Получение пути к конфигурации (новая логика)
-	finalConfigPath, err := getConfigPath(configPathFlag)
+	finalConfigPath, implicitConfig, err := getConfigPath(configPathFlag)
 	if err != nil {
 		log.Fatalf("Критическая ошибка: не удалось определить источник конфигурации: %v", err)
 	}
 
 	// 3. Загрузка конфигурации
-	cfg, err := config.LoadConfig(finalConfigPath)
+	cfg, err := config.LoadConfig(finalConfigPath, config.LoadOptions{
+		AllowUnsigned:         *allowUnsignedFlag,
+		RequireLocalSignature: implicitConfig,
+		// "config validate" сам проверяет конфигурацию и выводит полный отчет
+		SkipValidation:    len(args) > 0 && args[0] == "config",
+		LocalOverridePath: localOverridePath(),
+		Secrets:           secretsResolver(args),
+		Site:              *siteFlag,
+		PickSite:          sitePicker(interactive),
+	})
 	if err != nil {
 		log.Fatalf("Критическая ошибка: не удалось загрузить конфигурацию: %v", err)
 	}
 
+	// Пароли и секреты не должны попадать в вывод
+	for _, secret := range sensitiveValues(cfg) {
+		tui.AddSecret(secret)
+	}
+
 	// 4. Инициализация менеджера ресурсов
 	assetManager, err := assetmgr.New(cfg)
 	if err != nil {
 		log.Fatalf("Критическая ошибка: не удалось инициализировать менеджер ресурсов: %v", err)
 	}
 
+	// Загрузка файла ответов, если он указан
+	var answers *config.Answers
+	if *answersPathFlag != "" {
+		answers, err = config.LoadAnswers(*answersPathFlag)
+		if err != nil {
+			log.Fatalf("Критическая ошибка: не удалось загрузить файл ответов: %v", err)
+		}
+		tui.InfoF("Используется файл ответов: %s", *answersPathFlag)
+	}
+
 	// Создаём реальный объект утилит
 	RealWinUtils := &RealWinUtils{}
 
@@ -168,39 +302,106 @@ func main() {
 		"Regime":       &regime.Module{},
 		"RemoteAccess": &remoteaccess.Module{},
 		"ServiceUtils": &serviceutils.Module{},
-		"DTO":          &dto.Module{},
-		"UTM":          &utm.Module{},
 	}
 
-	// 6. Основной цикл меню
+	// Пакеты из секции "packages" (и устаревших dto_config/utm_config) регистрируются
+	// динамически. Объявленный пакет доступен, даже если его нет в списке "modules".
+	for _, def := range cfg.PackageDefs() {
+		if def.ID == "" {
+			tui.Warn("В секции 'packages' найден пакет без id, он пропущен.")
+			continue
+		}
+		if _, exists := registeredModules[def.ID]; exists {
+			tui.Warn(fmt.Sprintf("Пакет '%s' совпадает по ID со встроенным модулем и пропущен.", def.ID))
+			continue
+		}
+		registeredModules[def.ID] = packages.New(def)
+		if !cfg.HasModule(def.ID) {
+			cfg.Modules = append(cfg.Modules, config.ModuleDef{ID: def.ID})
+		}
+	}
+
+	// Модули без реализации в этой сборке просто не попадут в меню, но о них стоит знать.
+	// Полный отчет выводит "goMH config validate".
+	if len(args) == 0 || args[0] != "config" {
+		for _, problem := range cfg.ValidateModules(moduleIDs(registeredModules)) {
+			tui.Warn(fmt.Sprintf("Конфигурация: %s", problem))
+		}
+	}
+
+	app := &App{
+		Cfg:        cfg,
+		AM:         assetManager,
+		Cache:      assetManager,
+		WU:         RealWinUtils,
+		Modules:    registeredModules,
+		Answers:    answers,
+		AskMissing: *askMissingFlag,
+	}
+
+	// В режиме dry-run модули работают через записывающие обертки
+	if *dryRunFlag {
+		app.Plan = dryrun.NewPlan()
+		app.AM = dryrun.NewAssetManager(assetManager, app.Plan)
+		app.WU = dryrun.NewWinUtils(RealWinUtils, app.Plan)
+		tui.Warn("Включен режим dry-run: изменения в систему вноситься не будут.")
+	}
+
+	// Журнал аудита сессии: все действия модулей проходят через журналирующие обертки
+	if commandWritesJournal(args) {
+		j, err := journal.Open(filepath.Join(cfg.RootPath, "logs"))
+		if err != nil {
+			tui.Warn(fmt.Sprintf("Не удалось открыть журнал аудита: %v. Работа продолжится без журнала.", err))
+		} else {
+			for _, secret := range sensitiveValues(cfg) {
+				j.AddSecret(secret)
+			}
+			j.Write(journal.Record{Event: journal.EventSessionStart, Message: strings.Join(os.Args, " "), Status: sessionMode(*dryRunFlag)})
+			app.Journal = j
+			assetManager.OnAttempt = j.DownloadAttempt
+			app.AM = journal.NewAssetManager(app.AM, j)
+			app.WU = journal.NewWinUtils(app.WU, j)
+		}
+	}
+
+	// Ctrl+C прерывает текущую операцию, повторное нажатие завершает программу
+	app.Interrupts = watchInterrupts(app.Close)
+
+	// 6. Неинтерактивный режим: выполняем подкоманду и выходим с её кодом
+	if !interactive {
+		code := app.RunCommand(args)
+		app.Close()
+		os.Exit(code)
+	}
+
+	// 7. Проверка обновлений и основной цикл меню
+	app.checkForUpdate()
+	prompter := tui.NewTerminalPrompter()
 	for {
 		var availableModules []tui.Installer
-		for _, modDef := range cfg.Modules {
-			if module, ok := registeredModules[modDef.ID]; ok {
-				availableModules = append(availableModules, module)
-			}
+		for _, module := range app.availableModules() {
+			availableModules = append(availableModules, module)
 		}
+		availableModules = append(availableModules, &statusEntry{app: app})
 
-		if len(availableModules) == 0 {
+		if len(availableModules) == 1 {
 			log.Fatal("В конфигурации не определено ни одного доступного модуля.")
 		}
 
-		selected, err := tui.ShowMenu(availableModules)
+		selected, err := tui.ShowMenu(cfg.Site, availableModules, func(module tui.Installer) string {
+			return app.stateLabel(module.(core.Installer))
+		})
 		if err != nil {
 			tui.Info("Выход из программы.")
+			app.Close()
 			os.Exit(0)
 		}
 
 		selectedModule := selected.(core.Installer)
 
-		err = selectedModule.Run(assetManager, RealWinUtils)
-		if err != nil {
-			tui.Error(fmt.Sprintf("\n--- ОПЕРАЦИЯ ЗАВЕРШИЛАСЬ С ОШИБКОЙ ---\n%v\n---------------------------------------\n", err))
-		} else {
-			tui.Success("\n--- Операция завершена успешно. ---")
-		}
+		err = app.runModule(selectedModule, app.prompterFor(selectedModule.ID(), nil, true))
+		printOutcome(err)
 
-		fmt.Println("\nНажмите Enter, чтобы вернуться в главное меню...")
-		fmt.Scanln()
+		prompter.Pause("\nНажмите Enter, чтобы вернуться в главное меню...")
 	}
 }
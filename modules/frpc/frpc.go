@@ -1,25 +1,29 @@
 package frpc
 
 import (
-	"archive/zip"
-	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"goMH/config"
 	"goMH/core"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
+
+	"gopkg.in/ini.v1"
 )
 
 type Module struct {
 	Cfg *config.FrpcConfig
+	// Site - выбранная площадка, она становится суффиксом имени туннеля.
+	Site string
 }
 
 type FrpsProxy struct {
@@ -39,103 +43,319 @@ func (m *Module) MenuText() string {
 	return "Fast Reverse Proxy Client (проброс портов)"
 }
 
-func (m *Module) Run(am core.AssetManager, wu core.WinUtils) error {
+// Run - совместимая обертка над методами жизненного цикла.
+func (m *Module) Run(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
 	m.Cfg = &am.Cfg().FrpcConfig
-	frpcExePath := filepath.Join(m.Cfg.InstallPath, "frpc.exe")
-	if _, err := os.Stat(frpcExePath); err == nil {
-		return m.runDiagnosticsWorkflow(am, wu)
+	m.Site = am.Cfg().Site
+	addPort := core.Action{
+		Option: core.Option{Value: "add-port", Label: "Добавить порт"},
+		Do: func() error {
+			if err := m.checkAPIPass(am.Cfg()); err != nil {
+				return err
+			}
+			return m.runAddPortWorkflow(ctx, wu, p)
+		},
 	}
-	return m.runFullInstallWorkflow(am, wu, false)
+	return core.RunLifecycle(ctx, m, am, wu, p, addPort)
 }
-func (m *Module) runDiagnosticsWorkflow(am core.AssetManager, wu core.WinUtils) error {
-	fmt.Println("\nОбнаружена существующая установка FRPC.")
-	fmt.Print("Введите 'R' для добавления порта, 'C' для полной переустановки или 'U' для удаления (R/C/U): ")
-	reader := bufio.NewReader(os.Stdin)
-	choice, _ := reader.ReadString('\n')
-	choice = strings.TrimSpace(strings.ToUpper(choice))
-	switch choice {
-	case "R":
-		return m.runAddPortWorkflow(wu, true)
-	case "C":
-		fmt.Println("Выполняем полную переустановку...")
-		return m.runFullInstallWorkflow(am, wu, true)
-	case "U":
-		fmt.Print("ВНИМАНИЕ: Это полностью удалит FRPC. Вы уверены? (y/n): ")
-		confirm, _ := reader.ReadString('\n')
-		if strings.TrimSpace(strings.ToLower(confirm)) != "y" {
-			fmt.Println("Удаление отменено.")
-			return nil
-		}
-		return m.uninstall(wu)
-	default:
-		fmt.Println("Отмена операции.")
+
+// Detect считает FRPC установленным, если в директории установки есть frpc.exe.
+func (m *Module) Detect(am core.AssetManager, wu core.WinUtils) (core.Status, error) {
+	m.Cfg = &am.Cfg().FrpcConfig
+	if _, err := os.Stat(filepath.Join(m.Cfg.InstallPath, "frpc.exe")); err != nil {
+		return core.Status{State: core.StateNotInstalled}, nil
+	}
+	status := core.Status{State: core.StateInstalled}
+	if ports := getLocalUsedPorts(filepath.Join(m.Cfg.InstallPath, "frpc.ini")); len(ports) > 0 {
+		status.Details = fmt.Sprintf("туннелей: %d", len(ports))
+	}
+	return status, nil
+}
+
+func (m *Module) Install(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	m.Cfg = &am.Cfg().FrpcConfig
+	m.Site = am.Cfg().Site
+	return m.runFullInstallWorkflow(ctx, am, wu, p, false)
+}
+
+// Upgrade выполняет полную переустановку FRPC с новой настройкой туннеля.
+func (m *Module) Upgrade(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	m.Cfg = &am.Cfg().FrpcConfig
+	m.Site = am.Cfg().Site
+	fmt.Println("Выполняем полную переустановку...")
+	return m.runFullInstallWorkflow(ctx, am, wu, p, true)
+}
+
+func (m *Module) Uninstall(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	m.Cfg = &am.Cfg().FrpcConfig
+	confirmed, err := p.Confirm("confirm", "ВНИМАНИЕ: Это полностью удалит FRPC. Вы уверены?", false)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Println("Удаление отменено.")
 		return nil
 	}
+	return m.uninstall(ctx, wu)
+}
+
+// Verify проверяет файлы установки, туннели в frpc.ini, службу и процесс frpc.
+func (m *Module) Verify(am core.AssetManager, wu core.WinUtils) ([]string, error) {
+	m.Cfg = &am.Cfg().FrpcConfig
+	var problems []string
+	for _, name := range []string{"frpc.exe", "nssm.exe", "frpc.ini"} {
+		if _, err := os.Stat(filepath.Join(m.Cfg.InstallPath, name)); err != nil {
+			problems = append(problems, fmt.Sprintf("Файл %s не найден.", name))
+		}
+	}
+	if len(getLocalUsedPorts(filepath.Join(m.Cfg.InstallPath, "frpc.ini"))) == 0 {
+		problems = append(problems, "В frpc.ini не настроено ни одного туннеля.")
+	}
+	exists, err := wu.ServiceExists(m.Cfg.ServiceName)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось проверить службу %s: %w", m.Cfg.ServiceName, err)
+	}
+	if !exists {
+		problems = append(problems, fmt.Sprintf("Служба '%s' не найдена.", m.Cfg.ServiceName))
+	} else if running, err := wu.IsProcessRunning("frpc"); err == nil && !running {
+		problems = append(problems, "Процесс frpc не запущен.")
+	}
+	return problems, nil
+}
+
+// Inspect сообщает состояние службы и процесса FRPC и туннели из frpc.ini.
+func (m *Module) Inspect(am core.AssetManager, wu core.WinUtils) ([]core.Check, error) {
+	m.Cfg = &am.Cfg().FrpcConfig
+	checks := []core.Check{core.ServiceCheck(wu, m.Cfg.ServiceName), core.ProcessCheck(wu, "frpc")}
+
+	tunnels, err := ReadTunnels(filepath.Join(m.Cfg.InstallPath, "frpc.ini"))
+	if err != nil {
+		return checks, nil
+	}
+	if len(tunnels) == 0 {
+		checks = append(checks, core.Check{Name: "туннели", Value: "не настроены"})
+	}
+	for _, t := range tunnels {
+		checks = append(checks, core.Check{
+			Name:  "туннель " + t.Name,
+			OK:    true,
+			Value: fmt.Sprintf("127.0.0.1:%s -> %s:%s", t.LocalPort, m.Cfg.ServerConfig.Host, t.RemotePort),
+		})
+	}
+	return checks, nil
+}
+
+// Tunnel - секция туннеля в frpc.ini.
+type Tunnel struct {
+	Name       string `json:"name"`
+	LocalPort  string `json:"local_port"`
+	RemotePort string `json:"remote_port"`
+}
+
+// ReadTunnels читает секции туннелей из frpc.ini (все, кроме [common]).
+func ReadTunnels(iniPath string) ([]Tunnel, error) {
+	file, err := ini.Load(iniPath)
+	if err != nil {
+		return nil, err
+	}
+	var tunnels []Tunnel
+	for _, section := range file.Sections() {
+		if section.Name() == ini.DefaultSection || section.Name() == "common" {
+			continue
+		}
+		tunnels = append(tunnels, Tunnel{
+			Name:       section.Name(),
+			LocalPort:  section.Key("local_port").String(),
+			RemotePort: section.Key("remote_port").String(),
+		})
+	}
+	return tunnels, nil
 }
-func (m *Module) runFullInstallWorkflow(am core.AssetManager, wu core.WinUtils, isReinstall bool) error {
+
+// runFullInstallWorkflow выполняет установку по шагам. При ошибке выполненные шаги откатываются,
+// чтобы не оставлять службу NSSM без секции туннеля в frpc.ini.
+func (m *Module) runFullInstallWorkflow(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter, isReinstall bool) error {
+	if err := m.checkAPIPass(am.Cfg()); err != nil {
+		return err
+	}
 	if isReinstall {
-		m.uninstall(wu)
+		m.uninstall(ctx, wu)
+	}
+	// createdDir - директорию установки создал этот запуск; существовавшую раньше откат не удаляет
+	createdDir := false
+	// iniBefore - содержимое frpc.ini до настройки туннеля: откат возвращает прежние
+	// секции, а удаляет файл, только если его создал этот запуск
+	iniPath := filepath.Join(m.Cfg.InstallPath, "frpc.ini")
+	var iniBefore []byte
+	iniExisted := false
+	return core.RunSteps(ctx, p,
+		core.Step{
+			Name: "подготовка директории установки",
+			Do: func(ctx context.Context) error {
+				if _, err := os.Stat(m.Cfg.InstallPath); errors.Is(err, fs.ErrNotExist) {
+					if err := wu.MkdirAll(m.Cfg.InstallPath, 0755); err != nil {
+						return fmt.Errorf("не удалось создать директорию %s: %w", m.Cfg.InstallPath, err)
+					}
+					createdDir = true
+				}
+				wu.AddDefenderExclusion(am.Cfg().RootPath)
+				return nil
+			},
+			Undo: func(ctx context.Context) error {
+				if !createdDir {
+					return nil
+				}
+				return wu.RemoveAll(m.Cfg.InstallPath)
+			},
+		},
+		core.Step{
+			Name: "скачивание и распаковка компонентов",
+			Do:   func(ctx context.Context) error { return m.downloadAndExtractComponents(ctx, am, wu) },
+		},
+		core.Step{
+			Name: "настройка туннеля",
+			Do: func(ctx context.Context) error {
+				data, err := os.ReadFile(iniPath)
+				switch {
+				case err == nil:
+					iniBefore, iniExisted = data, true
+				case !errors.Is(err, fs.ErrNotExist):
+					return fmt.Errorf("не удалось прочитать %s: %w", iniPath, err)
+				}
+				return m.configureTunnel(ctx, wu, p)
+			},
+			Undo: func(ctx context.Context) error {
+				if iniExisted {
+					return wu.WriteFile(iniPath, iniBefore, 0644)
+				}
+				return wu.RemoveAll(iniPath)
+			},
+		},
+		core.Step{
+			Name: "создание службы",
+			Do:   func(ctx context.Context) error { return m.setupNssmService(ctx, wu) },
+			Undo: func(ctx context.Context) error {
+				// nssm install мог не дойти до создания службы
+				if exists, err := wu.ServiceExists(m.Cfg.ServiceName); err != nil || !exists {
+					return err
+				}
+				_, err := wu.RunCommand(ctx, "sc.exe", "delete", m.Cfg.ServiceName)
+				return err
+			},
+		},
+		core.Step{
+			Name: "запуск службы",
+			Do:   func(ctx context.Context) error { return m.restartService(ctx, wu) },
+			Undo: func(ctx context.Context) error {
+				if running, err := wu.ServiceRunning(m.Cfg.ServiceName); err != nil || !running {
+					return err
+				}
+				_, err := wu.RunCommand(ctx, "sc.exe", "stop", m.Cfg.ServiceName)
+				return err
+			},
+		},
+	)
+}
+
+// checkAPIPass проверяет, что пароль API FRPS получен: без него нельзя подобрать свободный
+// порт, и установку лучше не начинать.
+func (m *Module) checkAPIPass(cfg *config.Config) error {
+	if err := cfg.SecretError(m.Cfg.ServerConfig.Pass); err != nil {
+		return fmt.Errorf("пароль API FRPS (frpc_config.server_config.pass): %w", err)
 	}
-	_ = os.MkdirAll(m.Cfg.InstallPath, 0755)
-	wu.AddDefenderExclusion(am.Cfg().RootPath)
-	if err := m.downloadAndExtractComponents(am, wu); err != nil {
+	return nil
+}
+
+// runAddPortWorkflow добавляет туннель к существующей установке и перезапускает службу.
+func (m *Module) runAddPortWorkflow(ctx context.Context, wu core.WinUtils, p core.Prompter) error {
+	if err := m.configureTunnel(ctx, wu, p); err != nil {
 		return err
 	}
-	return m.runAddPortWorkflow(wu, false)
+	return m.restartService(ctx, wu)
 }
-func (m *Module) runAddPortWorkflow(wu core.WinUtils, isAddingToExisting bool) error {
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Print("Введите локальный порт для туннеля (например, 5985 для WinRM): ")
-	localPortStr, _ := reader.ReadString('\n')
-	localPortStr = strings.TrimSpace(localPortStr)
-	if localPortStr == "" {
-		localPortStr = "5985"
-	}
-	fmt.Print("Введите имя этого узла (например, SRV-BACKOFFICE-01): ")
-	alias, _ := reader.ReadString('\n')
-	alias = strings.TrimSpace(alias)
-	freePort, err := m.findFreePort()
+
+// configureTunnel спрашивает параметры туннеля, подбирает удаленный порт и добавляет секцию в frpc.ini.
+func (m *Module) configureTunnel(ctx context.Context, wu core.WinUtils, p core.Prompter) error {
+	localPortStr, err := p.Input("local-port", "Введите локальный порт для туннеля (например, 5985 для WinRM)", "5985", validatePort)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("Выбран удаленный порт: %d\n", freePort)
-	if err := m.updateFrpcIni(alias, localPortStr, strconv.Itoa(freePort)); err != nil {
+	alias, err := p.Input("alias", "Введите имя этого узла (например, SRV-BACKOFFICE-01)", "", validateAlias)
+	if err != nil {
 		return err
 	}
-	if !isAddingToExisting {
-		if err := m.setupNssmService(); err != nil {
-			return err
-		}
+	freePort, err := m.findFreePort(ctx, p)
+	if err != nil {
+		return err
 	}
+	fmt.Printf("Выбран удаленный порт: %d\n", freePort)
+	return m.updateFrpcIni(wu, alias, localPortStr, strconv.Itoa(freePort))
+}
+
+// restartService перезапускает службу. Остановленная служба (только что созданная) просто запускается.
+func (m *Module) restartService(ctx context.Context, wu core.WinUtils) error {
 	fmt.Println("Перезапускаем службу для применения изменений...")
-	wu.RunCommand("sc.exe", "stop", m.Cfg.ServiceName)
-	time.Sleep(2 * time.Second)
-	wu.RunCommand("sc.exe", "start", m.Cfg.ServiceName)
+	running, err := wu.ServiceRunning(m.Cfg.ServiceName)
+	if err != nil {
+		return fmt.Errorf("не удалось проверить состояние службы %s: %w", m.Cfg.ServiceName, err)
+	}
+	if running {
+		if _, err := wu.RunCommand(ctx, "sc.exe", "stop", m.Cfg.ServiceName); err != nil {
+			return fmt.Errorf("не удалось остановить службу %s: %w", m.Cfg.ServiceName, err)
+		}
+		time.Sleep(2 * time.Second)
+	}
+	if _, err := wu.RunCommand(ctx, "sc.exe", "start", m.Cfg.ServiceName); err != nil {
+		return fmt.Errorf("не удалось запустить службу %s: %w", m.Cfg.ServiceName, err)
+	}
 	fmt.Println("\n--- Настройка FRPC завершена ---")
 	return nil
 }
-func (m *Module) uninstall(wu core.WinUtils) error {
+func (m *Module) uninstall(ctx context.Context, wu core.WinUtils) error {
 	fmt.Println("Остановка и удаление службы FRPC...")
-	wu.RunCommand("sc.exe", "stop", m.Cfg.ServiceName)
+	wu.RunCommand(ctx, "sc.exe", "stop", m.Cfg.ServiceName)
 	time.Sleep(2 * time.Second)
-	wu.RunCommand("sc.exe", "delete", m.Cfg.ServiceName)
+	wu.RunCommand(ctx, "sc.exe", "delete", m.Cfg.ServiceName)
 	fmt.Println("Удаление директории установки...")
-	os.RemoveAll(m.Cfg.InstallPath)
+	wu.RemoveAll(m.Cfg.InstallPath)
 	fmt.Println("Очистка завершена.")
 	return nil
 }
 
-func (m *Module) downloadAndExtractComponents(am core.AssetManager, wu core.WinUtils) error {
+// Archive - архив, который модуль скачивает в кэш мимо каталога ресурсов.
+type Archive struct {
+	Name      string
+	URLs      []string
+	LocalPath string
+}
+
+// Archives возвращает архивы FRPC и NSSM: адреса с зеркалами и пути в кэше.
+func Archives(cfg *config.Config) (frpcZip, nssmZip Archive) {
+	frpcZip = Archive{
+		Name:      "frpc.zip",
+		URLs:      append([]string{cfg.FrpcConfig.FrpcDownloadURL}, cfg.FrpcConfig.FrpcMirrors...),
+		LocalPath: filepath.Join(cfg.AssetsCachePath, "frpc.zip"),
+	}
+	nssmZip = Archive{
+		Name:      "nssm.zip",
+		URLs:      append([]string{cfg.FrpcConfig.NssmDownloadURL}, cfg.FrpcConfig.NssmMirrors...),
+		LocalPath: filepath.Join(cfg.AssetsCachePath, "nssm.zip"),
+	}
+	return frpcZip, nssmZip
+}
+
+func (m *Module) downloadAndExtractComponents(ctx context.Context, am core.AssetManager, wu core.WinUtils) error {
 	fmt.Println("\n--- Скачивание и распаковка компонентов ---")
 
 	// 1. Скачиваем архив FRPC с помощью assetmgr
-	frpcZipPath := filepath.Join(am.Cfg().AssetsCachePath, "frpc.zip")
-	if _, err := am.DownloadHTTPWithProgress(m.Cfg.FrpcDownloadURL, frpcZipPath); err != nil {
+	frpcArchive, nssmArchive := Archives(am.Cfg())
+	frpcZipPath := frpcArchive.LocalPath
+	if _, err := am.DownloadMirrors(ctx, frpcArchive.URLs, frpcZipPath); err != nil {
 		return fmt.Errorf("не удалось скачать FRPC: %w", err)
 	}
 
 	// 2. Находим путь к frpc.exe внутри архива
-	frpcPathInZip, err := findPathInZip(frpcZipPath, "frpc.exe")
+	frpcPathInZip, err := am.FindInZip(frpcZipPath, "frpc.exe")
 	if err != nil {
 		return fmt.Errorf("не найден frpc.exe в архиве: %w", err)
 	}
@@ -148,8 +368,8 @@ func (m *Module) downloadAndExtractComponents(am core.AssetManager, wu core.WinU
 	fmt.Println("frpc.exe успешно извлечен.")
 
 	// 4. Скачиваем архив NSSM с помощью assetmgr
-	nssmZipPath := filepath.Join(am.Cfg().AssetsCachePath, "nssm.zip")
-	if _, err := am.DownloadHTTPWithProgress(m.Cfg.NssmDownloadURL, nssmZipPath); err != nil {
+	nssmZipPath := nssmArchive.LocalPath
+	if _, err := am.DownloadMirrors(ctx, nssmArchive.URLs, nssmZipPath); err != nil {
 		return fmt.Errorf("не удалось скачать NSSM: %w", err)
 	}
 
@@ -163,7 +383,7 @@ func (m *Module) downloadAndExtractComponents(am core.AssetManager, wu core.WinU
 	}
 	nssmSubPath := filepath.Join(archDir, "nssm.exe")
 
-	nssmPathInZip, err := findPathInZip(nssmZipPath, nssmSubPath)
+	nssmPathInZip, err := am.FindInZip(nssmZipPath, nssmSubPath)
 	if err != nil {
 		return fmt.Errorf("не найден nssm.exe для архитектуры %s: %w", archDir, err)
 	}
@@ -178,31 +398,10 @@ func (m *Module) downloadAndExtractComponents(am core.AssetManager, wu core.WinU
 	return nil
 }
 
-func findPathInZip(zipPath, targetSuffix string) (string, error) {
-	r, err := zip.OpenReader(zipPath)
-	if err != nil {
-		return "", err
-	}
-	defer r.Close()
-
-	// Нормализуем разделители
-	targetSuffix = filepath.ToSlash(targetSuffix)
-
-	for _, f := range r.File {
-		if f.FileInfo().IsDir() {
-			continue
-		}
-		if strings.HasSuffix(filepath.ToSlash(f.Name), targetSuffix) {
-			return f.Name, nil // Возвращаем полный путь файла в архиве
-		}
-	}
-	return "", fmt.Errorf("файл, заканчивающийся на '%s', не найден в архиве '%s'", targetSuffix, zipPath)
-}
-
-func (m *Module) findFreePort() (int, error) {
+func (m *Module) findFreePort(ctx context.Context, p core.Prompter) (int, error) {
 	fmt.Println("Получение информации о прокси с сервера FRPS...")
 	apiURL := fmt.Sprintf("https://%s/api/proxy/tcp", m.Cfg.ServerConfig.Host)
-	req, _ := http.NewRequest("GET", apiURL, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	req.SetBasicAuth(m.Cfg.ServerConfig.User, m.Cfg.ServerConfig.Pass)
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
@@ -223,17 +422,11 @@ func (m *Module) findFreePort() (int, error) {
 	}
 	if hasOfflineProxy {
 		fmt.Println("\nВНИМАНИЕ: Обнаружены оффлайн-прокси. Автоматический выбор порта рискован.")
-		reader := bufio.NewReader(os.Stdin)
-		for {
-			fmt.Print("Пожалуйста, введите желаемый удаленный порт вручную: ")
-			portStr, _ := reader.ReadString('\n')
-			port, err := strconv.Atoi(strings.TrimSpace(portStr))
-			if err != nil {
-				fmt.Println("Неверный ввод. Пожалуйста, введите число.")
-				continue
-			}
-			return port, nil
+		portStr, err := p.Input("remote-port", "Пожалуйста, введите желаемый удаленный порт вручную", "", validatePort)
+		if err != nil {
+			return 0, err
 		}
+		return strconv.Atoi(portStr)
 	}
 	fmt.Println("Все прокси онлайн. Выполняем автоматический поиск свободного порта...")
 	usedPorts := make(map[int]bool)
@@ -246,9 +439,10 @@ func (m *Module) findFreePort() (int, error) {
 	for _, p := range localUsedPorts {
 		usedPorts[p] = true
 	}
-	parts := strings.Split(m.Cfg.PortRange, "-")
-	startPort, _ := strconv.Atoi(parts[0])
-	endPort, _ := strconv.Atoi(parts[1])
+	startPort, endPort, err := config.ParsePortRange(m.Cfg.PortRange)
+	if err != nil {
+		return 0, err
+	}
 	for port := startPort; port <= endPort; port++ {
 		if !usedPorts[port] {
 			return port, nil
@@ -256,7 +450,7 @@ func (m *Module) findFreePort() (int, error) {
 	}
 	return 0, fmt.Errorf("свободные порты в диапазоне %s не найдены", m.Cfg.PortRange)
 }
-func (m *Module) updateFrpcIni(alias, localPort, remotePort string) error { // ...
+func (m *Module) updateFrpcIni(wu core.WinUtils, alias, localPort, remotePort string) error { // ...
 	iniPath := filepath.Join(m.Cfg.InstallPath, "frpc.ini")
 	content, err := os.ReadFile(iniPath)
 	var lines []string
@@ -271,7 +465,12 @@ func (m *Module) updateFrpcIni(alias, localPort, remotePort string) error { // .
 	} else {
 		lines = strings.Split(string(content), "\n")
 	}
-	newSectionName := fmt.Sprintf("[%s-MH]", alias)
+	// Суффикс имени туннеля - площадка, без площадки - "MH"
+	suffix := "MH"
+	if m.Site != "" {
+		suffix = m.Site
+	}
+	newSectionName := fmt.Sprintf("[%s-%s]", alias, suffix)
 	sectionExists := false
 	for _, line := range lines {
 		if strings.TrimSpace(line) == newSectionName {
@@ -281,7 +480,7 @@ func (m *Module) updateFrpcIni(alias, localPort, remotePort string) error { // .
 	}
 	if !sectionExists {
 		lines = append(lines, "", newSectionName, "type = tcp", "local_ip = 127.0.0.1", "local_port = "+localPort, "remote_port = "+remotePort)
-		err = os.WriteFile(iniPath, []byte(strings.Join(lines, "\r\n")), 0644)
+		err = wu.WriteFile(iniPath, []byte(strings.Join(lines, "\r\n")), 0644)
 		if err != nil {
 			return fmt.Errorf("не удалось записать в frpc.ini: %w", err)
 		}
@@ -291,7 +490,7 @@ func (m *Module) updateFrpcIni(alias, localPort, remotePort string) error { // .
 	}
 	return nil
 }
-func (m *Module) setupNssmService() error { // ...
+func (m *Module) setupNssmService(ctx context.Context, wu core.WinUtils) error { // ...
 	nssmExe := filepath.Join(m.Cfg.InstallPath, "nssm.exe")
 	frpcExe := filepath.Join(m.Cfg.InstallPath, "frpc.exe")
 	frpcIni := filepath.Join(m.Cfg.InstallPath, "frpc.ini")
@@ -302,14 +501,34 @@ func (m *Module) setupNssmService() error { // ...
 	}
 	fmt.Printf("Создание и настройка службы '%s' с помощью nssm...\n", m.Cfg.ServiceName)
 	for _, args := range commands {
-		cmd := exec.Command(nssmExe, args...)
-		if err := cmd.Run(); err != nil {
+		if _, err := wu.RunCommand(ctx, nssmExe, args...); err != nil {
 			return fmt.Errorf("ошибка при выполнении nssm %s: %w", args[0], err)
 		}
 	}
 	fmt.Println("Служба успешно настроена.")
 	return nil
 }
+
+// validatePort проверяет, что строка - корректный номер TCP-порта.
+func validatePort(value string) error {
+	port, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("порт должен быть числом от 1 до 65535")
+	}
+	return nil
+}
+
+// validateAlias проверяет имя узла, которое станет именем секции в frpc.ini.
+func validateAlias(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return errors.New("имя узла не может быть пустым")
+	}
+	if strings.ContainsAny(value, "[]") {
+		return errors.New("имя узла не может содержать квадратные скобки")
+	}
+	return nil
+}
+
 func getLocalUsedPorts(iniPath string) []int { // ...
 	content, err := os.ReadFile(iniPath)
 	if err != nil {
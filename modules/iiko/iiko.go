@@ -2,7 +2,7 @@ package iiko
 
 import (
 	"archive/zip"
-	"bufio"
+	"context"
 	"encoding/csv"
 	"errors"
 	"fmt"
@@ -15,7 +15,6 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -23,8 +22,6 @@ import (
 // DiscoveredVersions хранит найденные на FTP версии и их компоненты
 type DiscoveredVersions map[string][]config.IikoComponent
 
-var errUserChoseExit = errors.New("пользователь выбрал выход в главное меню")
-
 type IikoPatch struct {
 	Path        string
 	Name        string
@@ -38,10 +35,107 @@ type Module struct {
 	Cfg *config.IikoConfig
 }
 
+// distroCacheDir - директория дистрибутивов iiko в кэше ресурсов (см. distroDir).
+const distroCacheDir = "iiko"
+
+// versionDirPattern - имя директории версии iiko на FTP и в кэше, например "900".
+var versionDirPattern = regexp.MustCompile(`^\d{3}$`)
+
 func (m *Module) ID() string       { return "iiko" }
 func (m *Module) MenuText() string { return "iiko (Front, Back, Card)" }
 
-func (m *Module) Run(am core.AssetManager, wu core.WinUtils) error {
+// Run - совместимая обертка: модуль всегда предлагает выбрать и установить дистрибутив.
+func (m *Module) Run(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	return m.Install(ctx, am, wu, p)
+}
+
+// Detect ищет установленные компоненты по пути run_after,
+// а версии - по скачанным дистрибутивам в кэше.
+func (m *Module) Detect(am core.AssetManager, wu core.WinUtils) (core.Status, error) {
+	m.Cfg = &am.Cfg().IikoConfig
+	var installed []string
+	for _, component := range m.Cfg.ComponentsToFind {
+		if component.RunAfter == "" {
+			continue
+		}
+		if _, err := os.Stat(component.RunAfter); err == nil {
+			installed = append(installed, component.ID)
+		}
+	}
+	if len(installed) == 0 {
+		return core.Status{State: core.StateNotInstalled}, nil
+	}
+	return core.Status{
+		State:   core.StateInstalled,
+		Version: strings.Join(m.LocalVersions(am.Cfg().AssetsCachePath), ", "),
+		Details: strings.Join(installed, ", "),
+	}, nil
+}
+
+// LocalVersions возвращает версии iiko, дистрибутивы которых скачаны в кэш cachePath.
+func (m *Module) LocalVersions(cachePath string) []string {
+	baseDir := filepath.Join(cachePath, distroCacheDir)
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		return nil
+	}
+	var versions []string
+	for _, entry := range entries {
+		if !entry.IsDir() || !versionDirPattern.MatchString(entry.Name()) {
+			continue
+		}
+		for _, component := range m.Cfg.ComponentsToFind {
+			if _, err := os.Stat(filepath.Join(baseDir, entry.Name(), component.FileName)); err == nil {
+				versions = append(versions, entry.Name())
+				break
+			}
+		}
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// Inspect сообщает, какие компоненты iiko установлены, запущен ли iikoFront
+// и какие дистрибутивы лежат в кэше.
+func (m *Module) Inspect(am core.AssetManager, wu core.WinUtils) ([]core.Check, error) {
+	m.Cfg = &am.Cfg().IikoConfig
+	var checks []core.Check
+	for _, component := range m.Cfg.ComponentsToFind {
+		if component.RunAfter == "" {
+			continue
+		}
+		check := core.Check{Name: component.MenuText, Value: "не установлен"}
+		if _, err := os.Stat(component.RunAfter); err == nil {
+			check.OK = true
+			check.Value = component.RunAfter
+		}
+		checks = append(checks, check)
+	}
+	checks = append(checks, core.ProcessCheck(wu, "iikoFront"))
+
+	versions := m.LocalVersions(am.Cfg().AssetsCachePath)
+	distros := core.Check{Name: "дистрибутивы в " + filepath.Join(am.Cfg().AssetsCachePath, distroCacheDir), OK: len(versions) > 0, Value: "нет"}
+	if len(versions) > 0 {
+		distros.Value = strings.Join(versions, ", ")
+	}
+	return append(checks, distros), nil
+}
+
+// Upgrade запускает обычную установку: установщики iiko сами обновляют существующую версию.
+func (m *Module) Upgrade(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	return m.Install(ctx, am, wu, p)
+}
+
+// Uninstall не поддерживается: iiko удаляется штатными средствами Windows.
+func (m *Module) Uninstall(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	return core.ErrNotSupported
+}
+
+func (m *Module) Verify(am core.AssetManager, wu core.WinUtils) ([]string, error) {
+	return nil, core.ErrNotSupported
+}
+
+func (m *Module) Install(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
 	m.Cfg = &am.Cfg().IikoConfig
 
 	// 1. Сканируем FTP на предмет доступных версий
@@ -54,11 +148,11 @@ func (m *Module) Run(am core.AssetManager, wu core.WinUtils) error {
 		return fmt.Errorf("на FTP не найдено ни одной корректной версии iiko")
 	}
 
-	// 2. Показываем меню выбора дистрибутива
-	selectedComponent, err := m.showDistroMenu(discovered)
+	// 2. Выбираем дистрибутив
+	selectedComponent, err := m.selectDistro(discovered, p)
 	if err != nil {
-		if errors.Is(err, errUserChoseExit) {
-			// Если пользователь выбрал "00", это не ошибка, просто выходим в главное меню
+		if errors.Is(err, core.ErrCancelled) {
+			// Если пользователь выбрал "Назад", это не ошибка, просто выходим в главное меню
 			tui.Info("Возврат в главное меню.")
 			return nil
 		}
@@ -71,16 +165,13 @@ func (m *Module) Run(am core.AssetManager, wu core.WinUtils) error {
 	}
 	fmt.Printf("\n--- Начало установки %s ---\n", distroName)
 
-	targetDir := filepath.Join(am.Cfg().RootPath, selectedComponent.Version)
-	if selectedComponent.ID == "iikoCard" {
-		targetDir = filepath.Join(am.Cfg().RootPath, "iikoCardPOS")
-	}
-	_ = os.MkdirAll(targetDir, 0755)
+	targetDir := distroDir(am.Cfg().AssetsCachePath, selectedComponent)
+	_ = wu.MkdirAll(targetDir, 0755)
 
 	installerPath := filepath.Join(targetDir, selectedComponent.FileName)
 
 	// 3. Скачиваем основной установщик
-	_, err = am.DownloadFTPWithProgress(selectedComponent.FTPPath, installerPath)
+	_, err = am.DownloadFTPWithProgress(ctx, selectedComponent.FTPPath, installerPath)
 	if err != nil {
 		return fmt.Errorf("не удалось скачать установщик %s: %w", distroName, err)
 	}
@@ -88,14 +179,17 @@ func (m *Module) Run(am core.AssetManager, wu core.WinUtils) error {
 	// 4. Обрабатываем патчи (только для Front)
 	var patchesToInstall []IikoPatch
 	if selectedComponent.ID == "Front" {
-		patchesToInstall, err = m.handlePatches(am, selectedComponent.Version, targetDir)
+		patchesToInstall, err = m.handlePatches(ctx, am, p, selectedComponent.Version, targetDir)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		if err != nil {
 			fmt.Printf("Предупреждение: не удалось обработать патчи: %v. Установка продолжится без них.\n", err)
 		}
 	}
 
 	// 5. Запускаем установщик
-	exitCode, err := m.runInstaller(wu, installerPath, selectedComponent.InstallArgs, am.Cfg().RootPath)
+	exitCode, err := m.runInstaller(ctx, wu, installerPath, selectedComponent.InstallArgs, am.Cfg().RootPath)
 	if err != nil {
 		return err
 	}
@@ -112,7 +206,7 @@ func (m *Module) Run(am core.AssetManager, wu core.WinUtils) error {
 			for _, patch := range patchesToInstall {
 				if patch.Downloaded {
 					fmt.Printf("\n--- Применение патча: %s ---\n", patch.Name)
-					if err := m.applyIikoPatch(installDir, patch.LocalPath, patch.Name); err != nil {
+					if err := m.applyIikoPatch(wu, installDir, patch.LocalPath, patch.Name); err != nil {
 						fmt.Printf("ОШИБКА при применении патча %s: %v\n", patch.Name, err)
 					}
 				}
@@ -126,15 +220,70 @@ func (m *Module) Run(am core.AssetManager, wu core.WinUtils) error {
 	if selectedComponent.RunAfter != "" {
 		if _, err := os.Stat(selectedComponent.RunAfter); err == nil {
 			fmt.Printf("Запуск %s...\n", selectedComponent.RunAfter)
-			exec.Command(selectedComponent.RunAfter).Start()
+			wu.StartProcess(selectedComponent.RunAfter, filepath.Dir(selectedComponent.RunAfter))
 		}
 	}
 
 	return nil
 }
 
+// Distro - дистрибутив iiko на FTP и путь, куда его скачивает установка.
+type Distro struct {
+	Name      string
+	FTPPath   string
+	LocalPath string
+}
+
+// LatestDistros возвращает для каждого компонента дистрибутив последней версии на FTP,
+// а также iikoCard. Пути те же, что при установке, поэтому скачанные заранее
+// (goMH assets prefetch) дистрибутивы установка не скачивает повторно.
+func (m *Module) LatestDistros(am core.AssetManager) ([]Distro, error) {
+	m.Cfg = &am.Cfg().IikoConfig
+	discovered, err := m.discoverVersions(am)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось просканировать FTP: %w", err)
+	}
+	var versions []string
+	for version := range discovered {
+		versions = append(versions, version)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(versions)))
+
+	var distros []Distro
+	for _, compTmpl := range m.Cfg.ComponentsToFind {
+		for _, version := range versions {
+			if comp, ok := findComponent(discovered[version], compTmpl.ID); ok {
+				distros = append(distros, m.distro(am.Cfg().AssetsCachePath, comp))
+				break
+			}
+		}
+	}
+	if m.Cfg.CardPOS.FileName != "" {
+		distros = append(distros, m.distro(am.Cfg().AssetsCachePath, m.cardPOSComponent()))
+	}
+	return distros, nil
+}
+
+func (m *Module) distro(cachePath string, comp config.IikoComponent) Distro {
+	name := "iiko " + comp.Version + " " + comp.ID
+	if comp.ID == "iikoCard" {
+		name = comp.ID
+	}
+	return Distro{Name: name, FTPPath: comp.FTPPath, LocalPath: filepath.Join(distroDir(cachePath, comp), comp.FileName)}
+}
+
 // --- Функции-помощники ---
 
+// distroDir возвращает директорию, в которую скачивается дистрибутив: <кэш>\iiko\<версия>
+// или <кэш>\iiko\iikoCardPOS. Дистрибутивы и патчи лежат в кэше, поэтому учитываются
+// в манифесте и ограничении assets_cache_max_mb и удаляются командой goMH assets gc.
+func distroDir(cachePath string, comp config.IikoComponent) string {
+	if comp.ID == "iikoCard" {
+		return filepath.Join(cachePath, distroCacheDir, "iikoCardPOS")
+	}
+	return filepath.Join(cachePath, distroCacheDir, comp.Version)
+}
+
 func (m *Module) discoverVersions(am core.AssetManager) (DiscoveredVersions, error) {
 	entries, err := am.ListFTP(m.Cfg.BaseFTPPath)
 	if err != nil {
@@ -142,11 +291,10 @@ func (m *Module) discoverVersions(am core.AssetManager) (DiscoveredVersions, err
 	}
 
 	discovered := make(DiscoveredVersions)
-	versionRegex := regexp.MustCompile(`^\d{3}$`)
 
 	for _, entry := range entries {
 		// Проверяем, что это директория
-		if entry.Type != 1 || !versionRegex.MatchString(entry.Name) {
+		if entry.Type != 1 || !versionDirPattern.MatchString(entry.Name) {
 			continue
 		}
 		version := entry.Name
@@ -180,61 +328,76 @@ func (m *Module) discoverVersions(am core.AssetManager) (DiscoveredVersions, err
 	return discovered, nil
 }
 
-func (m *Module) showDistroMenu(versions DiscoveredVersions) (config.IikoComponent, error) {
-	reader := bufio.NewReader(os.Stdin)
-	var menuOptions []config.IikoComponent
+// cardPOSComponent возвращает компонент iikoCard, который не привязан к версии iiko.
+func (m *Module) cardPOSComponent() config.IikoComponent {
+	cardPosOption := m.Cfg.CardPOS
+	cardPosOption.ID = "iikoCard"
+	cardPosOption.Version = "Card"
+	cardPosOption.FTPPath = m.Cfg.BaseFTPPath + "/" + cardPosOption.FileName
+	return cardPosOption
+}
+
+// selectDistro выбирает дистрибутив в два шага: сначала компонент (Front, BackOffice, iikoCard),
+// затем версию, в которой этот компонент есть. Для iikoCard версия не требуется.
+func (m *Module) selectDistro(versions DiscoveredVersions, p core.Prompter) (config.IikoComponent, error) {
 	var versionsSorted []string
 	for v := range versions {
 		versionsSorted = append(versionsSorted, v)
 	}
 	sort.Sort(sort.Reverse(sort.StringSlice(versionsSorted))) // Сначала новые версии
 
-	for {
-		tui.Title("\n--- Выберите дистрибутив iiko для установки ---")
-		menuOptions = nil
-
-		// Опция 0 - iikoCard
-		cardPosOption := m.Cfg.CardPOS
-		cardPosOption.ID = "iikoCard"
-		cardPosOption.Version = "Card"
-		cardPosOption.FTPPath = m.Cfg.BaseFTPPath + "/" + cardPosOption.FileName
-		menuOptions = append(menuOptions, cardPosOption)
-		fmt.Printf(" %d. %s\n", 0, cardPosOption.MenuText)
-
-		// Остальные опции
+	// Первый вариант - iikoCard, остальные - компоненты из конфигурации, найденные хотя бы в одной версии
+	cardPosOption := m.cardPOSComponent()
+	componentOptions := []core.Option{{Value: cardPosOption.ID, Label: cardPosOption.MenuText}}
+	for _, compTmpl := range m.Cfg.ComponentsToFind {
 		for _, version := range versionsSorted {
-			fmt.Printf("--- Версия iiko %s ---\n", version)
-			for _, comp := range versions[version] {
-				menuOptions = append(menuOptions, comp)
-				fmt.Printf(" %d. %s %s\n", len(menuOptions)-1, version, comp.MenuText)
+			if _, ok := findComponent(versions[version], compTmpl.ID); ok {
+				componentOptions = append(componentOptions, core.Option{Value: compTmpl.ID, Label: compTmpl.MenuText})
+				break
 			}
 		}
+	}
 
-		fmt.Println("\n 00. Назад в главное меню")
-		fmt.Print("Введите номер пункта: ")
-
-		choiceStr, _ := reader.ReadString('\n')
-		choiceStr = strings.TrimSpace(choiceStr)
-
-		if choiceStr == "00" {
-			return config.IikoComponent{}, errUserChoseExit
+	choice, err := p.Choose("component", "Выберите дистрибутив iiko для установки", componentOptions)
+	if err != nil {
+		return config.IikoComponent{}, err
+	}
+	if choice == 0 {
+		return cardPosOption, nil
+	}
+	componentID := componentOptions[choice].Value
+
+	var versionOptions []core.Option
+	var candidates []config.IikoComponent
+	for _, version := range versionsSorted {
+		if comp, ok := findComponent(versions[version], componentID); ok {
+			candidates = append(candidates, comp)
+			versionOptions = append(versionOptions, core.Option{Value: version, Label: "iiko " + version + " " + comp.MenuText})
 		}
+	}
+
+	choice, err = p.Choose("version", "Выберите версию iiko", versionOptions)
+	if err != nil {
+		return config.IikoComponent{}, err
+	}
+	return candidates[choice], nil
+}
 
-		choice, err := strconv.Atoi(choiceStr)
-		if err != nil || choice < 0 || choice >= len(menuOptions) {
-			tui.Error("Некорректный выбор. Попробуйте снова. 2 секунды...")
-			time.Sleep(2 * time.Second)
-			continue // Показываем меню заново
+// findComponent ищет компонент с указанным ID среди найденных в версии.
+func findComponent(components []config.IikoComponent, id string) (config.IikoComponent, bool) {
+	for _, comp := range components {
+		if comp.ID == id {
+			return comp, true
 		}
-		return menuOptions[choice], nil
 	}
+	return config.IikoComponent{}, false
 }
 
-func (m *Module) handlePatches(am core.AssetManager, version, targetDir string) ([]IikoPatch, error) {
+func (m *Module) handlePatches(ctx context.Context, am core.AssetManager, p core.Prompter, version, targetDir string) ([]IikoPatch, error) {
 	routeFTPPath := m.Cfg.BaseFTPPath + m.Cfg.PatchRouteFile
 	tempRouteFile := filepath.Join(os.TempDir(), "patcher_route.txt")
 
-	_, err := am.DownloadFTPWithProgress(routeFTPPath, tempRouteFile)
+	_, err := am.DownloadFTPWithProgress(ctx, routeFTPPath, tempRouteFile)
 	if err != nil {
 		return nil, fmt.Errorf("не удалось скачать файл с патчами: %w", err)
 	}
@@ -271,34 +434,31 @@ func (m *Module) handlePatches(am core.AssetManager, version, targetDir string)
 	}
 
 	// Показываем меню выбора патчей
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Println("\nНайдены следующие патчи. Выберите, какие установить:")
-	for i, p := range availablePatches {
-		fmt.Printf(" %d) %s - %s\n", i+1, p.Name, p.Description)
-	}
-	fmt.Print("Введите номера через запятую (напр., 1,3) или Enter для пропуска: ")
-	choiceStr, _ := reader.ReadString('\n')
-	choiceStr = strings.TrimSpace(choiceStr)
-	if choiceStr == "" {
-		return nil, nil
+	var patchOptions []core.Option
+	for _, patch := range availablePatches {
+		patchOptions = append(patchOptions, core.Option{Value: patch.Name, Label: patch.Name + " - " + patch.Description})
+	}
+	chosen, err := p.MultiChoose("patches", "Найдены следующие патчи. Выберите, какие установить", patchOptions)
+	if err != nil {
+		return nil, err
 	}
 
 	var selectedPatches []IikoPatch
-	for _, idxStr := range strings.Split(choiceStr, ",") {
-		idx, err := strconv.Atoi(strings.TrimSpace(idxStr))
-		if err == nil && idx >= 1 && idx <= len(availablePatches) {
-			patch := availablePatches[idx-1]
-			patch.LocalPath = filepath.Join(targetDir, filepath.Base(patch.Path))
-			patch.Downloaded = false
-			selectedPatches = append(selectedPatches, patch)
-		}
+	for _, idx := range chosen {
+		patch := availablePatches[idx]
+		patch.LocalPath = filepath.Join(targetDir, filepath.Base(patch.Path))
+		patch.Downloaded = false
+		selectedPatches = append(selectedPatches, patch)
 	}
 
 	// Скачиваем выбранные патчи
 	for i := range selectedPatches {
 		patch := &selectedPatches[i] // Берем указатель, чтобы изменять поле Downloaded
 		ftpURL := m.Cfg.BaseFTPPath + patch.Path
-		_, err := am.DownloadFTPWithProgress(ftpURL, patch.LocalPath)
+		_, err := am.DownloadFTPWithProgress(ctx, ftpURL, patch.LocalPath)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		if err != nil {
 			fmt.Printf("ОШИБКА скачивания патча %s: %v\n", patch.Name, err)
 		} else {
@@ -309,7 +469,7 @@ func (m *Module) handlePatches(am core.AssetManager, version, targetDir string)
 	return selectedPatches, nil
 }
 
-func (m *Module) runInstaller(wu core.WinUtils, installerPath, args, rootPath string) (int, error) {
+func (m *Module) runInstaller(ctx context.Context, wu core.WinUtils, installerPath, args, rootPath string) (int, error) {
 	// Создаем путь для временного лог-файла
 	logFileName := fmt.Sprintf("installer_log_%d.txt", time.Now().Unix())
 	tempLogPath := filepath.Join(os.TempDir(), logFileName)
@@ -321,10 +481,11 @@ func (m *Module) runInstaller(wu core.WinUtils, installerPath, args, rootPath st
 	fmt.Printf("\nЗапуск установщика: %s с аргументами %v\n", installerPath, finalArgs)
 	fmt.Println("... ИДЕТ УСТАНОВКА, ПОЖАЛУЙСТА, ОЖИДАЙТЕ ...")
 
-	_, err := wu.RunCommand(installerPath, finalArgs...)
+	_, err := wu.RunCommand(ctx, installerPath, finalArgs...)
 	var exitCode int
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
 			exitCode = exitErr.ExitCode()
 		} else {
 			// Если ошибка не связана с кодом завершения (например, файл не найден),
@@ -341,68 +502,140 @@ func (m *Module) runInstaller(wu core.WinUtils, installerPath, args, rootPath st
 			// Установка завершилась с ошибкой, ПЕРЕМЕЩАЕМ лог
 			finalLogPath := filepath.Join(rootPath, logFileName)
 			fmt.Printf("Установщик завершился с ошибкой. Сохраняем лог в: %s\n", finalLogPath)
-			if renameErr := os.Rename(tempLogPath, finalLogPath); renameErr != nil {
+			if renameErr := wu.Rename(tempLogPath, finalLogPath); renameErr != nil {
 				fmt.Printf("Предупреждение: не удалось переместить лог-файл: %v\n", renameErr)
 				// Если переместить не удалось, пробуем хотя бы не удалять его из временной папки
 			}
 		} else {
 			// Установка успешна, УДАЛЯЕМ временный лог
-			os.Remove(tempLogPath)
+			wu.RemoveAll(tempLogPath)
 		}
 	}
 
 	return exitCode, nil
 }
 
-func (m *Module) applyIikoPatch(installDir, patchZipPath, patchName string) error {
-	backupDir := filepath.Join(filepath.Dir(patchZipPath), fmt.Sprintf("backup_%s_%d", patchName, time.Now().Unix()))
-	if err := os.MkdirAll(backupDir, 0755); err != nil {
+// patchStagingDir - временная директория внутри installDir, куда сначала распаковывается
+// патч: оттуда файлы переносятся переименованием в пределах одного диска.
+// По той же причине бэкапы заменяемых файлов хранятся в patchBackupDir внутри installDir,
+// пока патч применяется.
+const (
+	patchStagingDir = ".gomh-patch"
+	patchBackupDir  = ".gomh-backup"
+)
+
+// applyIikoPatch применяет патч так, чтобы установка не осталась пропатченной наполовину:
+// архив целиком распаковывается в patchStagingDir, затем каждый файл установки переносится
+// в бэкап и заменяется файлом патча. Если заменить файл не удалось, уже замененные файлы
+// возвращаются из бэкапа. Бэкап удаляется, когда патч применен или откат удался; остается
+// он, только если вернуть файлы не получилось. Все изменения на диске идут через wu
+// (в dry-run - в план).
+func (m *Module) applyIikoPatch(wu core.WinUtils, installDir, patchZipPath, patchName string) error {
+	backupRoot := filepath.Join(installDir, patchBackupDir)
+	backupDir := filepath.Join(backupRoot, fmt.Sprintf("%s_%d", patchName, time.Now().Unix()))
+	if err := wu.MkdirAll(backupDir, 0755); err != nil {
 		return fmt.Errorf("не удалось создать папку для бэкапа: %w", err)
 	}
-	fmt.Printf("Создана папка для бэкапа: %s\n", backupDir)
+	removeBackup := func() {
+		wu.RemoveAll(backupDir)
+		// Общая папка бэкапов удаляется, если в ней не осталось бэкапов других патчей
+		if entries, _ := os.ReadDir(backupRoot); len(entries) == 0 {
+			wu.RemoveAll(backupRoot)
+		}
+	}
 
-	r, err := zip.OpenReader(patchZipPath)
+	stagingDir := filepath.Join(installDir, patchStagingDir)
+	defer wu.RemoveAll(stagingDir)
+	files, err := extractPatch(wu, patchZipPath, stagingDir)
 	if err != nil {
-		return err
+		removeBackup()
+		return fmt.Errorf("не удалось распаковать патч: %w", err)
 	}
-	defer r.Close()
 
-	for _, f := range r.File {
-		destPath := filepath.Join(installDir, f.Name)
-		if f.FileInfo().IsDir() {
-			os.MkdirAll(destPath, f.Mode())
-			continue
+	// replaced - замененный файл и его бэкап (пустой, если файла в установке не было)
+	type replaced struct{ destPath, backupPath string }
+	var done []replaced
+	// rollback возвращает замененные файлы из бэкапа. Если какой-то файл вернуть не удалось,
+	// бэкап остается на диске, и ошибка сообщает, где его искать.
+	rollback := func(cause error) error {
+		var failed []string
+		for i := len(done) - 1; i >= 0; i-- {
+			wu.RemoveAll(done[i].destPath)
+			if done[i].backupPath != "" {
+				if err := wu.Rename(done[i].backupPath, done[i].destPath); err != nil {
+					failed = append(failed, done[i].destPath)
+				}
+			}
 		}
+		if len(failed) > 0 {
+			return fmt.Errorf("%w; не удалось вернуть файлы %s, их копии остались в %s",
+				cause, strings.Join(failed, ", "), backupDir)
+		}
+		removeBackup()
+		return cause
+	}
 
+	for _, name := range files {
+		step := replaced{destPath: filepath.Join(installDir, name)}
 		// Бэкап существующего файла
-		if _, err := os.Stat(destPath); err == nil {
-			backupPath := filepath.Join(backupDir, f.Name)
-			os.MkdirAll(filepath.Dir(backupPath), 0755)
-			if err := os.Rename(destPath, backupPath); err != nil {
-				fmt.Printf("Предупреждение: не удалось сделать бэкап файла %s: %v\n", destPath, err)
+		if _, err := os.Stat(step.destPath); err == nil {
+			step.backupPath = filepath.Join(backupDir, name)
+			wu.MkdirAll(filepath.Dir(step.backupPath), 0755)
+			if err := wu.Rename(step.destPath, step.backupPath); err != nil {
+				return rollback(fmt.Errorf("не удалось сделать бэкап файла %s: %w", step.destPath, err))
 			}
 		}
 
-		// Распаковка нового файла
-		os.MkdirAll(filepath.Dir(destPath), 0755)
-		destFile, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
+		err := wu.MkdirAll(filepath.Dir(step.destPath), 0755)
+		if err == nil {
+			err = wu.Rename(filepath.Join(stagingDir, name), step.destPath)
+		}
+		done = append(done, step)
 		if err != nil {
-			return err
+			return rollback(fmt.Errorf("не удалось заменить файл %s, патч отменен: %w", step.destPath, err))
+		}
+	}
+	removeBackup()
+	fmt.Printf("Патч '%s' успешно применен.\n", patchName)
+	return nil
+}
+
+// extractPatch распаковывает файлы архива патча в stagingDir и возвращает их пути
+// относительно stagingDir.
+func extractPatch(wu core.WinUtils, patchZipPath, stagingDir string) ([]string, error) {
+	r, err := zip.OpenReader(patchZipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	if err := wu.RemoveAll(stagingDir); err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name := filepath.FromSlash(f.Name)
+		destPath := filepath.Join(stagingDir, name)
+		if !strings.HasPrefix(destPath, filepath.Clean(stagingDir)+string(os.PathSeparator)) {
+			return nil, fmt.Errorf("небезопасный путь в архиве: %s", f.Name)
 		}
 
 		srcFile, err := f.Open()
 		if err != nil {
-			destFile.Close()
-			return err
+			return nil, err
 		}
-
-		_, err = io.Copy(destFile, srcFile)
-		destFile.Close()
+		data, err := io.ReadAll(srcFile)
 		srcFile.Close()
 		if err != nil {
-			return err
+			return nil, err
 		}
+		if err := wu.WriteFile(destPath, data, f.Mode()); err != nil {
+			return nil, err
+		}
+		files = append(files, name)
 	}
-	fmt.Printf("Патч '%s' успешно применен.\n", patchName)
-	return nil
+	return files, nil
 }
@@ -0,0 +1,251 @@
+// Package packages реализует универсальный модуль установки стороннего ПО
+// по описанию из секции "packages" конфигурации (драйверы ККТ, УТМ, крипто-провайдеры и т.п.).
+package packages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goMH/config"
+	"goMH/core"
+	"goMH/tui"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// rebootRequiredCode - код завершения установщиков Windows "успешно, требуется перезагрузка".
+const rebootRequiredCode = 3010
+
+type Module struct {
+	Def config.PackageDef
+}
+
+// New создает модуль по описанию пакета из конфигурации.
+func New(def config.PackageDef) *Module {
+	return &Module{Def: def}
+}
+
+func (m *Module) ID() string {
+	return m.Def.ID
+}
+
+func (m *Module) MenuText() string {
+	if m.Def.MenuText == "" {
+		return fmt.Sprintf("Установить %s", m.Def.ID)
+	}
+	return m.Def.MenuText
+}
+
+func (m *Module) Run(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	return core.RunLifecycle(ctx, m, am, wu, p)
+}
+
+// Detect проверяет правило обнаружения пакета. Без правила состояние неизвестно,
+// и запуск модуля всегда выполняет установку.
+func (m *Module) Detect(am core.AssetManager, wu core.WinUtils) (core.Status, error) {
+	rule := m.Def.Detect
+	if rule == nil || (rule.Service == "" && rule.File == "" && rule.RegistryUninstall == "") {
+		return core.Status{State: core.StateUnknown}, nil
+	}
+
+	if rule.RegistryUninstall != "" {
+		program, err := wu.FindInstalledProgram(rule.RegistryUninstall)
+		if err != nil {
+			return core.Status{}, err
+		}
+		if program != nil {
+			return core.Status{State: core.StateInstalled, Version: program.Version, Details: program.Name}, nil
+		}
+	}
+	if rule.Service != "" {
+		exists, err := wu.ServiceExists(rule.Service)
+		if err != nil {
+			return core.Status{}, fmt.Errorf("не удалось проверить наличие службы '%s': %w", rule.Service, err)
+		}
+		if exists {
+			return core.Status{State: core.StateInstalled, Details: "служба " + rule.Service}, nil
+		}
+	}
+	if rule.File != "" {
+		path := resolvePath(am, rule.File)
+		if _, err := os.Stat(path); err == nil {
+			return core.Status{State: core.StateInstalled, Details: path}, nil
+		}
+	}
+	return core.Status{State: core.StateNotInstalled}, nil
+}
+
+func (m *Module) Install(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	def := m.Def
+	tui.Title(fmt.Sprintf("\n--- Начало установки: %s ---", m.MenuText()))
+
+	if def.AssetID == "" {
+		return fmt.Errorf("для пакета '%s' не указан asset_id", def.ID)
+	}
+
+	tui.Info("Получение установщика через AssetManager...")
+	// Используем assetmgr для скачивания файла в кэш, он сам выберет метод (HTTP/FTP)
+	installerPath, err := am.DownloadToCache(ctx, def.AssetID)
+	if err != nil {
+		return fmt.Errorf("не удалось получить ассет '%s': %w", def.AssetID, err)
+	}
+
+	tui.Info("Запуск установки...")
+	tui.InfoF("Аргументы: %s", def.InstallArgs)
+	args := strings.Fields(def.InstallArgs)
+
+	output, err := wu.RunCommand(ctx, installerPath, args...)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || !def.IsSuccessCode(exitErr.ExitCode()) {
+			return fmt.Errorf("ошибка при установке %s: %w. Вывод: %s", def.ID, err, output)
+		}
+		if exitErr.ExitCode() == rebootRequiredCode {
+			tui.Warn("Установщик сообщил, что для завершения установки требуется перезагрузка.")
+		} else {
+			tui.InfoF("Установщик завершился с кодом %d (считается успешным).", exitErr.ExitCode())
+		}
+	}
+
+	for _, action := range def.PostInstall {
+		if err := runPostAction(ctx, am, wu, action); err != nil {
+			return fmt.Errorf("пакет %s установлен, но действие '%s' не выполнено: %w", def.ID, action.Type, err)
+		}
+	}
+
+	tui.SuccessF("Установка %s завершена.", def.ID)
+	return nil
+}
+
+// Upgrade повторно запускает установщик: он сам обновляет существующую установку.
+func (m *Module) Upgrade(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	return m.Install(ctx, am, wu, p)
+}
+
+// Uninstall запускает команду удаления из реестра. Доступно только для пакетов
+// с правилом обнаружения registry_uninstall.
+func (m *Module) Uninstall(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	if m.Def.Detect == nil || m.Def.Detect.RegistryUninstall == "" {
+		return core.ErrNotSupported
+	}
+	program, err := wu.FindInstalledProgram(m.Def.Detect.RegistryUninstall)
+	if err != nil {
+		return err
+	}
+	if program == nil || program.UninstallString == "" {
+		return fmt.Errorf("в реестре не найдена команда удаления для '%s'", m.Def.Detect.RegistryUninstall)
+	}
+
+	confirmed, err := p.Confirm("confirm", fmt.Sprintf("Удалить %s?", program.Name), false)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		tui.Info("Удаление отменено.")
+		return nil
+	}
+
+	tui.Title(fmt.Sprintf("\n--- Удаление %s ---", program.Name))
+	// Строка удаления из реестра - готовая командная строка с кавычками, ее разбирает cmd
+	output, err := wu.RunCommand(ctx, "cmd", "/c", program.UninstallString)
+	if err != nil {
+		return fmt.Errorf("удаление завершилось с ошибкой: %w. Вывод: %s", err, output)
+	}
+	tui.SuccessF("%s удален.", program.Name)
+	return nil
+}
+
+// Verify проверяет службы из правила обнаружения и действий после установки.
+func (m *Module) Verify(am core.AssetManager, wu core.WinUtils) ([]string, error) {
+	checks, err := m.Inspect(am, wu)
+	if err != nil {
+		return nil, err
+	}
+	if len(checks) == 0 {
+		return nil, core.ErrNotSupported
+	}
+	var problems []string
+	for _, check := range checks {
+		if !check.OK {
+			problems = append(problems, fmt.Sprintf("%s: %s", check.Name, check.Value))
+		}
+	}
+	return problems, nil
+}
+
+// Inspect сообщает состояние служб и файлов, указанных в описании пакета.
+func (m *Module) Inspect(am core.AssetManager, wu core.WinUtils) ([]core.Check, error) {
+	var checks []core.Check
+	seen := make(map[string]bool)
+	addService := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			checks = append(checks, core.ServiceCheck(wu, name))
+		}
+	}
+
+	if rule := m.Def.Detect; rule != nil {
+		addService(rule.Service)
+		if rule.File != "" {
+			path := resolvePath(am, rule.File)
+			check := core.Check{Name: "файл " + path, Value: "не найден"}
+			if _, err := os.Stat(path); err == nil {
+				check.OK, check.Value = true, "есть"
+			}
+			checks = append(checks, check)
+		}
+	}
+	for _, action := range m.Def.PostInstall {
+		if action.Type == config.PostStartService {
+			addService(action.Service)
+		}
+	}
+	return checks, nil
+}
+
+func runPostAction(ctx context.Context, am core.AssetManager, wu core.WinUtils, action config.PostAction) error {
+	switch action.Type {
+	case config.PostStartService:
+		if action.Service == "" {
+			return errors.New("не указано имя службы")
+		}
+		tui.InfoF("Запуск службы %s...", action.Service)
+		if _, err := wu.RunCommand(ctx, "sc.exe", "start", action.Service); err != nil {
+			// sc.exe возвращает 1056, если служба уже запущена; вывод команды входит в текст ошибки
+			if !strings.Contains(err.Error(), "1056") {
+				return err
+			}
+		}
+	case config.PostDefenderExclusion:
+		if action.Path == "" {
+			return errors.New("не указан путь")
+		}
+		path := resolvePath(am, action.Path)
+		tui.InfoF("Добавление исключения Защитника Windows: %s", path)
+		return wu.AddDefenderExclusion(path)
+	case config.PostScheduledTask:
+		if action.TaskName == "" || action.Path == "" {
+			return errors.New("не указаны task_name или path")
+		}
+		path := resolvePath(am, action.Path)
+		workingDir := filepath.Dir(path)
+		if action.WorkingDir != "" {
+			workingDir = resolvePath(am, action.WorkingDir)
+		}
+		tui.InfoF("Создание задачи планировщика '%s'...", action.TaskName)
+		return wu.CreateScheduledTask(action.TaskName, path, workingDir)
+	default:
+		return fmt.Errorf("неизвестный тип действия '%s'", action.Type)
+	}
+	return nil
+}
+
+// resolvePath переводит относительный путь из конфигурации в путь от RootPath.
+func resolvePath(am core.AssetManager, path string) string {
+	if filepath.IsAbs(path) || strings.Contains(path, ":") {
+		return path
+	}
+	return filepath.Join(am.Cfg().RootPath, path)
+}
@@ -0,0 +1,114 @@
+package packages
+
+import (
+	"context"
+	"goMH/config"
+	"goMH/core"
+	"goMH/tui"
+	"strings"
+	"testing"
+)
+
+// fakeWinUtils записывает выполненные команды. Методы, которые тест не переопределил,
+// паникуют (встроенный интерфейс равен nil).
+type fakeWinUtils struct {
+	core.WinUtils
+	program  *core.InstalledProgram
+	commands []string
+}
+
+func (f *fakeWinUtils) RunCommand(ctx context.Context, name string, args ...string) (string, error) {
+	f.commands = append(f.commands, strings.Join(append([]string{name}, args...), " "))
+	return "", nil
+}
+
+func (f *fakeWinUtils) FindInstalledProgram(namePrefix string) (*core.InstalledProgram, error) {
+	return f.program, nil
+}
+
+func (f *fakeWinUtils) ServiceExists(serviceName string) (bool, error) { return true, nil }
+
+func (f *fakeWinUtils) ServiceRunning(serviceName string) (bool, error) { return true, nil }
+
+// fakeAssetManager отдает установщик "из кэша" без скачивания.
+type fakeAssetManager struct {
+	core.AssetManager
+	cfg        *config.Config
+	downloaded []string
+}
+
+func (f *fakeAssetManager) Cfg() *config.Config { return f.cfg }
+
+func (f *fakeAssetManager) DownloadToCache(ctx context.Context, assetName string) (string, error) {
+	f.downloaded = append(f.downloaded, assetName)
+	return `C:\MH\_assets\` + assetName + `\setup.exe`, nil
+}
+
+func testPackage() *Module {
+	return New(config.PackageDef{
+		ID:          "UTM",
+		AssetID:     "UTM_Installer",
+		InstallArgs: "/S /quiet",
+		Detect:      &config.DetectRule{RegistryUninstall: "УТМ"},
+		PostInstall: []config.PostAction{{Type: config.PostStartService, Service: "Transport"}},
+	})
+}
+
+func TestRunInstallsMissingPackage(t *testing.T) {
+	wu := &fakeWinUtils{}
+	am := &fakeAssetManager{cfg: &config.Config{RootPath: `C:\MH`}}
+	p := tui.NewScriptedPrompter(core.Params{})
+
+	if err := testPackage().Run(context.Background(), am, wu, p); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(am.downloaded) != 1 || am.downloaded[0] != "UTM_Installer" {
+		t.Errorf("скачаны ресурсы %v, ожидался UTM_Installer", am.downloaded)
+	}
+	want := []string{`C:\MH\_assets\UTM_Installer\setup.exe /S /quiet`, "sc.exe start Transport"}
+	if strings.Join(wu.commands, "\n") != strings.Join(want, "\n") {
+		t.Errorf("команды:\n%s\nожидались:\n%s", strings.Join(wu.commands, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRunUninstallsByScriptedAnswers(t *testing.T) {
+	tests := []struct {
+		confirm string
+		want    []string
+	}{
+		{"yes", []string{`cmd /c "C:\Program Files\UTM\uninstall.exe" /S`}},
+		{"no", nil},
+	}
+	for _, tt := range tests {
+		wu := &fakeWinUtils{program: &core.InstalledProgram{
+			Name:            "УТМ 4.2",
+			Version:         "4.2.0",
+			UninstallString: `"C:\Program Files\UTM\uninstall.exe" /S`,
+		}}
+		am := &fakeAssetManager{cfg: &config.Config{RootPath: `C:\MH`}}
+		p := tui.NewScriptedPrompter(core.Params{"action": "uninstall", "confirm": tt.confirm})
+
+		if err := testPackage().Run(context.Background(), am, wu, p); err != nil {
+			t.Fatalf("confirm=%s: Run: %v", tt.confirm, err)
+		}
+		if len(am.downloaded) != 0 {
+			t.Errorf("confirm=%s: при удалении ничего не должно скачиваться, скачано %v", tt.confirm, am.downloaded)
+		}
+		if strings.Join(wu.commands, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("confirm=%s: команды %q, ожидались %q", tt.confirm, wu.commands, tt.want)
+		}
+	}
+}
+
+func TestRunFailsWithoutScriptedAction(t *testing.T) {
+	wu := &fakeWinUtils{program: &core.InstalledProgram{Name: "УТМ 4.2", UninstallString: "uninstall.exe"}}
+	am := &fakeAssetManager{cfg: &config.Config{RootPath: `C:\MH`}}
+	p := tui.NewScriptedPrompter(core.Params{})
+
+	if err := testPackage().Run(context.Background(), am, wu, p); err == nil {
+		t.Fatal("Run без ответа на вопрос action должен вернуть ошибку")
+	}
+	if len(wu.commands) != 0 {
+		t.Errorf("без ответа не должно выполняться команд, выполнено %q", wu.commands)
+	}
+}
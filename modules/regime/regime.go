@@ -1,10 +1,10 @@
 package regime
 
 import (
+	"context"
 	"fmt"
 	"goMH/core"
 	"goMH/tui"
-	"os"
 	"path/filepath"
 	"time"
 )
@@ -19,29 +19,95 @@ func (m *Module) MenuText() string {
 	return "Regime (Локальный модуль ЧестныйЗнак)"
 }
 
-func (m *Module) Run(am core.AssetManager, wu core.WinUtils) error {
+const serviceName = "regime"
+
+// Run - совместимая обертка: модуль всегда устанавливает или обновляет Regime без дополнительных вопросов.
+func (m *Module) Run(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	status, err := m.Detect(am, wu)
+	if err != nil {
+		return err
+	}
+	if status.State == core.StateInstalled {
+		return m.Upgrade(ctx, am, wu, p)
+	}
+	return m.Install(ctx, am, wu, p)
+}
+
+// Detect проверяет наличие службы "regime".
+func (m *Module) Detect(am core.AssetManager, wu core.WinUtils) (core.Status, error) {
+	exists, err := wu.ServiceExists(serviceName)
+	if err != nil {
+		return core.Status{}, fmt.Errorf("не удалось проверить наличие службы '%s': %w", serviceName, err)
+	}
+	if !exists {
+		return core.Status{State: core.StateNotInstalled}, nil
+	}
+	return core.Status{State: core.StateInstalled}, nil
+}
+
+func (m *Module) Install(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	tui.Info("Новая установка 'regime'.")
+	return m.runMsiexec(ctx, am, wu, false)
+}
+
+// Upgrade переустанавливает Regime с сохранением данных (REINSTALL_FLAG=1).
+func (m *Module) Upgrade(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	tui.Warn("Обнаружена существующая служба 'regime'. Будет выполнена переустановка с сохранением данных.")
+	return m.runMsiexec(ctx, am, wu, true)
+}
+
+// Uninstall удаляет Regime с помощью того же MSI-пакета.
+func (m *Module) Uninstall(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	tui.Title("\n--- Удаление Regime ---")
+	msiPath, err := am.DownloadToCache(ctx, "Regime_Installer")
+	if err != nil {
+		return fmt.Errorf("не удалось получить ресурс 'Regime_Installer': %w", err)
+	}
+	logPath := msiLogPath(am, wu, "regime_uninstall")
+	output, err := wu.RunCommand(ctx, "msiexec.exe", "/x", msiPath, "/qn", "/norestart", "/L*v", logPath)
+	if err != nil {
+		return fmt.Errorf("удаление завершилось с ошибкой. Лог: %s. Вывод: %s. Ошибка: %w", logPath, output, err)
+	}
+	tui.SuccessF("Regime удален. Подробный лог сохранен в %s", logPath)
+	return nil
+}
+
+// Verify проверяет, что служба "regime" зарегистрирована.
+func (m *Module) Verify(am core.AssetManager, wu core.WinUtils) ([]string, error) {
+	status, err := m.Detect(am, wu)
+	if err != nil {
+		return nil, err
+	}
+	if status.State != core.StateInstalled {
+		return []string{fmt.Sprintf("Служба '%s' не найдена.", serviceName)}, nil
+	}
+	return nil, nil
+}
+
+// Inspect сообщает состояние службы "regime".
+func (m *Module) Inspect(am core.AssetManager, wu core.WinUtils) ([]core.Check, error) {
+	return []core.Check{core.ServiceCheck(wu, serviceName)}, nil
+}
+
+func (m *Module) runMsiexec(ctx context.Context, am core.AssetManager, wu core.WinUtils, isReinstall bool) error {
 	tui.Title("\n--- Запуск установки/обновления Regime ---")
 
 	// 1. Получаем ресурс (MSI-установщик) через assetmgr
 	tui.Info("-> Этап 1: Получение установщика...")
-	msiPath, err := am.DownloadToCache("Regime_Installer")
+	msiPath, err := am.DownloadToCache(ctx, "Regime_Installer")
 	if err != nil {
 		return fmt.Errorf("не удалось получить ресурс 'Regime_Installer': %w", err)
 	}
 
-	// 2. Проверяем, установлена ли служба "regime"
-	tui.Info("-> Этап 2: Проверка существующей установки...")
-	const serviceName = "regime"
-	isReinstall, err := wu.ServiceExists(serviceName)
-	if err != nil {
-		// Если сама проверка не удалась, это критическая ошибка.
-		return fmt.Errorf("не удалось проверить наличие службы '%s': %w", serviceName, err)
+	// 2. Формируем аргументы для msiexec
+	admin := am.Cfg().RegimeConfig
+	if err := am.Cfg().SecretError(admin.AdminPassword); err != nil {
+		return fmt.Errorf("пароль администратора Regime (regime_config.admin_password): %w", err)
 	}
-
-	// 3. Формируем аргументы для msiexec
-	logDir := filepath.Join(am.Cfg().RootPath, "logs")
-	_ = os.MkdirAll(logDir, 0755)
-	logPath := filepath.Join(logDir, fmt.Sprintf("regime_install_%d.log", time.Now().Unix()))
+	if admin.AdminUser == "" || admin.AdminPassword == "" {
+		return fmt.Errorf("не задана учетная запись администратора Regime (regime_config.admin_user, regime_config.admin_password)")
+	}
+	logPath := msiLogPath(am, wu, "regime_install")
 
 	// Базовый набор аргументов
 	args := []string{
@@ -49,24 +115,21 @@ func (m *Module) Run(am core.AssetManager, wu core.WinUtils) error {
 		"/qn", // Тихий режим без интерфейса
 		"/norestart",
 		"/L*v", logPath,
-		"ADMINUSER=MH",
-		"ADMINPASSWORD=mhrcadmin994525",
+		"ADMINUSER=" + admin.AdminUser,
+		"ADMINPASSWORD=" + admin.AdminPassword,
 	}
 
 	// Условное добавление флага переустановки
 	if isReinstall {
-		tui.Warn("Обнаружена существующая служба 'regime'. Будет выполнена переустановка с сохранением данных.")
 		args = append(args, "REINSTALL_FLAG=1")
-	} else {
-		tui.Info("Новая установка 'regime'.")
 	}
 
-	// 4. Запуск установки с помощью msiexec
-	tui.InfoF("-> Этап 3: Запуск установки %s...", filepath.Base(msiPath))
+	// 3. Запуск установки с помощью msiexec
+	tui.InfoF("-> Этап 2: Запуск установки %s...", filepath.Base(msiPath))
 	tui.Info("Установка будет выполнена в тихом режиме. Это может занять несколько минут...")
 
 	// Передаем слайс аргументов в RunCommand
-	output, err := wu.RunCommand("msiexec.exe", args...)
+	output, err := wu.RunCommand(ctx, "msiexec.exe", args...)
 	if err != nil {
 		return fmt.Errorf("установщик msiexec завершился с ошибкой. Лог: %s. Вывод: %s. Ошибка: %w", logPath, output, err)
 	}
@@ -74,3 +137,10 @@ func (m *Module) Run(am core.AssetManager, wu core.WinUtils) error {
 	tui.SuccessF("Установка успешно завершена. Подробный лог сохранен в %s", logPath)
 	return nil
 }
+
+// msiLogPath возвращает путь к новому логу msiexec в RootPath/logs.
+func msiLogPath(am core.AssetManager, wu core.WinUtils, prefix string) string {
+	logDir := filepath.Join(am.Cfg().RootPath, "logs")
+	_ = wu.MkdirAll(logDir, 0755)
+	return filepath.Join(logDir, fmt.Sprintf("%s_%d.log", prefix, time.Now().Unix()))
+}
@@ -1,8 +1,8 @@
 package remoteaccess
 
 import (
-	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"goMH/core"
@@ -29,77 +29,134 @@ type remoteComponent struct {
 	Name        string
 	ServiceName string
 	IsInstalled bool
-	InstallFunc func(am core.AssetManager, wu core.WinUtils) error
+	InstallFunc func(ctx context.Context, am core.AssetManager, wu core.WinUtils) error
 }
 
-// Главная функция Run теперь управляет подменю
-func (m *Module) Run(am core.AssetManager, wu core.WinUtils) error {
-	// Инициализируем компоненты
-	components := []*remoteComponent{
-		{ID: "1", Name: "TeamViewer", ServiceName: "TeamViewer", InstallFunc: m.installTeamViewer},
-		{ID: "2", Name: "LiteManager", ServiceName: "ROMService", InstallFunc: m.installLiteManager},
-		{ID: "3", Name: "Getad Agent", ServiceName: "MH_Getad", InstallFunc: m.installGetad},
+// components возвращает список компонентов модуля
+func (m *Module) components() []*remoteComponent {
+	return []*remoteComponent{
+		{ID: "TeamViewer", Name: "TeamViewer", ServiceName: "TeamViewer", InstallFunc: m.installTeamViewer},
+		{ID: "LiteManager", Name: "LiteManager", ServiceName: "ROMService", InstallFunc: m.installLiteManager},
+		{ID: "Getad", Name: "Getad Agent", ServiceName: "MH_Getad", InstallFunc: m.installGetad},
 	}
+}
+
+// Run - совместимая обертка: подменю само показывает состояние каждого компонента.
+func (m *Module) Run(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	return m.Install(ctx, am, wu, p)
+}
+
+// Detect считает модуль установленным, если установлен хотя бы один компонент.
+func (m *Module) Detect(am core.AssetManager, wu core.WinUtils) (core.Status, error) {
+	components := m.components()
+	var installed []string
+	for _, c := range components {
+		exists, err := wu.ServiceExists(c.ServiceName)
+		if err != nil {
+			return core.Status{}, fmt.Errorf("не удалось проверить статус службы %s: %w", c.ServiceName, err)
+		}
+		if exists {
+			installed = append(installed, c.Name)
+		}
+	}
+	if len(installed) == 0 {
+		return core.Status{State: core.StateNotInstalled}, nil
+	}
+	return core.Status{State: core.StateInstalled, Details: strings.Join(installed, ", ")}, nil
+}
 
-	reader := bufio.NewReader(os.Stdin)
+// Upgrade не поддерживается: компоненты переустанавливаются только после удаления средствами Windows.
+func (m *Module) Upgrade(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	return core.ErrNotSupported
+}
+
+func (m *Module) Uninstall(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	return core.ErrNotSupported
+}
+
+// Verify проверяет, что у установленного Getad на месте исполняемый файл службы.
+func (m *Module) Verify(am core.AssetManager, wu core.WinUtils) ([]string, error) {
+	var problems []string
+	exists, err := wu.ServiceExists("MH_Getad")
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		installDir := filepath.Join(am.Cfg().RootPath, am.Cfg().AssetCatalog["Getad_Agent"].Destination)
+		if _, err := os.Stat(filepath.Join(installDir, "getad-service.exe")); err != nil {
+			problems = append(problems, fmt.Sprintf("Служба MH_Getad есть, но не найден %s.", filepath.Join(installDir, "getad-service.exe")))
+		}
+	}
+	return problems, nil
+}
+
+// Inspect сообщает состояние служб всех компонентов удаленного доступа.
+func (m *Module) Inspect(am core.AssetManager, wu core.WinUtils) ([]core.Check, error) {
+	var checks []core.Check
+	for _, c := range m.components() {
+		checks = append(checks, core.ServiceCheck(wu, c.ServiceName))
+	}
+	return checks, nil
+}
+
+// Install показывает подменю выбора компонентов для установки.
+func (m *Module) Install(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	components := m.components()
 
 	// Основной цикл подменю
 	for {
-		tui.Title("\n--- Меню установки средств удаленного доступа ---")
 		// Перед показом меню обновляем статусы
 		m.checkStatuses(wu, components)
 
-		// Отображаем меню
+		// Формируем варианты со статусами
+		var options []core.Option
 		for _, c := range components {
 			status := tui.ColorRed + "[не установлено]" + tui.ColorReset
 			if c.IsInstalled {
 				status = tui.ColorGreen + "[установлено]" + tui.ColorReset
 			}
-			fmt.Printf(" %s. Установить %s %s\n", c.ID, c.Name, status)
+			options = append(options, core.Option{Value: c.ID, Label: fmt.Sprintf("Установить %s %s", c.Name, status)})
 		}
-		fmt.Println("\n 0. Назад в главное меню")
-		fmt.Print("Выберите пункт для установки или возврата: ")
 
 		// Читаем выбор пользователя
-		choiceStr, _ := reader.ReadString('\n')
-		choiceStr = strings.TrimSpace(choiceStr)
-
-		if choiceStr == "0" {
-			return nil // Выход из подменю
+		chosen, err := p.MultiChoose("components", "Меню установки средств удаленного доступа", options)
+		if err != nil {
+			return err
 		}
-
-		// Находим выбранный компонент
-		var chosenComponent *remoteComponent
-		for _, c := range components {
-			if c.ID == choiceStr {
-				chosenComponent = c
-				break
-			}
+		if len(chosen) == 0 {
+			return nil // Выход из подменю
 		}
 
-		// Если выбор корректен, запускаем установку
-		if chosenComponent != nil {
+		var failed []string
+		for _, idx := range chosen {
+			chosenComponent := components[idx]
 			if chosenComponent.IsInstalled {
 				tui.Warn(fmt.Sprintf("\n%s уже установлен. Для переустановки сначала удалите его стандартными средствами Windows.", chosenComponent.Name))
-				fmt.Println("Нажмите Enter для продолжения...")
-				_, _ = reader.ReadString('\n')
 				continue
 			}
 
 			// Запускаем функцию установки
-			err := chosenComponent.InstallFunc(am, wu)
+			err := chosenComponent.InstallFunc(ctx, am, wu)
+			if ctx.Err() != nil {
+				// Ctrl+C: остальные выбранные компоненты не устанавливаем
+				return fmt.Errorf("установка %s прервана: %w", chosenComponent.Name, ctx.Err())
+			}
 			if err != nil {
 				tui.Error(fmt.Sprintf("\n--- ОШИБКА при установке %s ---\n%v\n---------------------------------------\n", chosenComponent.Name, err))
+				failed = append(failed, chosenComponent.Name)
 			} else {
 				tui.Success(fmt.Sprintf("\n--- %s успешно установлен. ---", chosenComponent.Name))
 			}
-			fmt.Println("\nНажмите Enter, чтобы вернуться в меню...")
-			reader.ReadString('\n')
+		}
 
-		} else {
-			tui.Error("\nНеверный выбор. Попробуйте снова.")
-			time.Sleep(2 * time.Second)
+		// Без живого пользователя меню не повторяем
+		if !p.Interactive() {
+			if len(failed) > 0 {
+				return fmt.Errorf("не удалось установить: %s", strings.Join(failed, ", "))
+			}
+			return nil
 		}
+		p.Pause("\nНажмите Enter, чтобы вернуться в меню...")
 	}
 }
 
@@ -120,14 +177,14 @@ func (m *Module) checkStatuses(wu core.WinUtils, components []*remoteComponent)
 // --- Функции установки остаются такими же, как и были ---
 
 // --- Установка TeamViewer ---
-func (m *Module) installTeamViewer(am core.AssetManager, wu core.WinUtils) error {
+func (m *Module) installTeamViewer(ctx context.Context, am core.AssetManager, wu core.WinUtils) error {
 	tui.Info("\n-> Начало установки TeamViewer...")
 	cfg := am.Cfg().TeamViewerConfig
 
 	// --- Шаг 1: Получение configId ---
 	tui.InfoF("Запрос страницы: %s", cfg.ShortURL)
 	client := &http.Client{}
-	req, err := http.NewRequest("GET", cfg.ShortURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", cfg.ShortURL, nil)
 	if err != nil {
 		return fmt.Errorf("не удалось создать HTTP-запрос: %w", err)
 	}
@@ -168,7 +225,7 @@ func (m *Module) installTeamViewer(am core.AssetManager, wu core.WinUtils) error
 	jsonBody, _ := json.Marshal(reqBody)
 
 	tui.InfoF("Запрос прямой ссылки от API: %s", cfg.ApiURL)
-	apiReq, err := http.NewRequest("POST", cfg.ApiURL, bytes.NewBuffer(jsonBody))
+	apiReq, err := http.NewRequestWithContext(ctx, "POST", cfg.ApiURL, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return fmt.Errorf("не удалось создать API-запрос: %w", err)
 	}
@@ -198,31 +255,31 @@ func (m *Module) installTeamViewer(am core.AssetManager, wu core.WinUtils) error
 	installerName := "TeamViewer_Setup.exe"
 	installerPath := filepath.Join(am.Cfg().AssetsCachePath, installerName)
 
-	if _, err := am.DownloadHTTPWithProgress(directURL, installerPath); err != nil {
+	if _, err := am.DownloadHTTPWithProgress(ctx, directURL, installerPath); err != nil {
 		return fmt.Errorf("не удалось скачать установщик: %w", err)
 	}
 
 	// --- Шаг 4: Запуск установщика ---
 	tui.Info("Запуск установщика TeamViewer в тихом режиме...")
-	_, err = wu.RunCommand(installerPath, "/S")
+	_, err = wu.RunCommand(ctx, installerPath, "/S")
 	return err
 }
 
 // --- Установка LiteManager ---
-func (m *Module) installLiteManager(am core.AssetManager, wu core.WinUtils) error {
+func (m *Module) installLiteManager(ctx context.Context, am core.AssetManager, wu core.WinUtils) error {
 	tui.Info("\n-> Начало установки LiteManager...")
-	msiPath, err := am.DownloadToCache("LiteManager_Installer")
+	msiPath, err := am.DownloadToCache(ctx, "LiteManager_Installer")
 	if err != nil {
 		return fmt.Errorf("не удалось скачать установщик LiteManager: %w", err)
 	}
 
 	tui.Info("Запуск установки LiteManager в тихом режиме...")
-	_, err = wu.RunCommand("msiexec.exe", "/i", msiPath, "/quiet", "/norestart")
+	_, err = wu.RunCommand(ctx, "msiexec.exe", "/i", msiPath, "/quiet", "/norestart")
 	return err
 }
 
 // --- Установка Getad ---
-func (m *Module) installGetad(am core.AssetManager, wu core.WinUtils) error {
+func (m *Module) installGetad(ctx context.Context, am core.AssetManager, wu core.WinUtils) error {
 	const assetName = "Getad_Agent"
 	tui.Info("\n-> Начало установки Getad Agent...")
 
@@ -231,7 +288,7 @@ func (m *Module) installGetad(am core.AssetManager, wu core.WinUtils) error {
 	installDir := filepath.Join(am.Cfg().RootPath, assetInfo.Destination)
 
 	tui.InfoF("Подготовка директории: %s", installDir)
-	if err := os.MkdirAll(installDir, 0755); err != nil {
+	if err := wu.MkdirAll(installDir, 0755); err != nil {
 		return fmt.Errorf("не удалось создать директорию установки %s: %w", installDir, err)
 	}
 
@@ -246,7 +303,7 @@ func (m *Module) installGetad(am core.AssetManager, wu core.WinUtils) error {
 
 	// --- ШАГ 2: Получаем архив в кэш ---
 	tui.Info("Скачивание архива агента...")
-	cachePath, err := am.DownloadToCache(assetName)
+	cachePath, err := am.DownloadToCache(ctx, assetName)
 	if err != nil {
 		return fmt.Errorf("не удалось скачать архив агента: %w", err)
 	}
@@ -268,16 +325,16 @@ func (m *Module) installGetad(am core.AssetManager, wu core.WinUtils) error {
 
 	// Остановка существующей службы (на случай переустановки)
 	tui.Info("Остановка существующей службы (если есть)...")
-	_, _ = wu.RunCommand(serviceExe, "stop")
+	_, _ = wu.RunCommand(ctx, serviceExe, "stop")
 	time.Sleep(1 * time.Second)
 
 	tui.Info("Установка службы...")
-	if _, err := wu.RunCommand(serviceExe, "--startup", "auto", "install"); err != nil {
+	if _, err := wu.RunCommand(ctx, serviceExe, "--startup", "auto", "install"); err != nil {
 		return fmt.Errorf("не удалось установить службу: %w", err)
 	}
 
 	tui.Info("Запуск службы...")
-	if _, err := wu.RunCommand(serviceExe, "start"); err != nil {
+	if _, err := wu.RunCommand(ctx, serviceExe, "start"); err != nil {
 		return fmt.Errorf("не удалось запустить службу: %w", err)
 	}
 
@@ -2,7 +2,6 @@ package serviceutils
 
 import (
 	"archive/zip"
-	"bufio"
 	"compress/gzip"
 	"context"
 	"errors"
@@ -13,13 +12,11 @@ import (
 	"io"
 	"io/fs"
 	"os"
-	"os/signal"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/mholt/archives"
@@ -43,35 +40,43 @@ func (m *Module) MenuText() string {
 }
 
 // Run управляет подменю утилит
-func (m *Module) Run(am core.AssetManager, wu core.WinUtils) error {
-	reader := bufio.NewReader(os.Stdin)
+func (m *Module) Run(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	actions := []core.Option{
+		{Value: "clean-temp", Label: "Очистка временных файлов"},
+		{Value: "collect-logs", Label: "Сборщик логов в архив"},
+		{Value: "tail", Label: "Просмотр лога в реальном времени (tail -f)"},
+	}
 
 	for {
-		tui.Title("\n--- Меню утилит обслуживания ---")
-		fmt.Println(" 1. Очистка временных файлов")
-		fmt.Println(" 2. Сборщик логов в архив")
-		fmt.Println(" 3. Просмотр лога в реальном времени (tail -f)")
-		fmt.Println("\n 0. Назад в главное меню")
-		fmt.Print("Выберите пункт: ")
-
-		choiceStr, _ := reader.ReadString('\n')
-		choiceStr = strings.TrimSpace(choiceStr)
-
-		var err error
-		switch choiceStr {
-		case "1":
-			err = m.cleanTempFiles(am)
-		case "2":
-			err = m.collectLogs(am)
-		case "3":
-			err = m.viewLog(am)
-		case "0":
+		choice, err := p.Choose("action", "Меню утилит обслуживания", actions)
+		if errors.Is(err, core.ErrCancelled) {
 			tui.Info("Возврат в главное меню.")
 			return nil
-		default:
-			tui.Error("Неверный выбор. Попробуйте снова.")
-			time.Sleep(2 * time.Second)
-			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		switch actions[choice].Value {
+		case "clean-temp":
+			err = m.cleanTempFiles(am, wu)
+		case "collect-logs":
+			err = m.collectLogs(am, p)
+		case "tail":
+			err = m.viewLog(ctx, am, p)
+		}
+
+		// Ctrl+C прерывает и действие, и меню утилит: возвращаемся в главное меню
+		if ctx.Err() != nil {
+			if actions[choice].Value == "tail" {
+				return nil
+			}
+			return ctx.Err()
+		}
+
+		// Без живого пользователя выполняем одно действие и выходим
+		if !p.Interactive() {
+			return err
 		}
 
 		if err != nil {
@@ -80,13 +85,12 @@ func (m *Module) Run(am core.AssetManager, wu core.WinUtils) error {
 			tui.Success("\n--- Операция завершена успешно. ---")
 		}
 
-		fmt.Println("\nНажмите Enter, чтобы вернуться в меню утилит...")
-		reader.ReadString('\n')
+		p.Pause("\nНажмите Enter, чтобы вернуться в меню утилит...")
 	}
 }
 
 // --- Пункт 1: Очистка временных файлов ---
-func (m *Module) cleanTempFiles(am core.AssetManager) error {
+func (m *Module) cleanTempFiles(am core.AssetManager, wu core.WinUtils) error {
 	pathsToCleanRaw := am.Cfg().MaintenanceConfig.TempPaths
 	if len(pathsToCleanRaw) == 0 {
 		return errors.New("список путей для очистки 'TempPaths' в конфигурации пуст")
@@ -125,7 +129,7 @@ func (m *Module) cleanTempFiles(am core.AssetManager) error {
 
 		if !fi.IsDir() {
 			size := fi.Size()
-			if err := os.Remove(path); err != nil {
+			if err := wu.RemoveAll(path); err != nil {
 				tui.Warn(fmt.Sprintf("  Не удалось удалить файл %s: %v", path, err))
 			} else {
 				totalFreed += size
@@ -158,7 +162,7 @@ func (m *Module) cleanTempFiles(am core.AssetManager) error {
 		tui.InfoF("  Найдено для удаления: %.2f MB. Начинаем удаление...", float64(currentPathSize)/1024/1024)
 		for _, entry := range dirEntries {
 			fullPath := filepath.Join(path, entry.Name())
-			if err := os.RemoveAll(fullPath); err != nil {
+			if err := wu.RemoveAll(fullPath); err != nil {
 				tui.Warn(fmt.Sprintf("  Не удалось удалить %s: %v", fullPath, err))
 			}
 		}
@@ -169,6 +173,15 @@ func (m *Module) cleanTempFiles(am core.AssetManager) error {
 	return nil
 }
 
+// validatePositive проверяет, что строка - положительное целое число.
+func validatePositive(value string) error {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n <= 0 {
+		return errors.New("должно быть положительное число")
+	}
+	return nil
+}
+
 // getPathSize рекурсивно вычисляет размер файла или содержимого директории.
 func getPathSize(path string) (int64, error) {
 	var size int64
@@ -185,38 +198,30 @@ func getPathSize(path string) (int64, error) {
 }
 
 // --- Пункт 2: Сборщик логов ---
-func (m *Module) collectLogs(am core.AssetManager) error {
-	reader := bufio.NewReader(os.Stdin)
-
-	fmt.Print("За какое количество дней нужно собрать логи? (например, 7): ")
-	daysStr, _ := reader.ReadString('\n')
-	days, err := strconv.Atoi(strings.TrimSpace(daysStr))
-	if err != nil || days <= 0 {
-		return errors.New("некорректное количество дней, должно быть положительное число")
+func (m *Module) collectLogs(am core.AssetManager, p core.Prompter) error {
+	daysStr, err := p.Input("days", "За какое количество дней нужно собрать логи? (например, 7)", "", validatePositive)
+	if err != nil {
+		return err
 	}
+	days, _ := strconv.Atoi(daysStr)
 
 	availableDirs := m.findLogDirectories(am.Cfg())
 	if len(availableDirs) == 0 {
 		return errors.New("не найдено ни одной доступной директории с логами на основе конфигурации")
 	}
 
-	tui.Title("\n--- Доступные директории для сбора логов ---")
-	for i, dir := range availableDirs {
-		fmt.Printf(" %d. %s\n", i+1, dir)
+	var dirOptions []core.Option
+	for _, dir := range availableDirs {
+		dirOptions = append(dirOptions, core.Option{Value: dir, Label: dir})
+	}
+	chosen, err := p.MultiChoose("dirs", "Доступные директории для сбора логов", dirOptions)
+	if err != nil {
+		return err
 	}
-	fmt.Print("Укажите номера путей, откуда собрать логи (через запятую, например: 1,3): ")
-	choiceStr, _ := reader.ReadString('\n')
-	choiceStr = strings.TrimSpace(choiceStr)
 
 	var selectedDirs []string
-	parts := strings.Split(choiceStr, ",")
-	for _, part := range parts {
-		idx, err := strconv.Atoi(strings.TrimSpace(part))
-		if err != nil || idx < 1 || idx > len(availableDirs) {
-			tui.Warn(fmt.Sprintf("Некорректный номер '%s', пропускаем.", part))
-			continue
-		}
-		selectedDirs = append(selectedDirs, availableDirs[idx-1])
+	for _, idx := range chosen {
+		selectedDirs = append(selectedDirs, availableDirs[idx])
 	}
 
 	if len(selectedDirs) == 0 {
@@ -271,7 +276,7 @@ func (m *Module) collectLogs(am core.AssetManager) error {
 		return errors.New("не найдено ни одного подходящего лог-файла за указанный период в выбранных папках")
 	}
 
-	return m.createLogArchive(filesToArchive, am.Cfg().RootPath, days)
+	return m.createLogArchive(filesToArchive, am.Cfg().RootPath, am.Cfg().Site, days)
 }
 
 // handleArchive - гибридная функция, которая пытается распаковать архив сначала как GZIP, а потом как универсальный архив.
@@ -407,11 +412,13 @@ func (m *Module) findLogDirectories(cfg *config.Config) []string {
 	for dir := range dirMap {
 		result = append(result, dir)
 	}
+	// Сортируем, чтобы номера директорий были стабильными между запусками
+	sort.Strings(result)
 	return result
 }
 
-// createLogArchive создает архив с логами.
-func (m *Module) createLogArchive(files []fileToArchive, rootPath string, days int) error {
+// createLogArchive создает архив с логами. Имя площадки (если выбрана) входит в имя архива.
+func (m *Module) createLogArchive(files []fileToArchive, rootPath, site string, days int) error {
 	archiveDir := filepath.Join(rootPath, "log_collector")
 	if err := os.MkdirAll(archiveDir, 0755); err != nil {
 		return fmt.Errorf("не удалось создать директорию для архивов %s: %w", archiveDir, err)
@@ -419,6 +426,9 @@ func (m *Module) createLogArchive(files []fileToArchive, rootPath string, days i
 
 	datetimeStr := time.Now().Format("2006-01-02_1504")
 	archiveName := fmt.Sprintf("logs_%s_%ddelta.zip", datetimeStr, days)
+	if site != "" {
+		archiveName = fmt.Sprintf("logs_%s_%s_%ddelta.zip", site, datetimeStr, days)
+	}
 	archivePath := filepath.Join(archiveDir, archiveName)
 
 	tui.InfoF("Создание архива: %s", archivePath)
@@ -465,7 +475,7 @@ func (m *Module) createLogArchive(files []fileToArchive, rootPath string, days i
 }
 
 // --- Пункт 3: Просмотр лога в реальном времени ---
-func (m *Module) viewLog(am core.AssetManager) error {
+func (m *Module) viewLog(ctx context.Context, am core.AssetManager, p core.Prompter) error {
 	allLogDirs := m.findLogDirectories(am.Cfg())
 	if len(allLogDirs) == 0 {
 		return errors.New("не найдено ни одной директории с логами")
@@ -510,37 +520,32 @@ func (m *Module) viewLog(am core.AssetManager) error {
 	}
 	sort.Strings(dirList)
 
-	reader := bufio.NewReader(os.Stdin)
-	tui.Title("\n--- Найдены сегодняшние логи в следующих папках: ---")
-	for i, dir := range dirList {
-		fmt.Printf(" %d. %s (%d шт.)\n", i+1, dir, len(dirsWithTodayLogs[dir]))
+	var dirOptions []core.Option
+	for _, dir := range dirList {
+		dirOptions = append(dirOptions, core.Option{Value: dir, Label: fmt.Sprintf("%s (%d шт.)", dir, len(dirsWithTodayLogs[dir]))})
 	}
-	fmt.Print("Выберите номер папки: ")
-	choiceStr, _ := reader.ReadString('\n')
-	choice, err := strconv.Atoi(strings.TrimSpace(choiceStr))
-	if err != nil || choice < 1 || choice > len(dirList) {
-		return errors.New("неверный выбор папки")
+	choice, err := p.Choose("log-dir", "Найдены сегодняшние логи в следующих папках", dirOptions)
+	if err != nil {
+		return err
 	}
-	selectedDirKey := dirList[choice-1]
+	selectedDirKey := dirList[choice]
 	filesInSelectedDir := dirsWithTodayLogs[selectedDirKey]
 
-	tui.Title(fmt.Sprintf("\n--- Актуальные логи в папке: %s ---", selectedDirKey))
-	for i, file := range filesInSelectedDir {
-		fmt.Printf(" %d. %s\n", i+1, filepath.Base(file))
+	var fileOptions []core.Option
+	for _, file := range filesInSelectedDir {
+		fileOptions = append(fileOptions, core.Option{Value: filepath.Base(file), Label: filepath.Base(file)})
 	}
-	fmt.Print("Выберите номер файла для просмотра: ")
-	choiceStr, _ = reader.ReadString('\n')
-	choice, err = strconv.Atoi(strings.TrimSpace(choiceStr))
-	if err != nil || choice < 1 || choice > len(filesInSelectedDir) {
-		return errors.New("неверный выбор файла")
+	choice, err = p.Choose("log-file", fmt.Sprintf("Актуальные логи в папке: %s", selectedDirKey), fileOptions)
+	if err != nil {
+		return err
 	}
-	selectedLog := filesInSelectedDir[choice-1]
+	selectedLog := filesInSelectedDir[choice]
 
-	return m.tailFile(selectedLog, 50) // <-- ВЫЗОВ С КОЛИЧЕСТВОМ СТРОК
+	return m.tailFile(ctx, selectedLog, 50) // <-- ВЫЗОВ С КОЛИЧЕСТВОМ СТРОК
 }
 
 // tailFile выводит последние N строк файла и продолжает следить за ним.
-func (m *Module) tailFile(filePath string, lineCount int) error {
+func (m *Module) tailFile(ctx context.Context, filePath string, lineCount int) error {
 	tui.Title(fmt.Sprintf("\n--- Просмотр файла: %s ---", filePath))
 	tui.Info("--- Управление: [Ctrl+C] - выход | [Ctrl+S] - пауза | [Ctrl+Q] - возобновить ---")
 	time.Sleep(1 * time.Second)
@@ -560,9 +565,6 @@ func (m *Module) tailFile(filePath string, lineCount int) error {
 		return fmt.Errorf("не удалось переместить указатель в файле: %w", err)
 	}
 
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer stop()
-
 	// Выводим "хвост" и продолжаем следить
 	if _, err := io.Copy(os.Stdout, file); err != nil {
 		return err
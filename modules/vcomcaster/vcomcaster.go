@@ -3,17 +3,15 @@
 package vcomcaster
 
 import (
-	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"goMH/core"
 	"goMH/tui"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
@@ -31,17 +29,100 @@ type Module struct{}
 func (m *Module) ID() string       { return "VComCaster" }
 func (m *Module) MenuText() string { return "VComCaster (для сканера штрих-кодов)" }
 
-// Run - главная точка входа в модуль.
-func (m *Module) Run(am core.AssetManager, wu core.WinUtils) error {
-	vcomcasterBaseDir := filepath.Join(am.Cfg().RootPath, "vcomcaster")
+// Run - совместимая обертка над методами жизненного цикла.
+func (m *Module) Run(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	return core.RunLifecycle(ctx, m, am, wu, p)
+}
 
-	if _, err := os.Stat(vcomcasterBaseDir); err == nil {
-		// Если директория есть, запускаем режим диагностики/удаления
-		return m.runDiagnosticsWorkflow(wu, am, vcomcasterBaseDir)
+// Detect считает VComCaster установленным, если существует его директория в RootPath.
+func (m *Module) Detect(am core.AssetManager, wu core.WinUtils) (core.Status, error) {
+	if _, err := os.Stat(baseDir(am)); err != nil {
+		return core.Status{State: core.StateNotInstalled}, nil
+	}
+	status := core.Status{State: core.StateInstalled}
+	if running, err := wu.IsProcessRunning("vcomcaster"); err == nil && running {
+		status.Details = "запущен"
 	}
+	return status, nil
+}
+
+func (m *Module) Install(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	return m.runInstallWorkflow(ctx, am, wu, p)
+}
 
-	// Если директории нет, запускаем режим установки
-	return m.runInstallWorkflow(am, wu)
+func (m *Module) Upgrade(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	return m.runReinstallation(ctx, wu, am, p)
+}
+
+func (m *Module) Uninstall(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	return m.runUninstallation(ctx, wu, am)
+}
+
+// Verify проверяет наличие config.ini, деинсталлятора com0com и задачи в Планировщике.
+func (m *Module) Verify(am core.AssetManager, wu core.WinUtils) ([]string, error) {
+	var problems []string
+	dir := baseDir(am)
+	if _, err := os.Stat(filepath.Join(dir, "config.ini")); err != nil {
+		problems = append(problems, "Файл конфигурации config.ini не найден.")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "com0com", "uninstall.exe")); err != nil {
+		problems = append(problems, "Деинсталлятор com0com не найден.")
+	}
+	if _, err := wu.QueryCommand(context.Background(), "schtasks", "/Query", "/TN", taskName); err != nil {
+		problems = append(problems, fmt.Sprintf("Задача '%s' в Планировщике не найдена.", taskName))
+	}
+	return problems, nil
+}
+
+// Inspect сообщает состояние задачи в Планировщике, процесса vcomcaster и порты из config.ini.
+func (m *Module) Inspect(am core.AssetManager, wu core.WinUtils) ([]core.Check, error) {
+	task := core.Check{Name: "задача " + taskName, OK: true, Value: "создана"}
+	if _, err := wu.QueryCommand(context.Background(), "schtasks", "/Query", "/TN", taskName); err != nil {
+		task = core.Check{Name: "задача " + taskName, Value: "не найдена"}
+	}
+	checks := []core.Check{task, core.ProcessCheck(wu, "vcomcaster")}
+
+	scanner, err := ReadScanner(ConfigPath(am.Cfg().RootPath))
+	if err != nil {
+		return append(checks, core.Check{Name: "config.ini", Value: "не найден"}), nil
+	}
+	checks = append(checks, core.Check{
+		Name:  "сканер",
+		OK:    scanner.InputPort != "",
+		Value: fmt.Sprintf("%s: %s -> %s", scanner.DeviceID, scanner.InputPort, scanner.OutputPort),
+	})
+	return checks, nil
+}
+
+// Scanner - настройки сканера из секции [device] config.ini.
+type Scanner struct {
+	DeviceID   string `json:"device_id"`
+	InputPort  string `json:"input_port"`
+	OutputPort string `json:"output_port"`
+}
+
+// ReadScanner читает настройки сканера из config.ini VComCaster.
+func ReadScanner(iniPath string) (Scanner, error) {
+	cfg, err := readConfig(iniPath)
+	if err != nil {
+		return Scanner{}, err
+	}
+	device := cfg.Section("device")
+	return Scanner{
+		DeviceID:   device.Key("device_id").String(),
+		InputPort:  device.Key("input_port").String(),
+		OutputPort: device.Key("output_port").String(),
+	}, nil
+}
+
+// ConfigPath возвращает путь к config.ini VComCaster.
+func ConfigPath(rootPath string) string {
+	return filepath.Join(rootPath, "vcomcaster", "config.ini")
+}
+
+// baseDir возвращает директорию установки VComCaster.
+func baseDir(am core.AssetManager) string {
+	return filepath.Join(am.Cfg().RootPath, "vcomcaster")
 }
 
 // extractDeviceID извлекает часть VID_...&PID_... из полного PNPDeviceID.
@@ -61,27 +142,113 @@ func extractDeviceID(pnpDeviceID string) string {
 }
 
 // --- РЕЖИМ УСТАНОВКИ ---
-func (m *Module) runInstallWorkflow(am core.AssetManager, wu core.WinUtils) error {
+
+// installState - данные, которые шаги установки передают друг другу.
+type installState struct {
+	destPath        string // Директория VComCaster в RootPath
+	com0comExe      string // Установщик com0com в кэше
+	newPorts        []string
+	scannerComPort  string
+	scannerDeviceID string
+	// iikoConfigPath и iikoConfigBackup - config.xml iiko и его содержимое до изменения
+	// порта сканера (nil, если файл не менялся)
+	iikoConfigPath   string
+	iikoConfigBackup []byte
+}
+
+// runInstallWorkflow выполняет установку по шагам. При ошибке выполненные шаги откатываются,
+// чтобы не оставлять com0com без config.ini и задачи в Планировщике.
+func (m *Module) runInstallWorkflow(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
 	tui.Title("\n--- Запуск установки VComCaster ---")
 
-	// 1. Получаем ресурсы
+	st := &installState{}
+	return core.RunSteps(ctx, p,
+		core.Step{
+			Name: "загрузка ресурсов",
+			Do:   func(ctx context.Context) error { return m.stepResources(ctx, am, st) },
+			Undo: func(ctx context.Context) error {
+				if st.destPath == "" {
+					return nil
+				}
+				return wu.RemoveAll(st.destPath)
+			},
+		},
+		core.Step{
+			Name: "установка com0com",
+			Do:   func(ctx context.Context) error { return m.stepCom0com(ctx, wu, st) },
+			Undo: func(ctx context.Context) error {
+				// установщик мог упасть, не дойдя до копирования деинсталлятора
+				if _, err := os.Stat(filepath.Join(st.destPath, "com0com", "uninstall.exe")); err != nil {
+					return nil
+				}
+				return m.uninstallCom0com(ctx, wu, st.destPath)
+			},
+		},
+		core.Step{
+			Name: "выбор сканера",
+			Do:   func(ctx context.Context) error { return m.stepScanner(wu, p, st) },
+		},
+		core.Step{
+			Name: "создание config.ini",
+			Do:   func(ctx context.Context) error { return m.stepConfig(wu, st) },
+			Undo: func(ctx context.Context) error { return wu.RemoveAll(filepath.Join(st.destPath, "config.ini")) },
+		},
+		core.Step{
+			Name: "создание задачи в Планировщике",
+			Do:   func(ctx context.Context) error { return m.stepScheduledTask(wu, st) },
+			Undo: func(ctx context.Context) error {
+				if _, err := wu.QueryCommand(ctx, "schtasks", "/Query", "/TN", taskName); err != nil {
+					return nil // задача не создана
+				}
+				_, err := wu.RunCommand(ctx, "schtasks", "/Delete", "/TN", taskName, "/F")
+				return err
+			},
+		},
+		core.Step{
+			Name: "настройка порта сканера в iiko",
+			Do:   func(ctx context.Context) error { return m.stepIikoConfig(wu, st) },
+			Undo: func(ctx context.Context) error {
+				if st.iikoConfigBackup == nil {
+					return nil
+				}
+				return wu.WriteFile(st.iikoConfigPath, st.iikoConfigBackup, 0644)
+			},
+		},
+		core.Step{
+			Name: "запуск vcomcaster.exe",
+			Do:   func(ctx context.Context) error { return m.stepStart(wu, st) },
+			Undo: func(ctx context.Context) error {
+				if running, err := wu.IsProcessRunning("vcomcaster"); err != nil || !running {
+					return err
+				}
+				_, err := wu.RunCommand(ctx, "taskkill", "/F", "/IM", "vcomcaster.exe")
+				return err
+			},
+		},
+	)
+}
+
+func (m *Module) stepResources(ctx context.Context, am core.AssetManager, st *installState) error {
 	tui.Info("-> Этап 1: Загрузка необходимых ресурсов...")
-	vcomcasterDestPath, err := am.Get("VComCaster_Package")
+	var err error
+	st.destPath, err = am.Get(ctx, "VComCaster_Package")
 	if err != nil {
 		return fmt.Errorf("не удалось получить VComCaster_Package: %w", err)
 	}
-	com0comInstallerExe, err := am.DownloadToCache("Com0Com_Installer")
+	st.com0comExe, err = am.DownloadToCache(ctx, "Com0Com_Installer")
 	if err != nil {
 		return fmt.Errorf("не удалось скачать Com0Com_Installer в кэш: %w", err)
 	}
-	tui.SuccessF("Установщик com0com находится в кэше: %s", com0comInstallerExe)
+	tui.SuccessF("Установщик com0com находится в кэше: %s", st.com0comExe)
+	return nil
+}
 
-	// 2. Установка com0com
+func (m *Module) stepCom0com(ctx context.Context, wu core.WinUtils, st *installState) error {
 	tui.Info("-> Этап 2: Установка com0com...")
 	portsBefore, _ := wu.GetComPorts()
 
-	com0comInstallDir := filepath.Join(vcomcasterDestPath, "com0com")
-	_ = os.MkdirAll(com0comInstallDir, 0755)
+	com0comInstallDir := filepath.Join(st.destPath, "com0com")
+	_ = wu.MkdirAll(com0comInstallDir, 0755)
 
 	com0comEnv := map[string]string{
 		"CNC_INSTALL_COMX_COMX_PORTS":      "YES",
@@ -89,9 +256,10 @@ func (m *Module) runInstallWorkflow(am core.AssetManager, wu core.WinUtils) erro
 		"CNC_INSTALL_START_MENU_SHORTCUTS": "NO",
 	}
 
-	_, err = wu.RunCommandWithEnv(
+	_, err := wu.RunCommandWithEnv(
+		ctx,
 		com0comEnv,
-		com0comInstallerExe,
+		st.com0comExe,
 		"/S",
 		fmt.Sprintf("/D=%s", com0comInstallDir),
 	)
@@ -102,15 +270,17 @@ func (m *Module) runInstallWorkflow(am core.AssetManager, wu core.WinUtils) erro
 	time.Sleep(5 * time.Second)
 
 	portsAfter, _ := wu.GetComPorts()
-	newPorts := findNewPorts(portsBefore, portsAfter)
-	if len(newPorts) < 2 {
+	st.newPorts = findNewPorts(portsBefore, portsAfter)
+	if len(st.newPorts) < 2 {
 		tui.Warn("ПРЕДУПРЕЖДЕНИЕ: Не удалось определить созданные виртуальные COM-порты. Проверьте Диспетчер устройств.")
 	} else {
-		sort.Strings(newPorts)
-		tui.SuccessF("Созданы виртуальные порты: %s и %s", newPorts[0], newPorts[1])
+		sort.Strings(st.newPorts)
+		tui.SuccessF("Созданы виртуальные порты: %s и %s", st.newPorts[0], st.newPorts[1])
 	}
+	return nil
+}
 
-	// 3. Определение сканера (НОВАЯ ЛОГИКА)
+func (m *Module) stepScanner(wu core.WinUtils, p core.Prompter, st *installState) error {
 	tui.Info("-> Этап 3: Определение сканера...")
 	scanners, err := wu.GetScanners()
 	if err != nil {
@@ -120,87 +290,99 @@ func (m *Module) runInstallWorkflow(am core.AssetManager, wu core.WinUtils) erro
 		return errors.New("не найдено ни одного USB-сканера, подключенного к COM-порту. Проверьте подключение и драйверы")
 	}
 
-	tui.Title("\n--- Найдены следующие устройства ---")
-	for i, scanner := range scanners {
+	// Значение варианта - VID/PID устройства, чтобы сканер можно было указать в файле ответов.
+	// Если VID/PID определить не удалось, используется имя порта.
+	var scannerOptions []core.Option
+	for _, scanner := range scanners {
+		value := extractDeviceID(scanner.PNPDeviceID)
+		if value == "" {
+			value = scanner.Port
+		}
 		// Проверяем, содержит ли Caption (название продукта) уже имя порта.
-		portInCaption := fmt.Sprintf("(%s)", scanner.Port)
-		if strings.Contains(scanner.Caption, portInCaption) {
-			// Если да, то просто выводим Caption как есть.
-			fmt.Printf(" %d. %s\n", i+1, scanner.Caption)
-		} else {
+		label := scanner.Caption
+		if !strings.Contains(scanner.Caption, fmt.Sprintf("(%s)", scanner.Port)) {
 			// Если нет, то добавляем порт в скобках для красоты.
-			fmt.Printf(" %d. %s (%s)\n", i+1, scanner.Caption, scanner.Port)
+			label = fmt.Sprintf("%s (%s)", scanner.Caption, scanner.Port)
 		}
+		scannerOptions = append(scannerOptions, core.Option{Value: value, Label: label})
 	}
-	fmt.Print("Выберите номер вашего сканера: ")
 
-	reader := bufio.NewReader(os.Stdin)
-	choiceStr, _ := reader.ReadString('\n')
-	choice, err := strconv.Atoi(strings.TrimSpace(choiceStr))
-	if err != nil || choice < 1 || choice > len(scanners) {
-		return errors.New("неверный выбор, установка прервана")
+	choice, err := p.Choose("scanner", "Найдены следующие устройства. Выберите ваш сканер", scannerOptions)
+	if err != nil {
+		return fmt.Errorf("сканер не выбран, установка прервана: %w", err)
 	}
 
-	selectedScanner := scanners[choice-1]
-	scannerComPort := selectedScanner.Port
-	scannerDeviceID := extractDeviceID(selectedScanner.PNPDeviceID)
+	selectedScanner := scanners[choice]
+	st.scannerComPort = selectedScanner.Port
+	st.scannerDeviceID = extractDeviceID(selectedScanner.PNPDeviceID)
 
-	tui.SuccessF("Выбран сканер: %s на порту %s", selectedScanner.Caption, scannerComPort)
-	if scannerDeviceID != "" {
-		tui.SuccessF("Определен ID устройства: %s", scannerDeviceID)
+	tui.SuccessF("Выбран сканер: %s на порту %s", selectedScanner.Caption, st.scannerComPort)
+	if st.scannerDeviceID != "" {
+		tui.SuccessF("Определен ID устройства: %s", st.scannerDeviceID)
 	} else {
 		tui.Warn("Не удалось определить VID/PID устройства. Поле device_id в конфиге будет пустым.")
 	}
+	return nil
+}
 
-	// 4. Создание config.ini
+func (m *Module) stepConfig(wu core.WinUtils, st *installState) error {
 	tui.Info("-> Этап 4: Создание config.ini...")
 	outputPort := ""
-	if len(newPorts) > 0 {
-		outputPort = newPorts[0]
+	if len(st.newPorts) > 0 {
+		outputPort = st.newPorts[0]
 	}
 	iniContent := fmt.Sprintf(
 		"[app]\r\nautostart_listing = 1\r\nautoreconnect = 1\r\nlogs-autoclear-days = 2\r\n[device]\r\ndevice_id = %s\r\ninput_port = %s\r\noutput_port = %s\r\nport_baudrate = 115200\r\ncr = 0\r\nlf = 0\r\n[service]\r\namount_rm_char_id = 0\r\ntimeout_clearcash = 1.5\r\ntimeout_autoreconnect = 3\r\ntimeout_reconnect = 3",
-		scannerDeviceID, scannerComPort, outputPort,
+		st.scannerDeviceID, st.scannerComPort, outputPort,
 	)
-	configPath := filepath.Join(vcomcasterDestPath, "config.ini")
-	if err := os.WriteFile(configPath, []byte(iniContent), 0644); err != nil {
+	configPath := filepath.Join(st.destPath, "config.ini")
+	if err := wu.WriteFile(configPath, []byte(iniContent), 0644); err != nil {
 		return fmt.Errorf("не удалось создать config.ini: %w", err)
 	}
 	tui.Success("Файл config.ini успешно создан.")
+	return nil
+}
 
-	// 5. Финальная настройка
+func (m *Module) stepScheduledTask(wu core.WinUtils, st *installState) error {
 	tui.Info("-> Этап 5: Финальная настройка (Планировщик, запуск)...")
-	vcomcasterExePath := filepath.Join(vcomcasterDestPath, "vcomcaster.exe")
+	vcomcasterExePath := filepath.Join(st.destPath, "vcomcaster.exe")
 
-	if err := wu.CreateScheduledTask(taskName, vcomcasterExePath, vcomcasterDestPath); err != nil {
+	if err := wu.CreateScheduledTask(taskName, vcomcasterExePath, st.destPath); err != nil {
 		tui.Warn(fmt.Sprintf("ВНИМАНИЕ: Не удалось создать/обновить задачу в планировщике: %v", err))
 	} else {
 		tui.SuccessF("Задача '%s' в Планировщике Windows успешно создана/обновлена.", taskName)
 	}
+	return nil
+}
 
-	if len(newPorts) > 1 {
-		iikoPort := newPorts[1]
-		if err := m.updateIikoConfig(wu, iikoPort); err != nil {
+// stepIikoConfig указывает iiko второй виртуальный порт как порт сканера. Прежнее
+// содержимое config.xml сохраняется в st для отката.
+func (m *Module) stepIikoConfig(wu core.WinUtils, st *installState) error {
+	if len(st.newPorts) > 1 {
+		iikoPort := st.newPorts[1]
+		if err := m.updateIikoConfig(wu, iikoPort, st); err != nil {
 			tui.Warn(fmt.Sprintf("Не удалось автоматически обновить конфиг iiko: %v", err))
 			tui.Warn(fmt.Sprintf("ВАЖНО: Пожалуйста, вручную укажите в настройках iiko порт сканера: %s", iikoPort))
 		}
 	} else {
 		tui.Warn("Не удалось определить порт для iiko. Пропустили обновление конфига.")
 	}
+	return nil
+}
 
+func (m *Module) stepStart(wu core.WinUtils, st *installState) error {
 	tui.Info("Запуск vcomcaster.exe...")
-	// Запуск GUI приложения без ожидания. Этот вызов остается прямым, т.к. не влияет на тестируемую логику.
-	startCmd := exec.Command(vcomcasterExePath)
-	startCmd.Dir = vcomcasterDestPath
-	if err := startCmd.Start(); err != nil {
+	// Запуск GUI приложения без ожидания.
+	if err := wu.StartProcess(filepath.Join(st.destPath, "vcomcaster.exe"), st.destPath); err != nil {
 		return fmt.Errorf("не удалось запустить vcomcaster.exe: %w", err)
 	}
 	tui.Success("Приложение vcomcaster успешно запущено в фоновом режиме.")
-
 	return nil
 }
 
-func (m *Module) updateIikoConfig(wu core.WinUtils, iikoPort string) error {
+// updateIikoConfig записывает порт сканера в config.xml iiko. Перед записью прежнее
+// содержимое файла сохраняется в st.iikoConfigBackup.
+func (m *Module) updateIikoConfig(wu core.WinUtils, iikoPort string, st *installState) error {
 	const maxRetries = 3
 	const retryDelay = 10 * time.Second
 
@@ -234,8 +416,12 @@ func (m *Module) updateIikoConfig(wu core.WinUtils, iikoPort string) error {
 		}
 	}
 
+	original, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения XML файла: %w", err)
+	}
 	doc := etree.NewDocument()
-	if err := doc.ReadFromFile(configPath); err != nil {
+	if err := doc.ReadFromBytes(original); err != nil {
 		return fmt.Errorf("ошибка чтения XML файла: %w", err)
 	}
 
@@ -254,7 +440,12 @@ func (m *Module) updateIikoConfig(wu core.WinUtils, iikoPort string) error {
 	portElement.SetText(iikoPort)
 
 	doc.Indent(2)
-	if err := doc.WriteToFile(configPath); err != nil {
+	data, err := doc.WriteToBytes()
+	if err != nil {
+		return fmt.Errorf("ошибка формирования XML: %w", err)
+	}
+	st.iikoConfigPath, st.iikoConfigBackup = configPath, original
+	if err := wu.WriteFile(configPath, data, 0644); err != nil {
 		return fmt.Errorf("ошибка сохранения XML файла: %w", err)
 	}
 
@@ -262,66 +453,17 @@ func (m *Module) updateIikoConfig(wu core.WinUtils, iikoPort string) error {
 	return nil
 }
 
-// --- РЕЖИМ ДИАГНОСТИКИ И УДАЛЕНИЯ ---
-func (m *Module) runDiagnosticsWorkflow(wu core.WinUtils, am core.AssetManager, baseDir string) error {
-	tui.Title("\n--- Обнаружена существующая установка. Запуск диагностики... ---")
-
-	var problems []string
-	configPath := filepath.Join(baseDir, "config.ini")
-	com0comUninstallerPath := filepath.Join(baseDir, "com0com", "uninstall.exe")
-
-	if _, err := os.Stat(configPath); err != nil {
-		problems = append(problems, "x Файл конфигурации config.ini не найден.")
-	}
-	if _, err := os.Stat(com0comUninstallerPath); err != nil {
-		problems = append(problems, "x Деинсталлятор com0com не найден.")
-	}
-	if _, err := wu.RunCommand("schtasks", "/Query", "/TN", taskName); err != nil {
-		problems = append(problems, fmt.Sprintf("x Задача '%s' в Планировщике не найдена.", taskName))
-	}
-
-	if len(problems) == 0 {
-		tui.Success("\n[ДИАГНОСТИКА] Проблем не обнаружено. Система выглядит настроенной.")
-	} else {
-		tui.Warn("\n[ДИАГНОСТИКА] Обнаружены следующие проблемы:")
-		for _, p := range problems {
-			tui.Warn(p)
-		}
-	}
-
-	fmt.Println("\nВыберите действие:")
-	fmt.Println(" 1. Полностью удалить VComCaster")
-	fmt.Println(" 2. Выполнить переустановку (сначала удалит, потом нужно запустить снова)")
-	fmt.Println(" 3. Вернуться в главное меню")
-	fmt.Print("Ваш выбор: ")
-
-	reader := bufio.NewReader(os.Stdin)
-	choice, _ := reader.ReadString('\n')
-	choice = strings.TrimSpace(choice)
-
-	switch choice {
-	case "1":
-		return m.runUninstallation(wu, am) // <-- Передаем am
-	case "2":
-		return m.runReinstallation(wu, am) // <-- Вызываем новую функцию
-	case "3":
-		tui.Info("Операция отменена. Возврат в главное меню.")
-		return nil
-	default:
-		tui.Warn("Неверный выбор. Возврат в главное меню.")
-		return nil
-	}
-}
+// --- РЕЖИМ ПЕРЕУСТАНОВКИ И УДАЛЕНИЯ ---
 
 // Новая функция переустановки
-func (m *Module) runReinstallation(wu core.WinUtils, am core.AssetManager) error {
+func (m *Module) runReinstallation(ctx context.Context, wu core.WinUtils, am core.AssetManager, p core.Prompter) error {
 	tui.Title("\n--- Начало процесса переустановки VComCaster ---")
 
 	tui.Info("-> Остановка процесса 'vcomcaster.exe'...")
-	_, _ = wu.RunCommand("taskkill", "/F", "/IM", "vcomcaster.exe")
+	_, _ = wu.RunCommand(ctx, "taskkill", "/F", "/IM", "vcomcaster.exe")
 
 	tui.InfoF("-> Удаление задачи '%s' из Планировщика...", taskName)
-	if _, err := wu.RunCommand("schtasks", "/Delete", "/TN", taskName, "/F"); err != nil {
+	if _, err := wu.RunCommand(ctx, "schtasks", "/Delete", "/TN", taskName, "/F"); err != nil {
 		tui.Warn("   (Предупреждение: не удалось удалить задачу, возможно, ее и не было)")
 	}
 
@@ -336,27 +478,25 @@ func (m *Module) runReinstallation(wu core.WinUtils, am core.AssetManager) error
 
 	tui.Info("\n--- Запуск новой установки ---")
 	// Просто вызываем основной воркфлоу установки
-	return m.runInstallWorkflow(am, wu)
+	return m.runInstallWorkflow(ctx, am, wu, p)
 }
 
 // Функция полного удаления
-func (m *Module) runUninstallation(wu core.WinUtils, am core.AssetManager) error { // <-- Добавляем am в аргументы
+func (m *Module) runUninstallation(ctx context.Context, wu core.WinUtils, am core.AssetManager) error { // <-- Добавляем am в аргументы
 	tui.Title("\n--- Начало процесса полного удаления ---")
-	baseDir := filepath.Join(am.Cfg().RootPath, "vcomcaster")
+	dir := baseDir(am)
 
 	tui.Info("-> Остановка процесса 'vcomcaster.exe'...")
-	_, _ = wu.RunCommand("taskkill", "/F", "/IM", "vcomcaster.exe")
+	_, _ = wu.RunCommand(ctx, "taskkill", "/F", "/IM", "vcomcaster.exe")
 
 	tui.InfoF("-> Удаление задачи '%s'...", taskName)
-	if _, err := wu.RunCommand("schtasks", "/Delete", "/TN", taskName, "/F"); err != nil {
+	if _, err := wu.RunCommand(ctx, "schtasks", "/Delete", "/TN", taskName, "/F"); err != nil {
 		tui.Warn("   (Предупреждение: не удалось удалить задачу, возможно, ее и не было)")
 	}
 
-	uninstallerPath := filepath.Join(baseDir, "com0com", "uninstall.exe")
-	installPath := filepath.Join(baseDir, "com0com")
-	if _, err := os.Stat(uninstallerPath); err == nil {
+	if _, err := os.Stat(filepath.Join(dir, "com0com", "uninstall.exe")); err == nil {
 		tui.Info("-> Запуск деинсталлятора com0com...")
-		if _, err := wu.RunCommand(uninstallerPath, "/S", fmt.Sprintf("_?=%s", installPath)); err != nil {
+		if err := m.uninstallCom0com(ctx, wu, dir); err != nil {
 			tui.Warn(fmt.Sprintf("   (Предупреждение: деинсталлятор com0com завершился с ошибкой: %v)", err))
 		} else {
 			tui.Success("   com0com удален.")
@@ -378,6 +518,14 @@ func (m *Module) runUninstallation(wu core.WinUtils, am core.AssetManager) error
 }
 
 // Вспомогательные функции
+
+// uninstallCom0com запускает деинсталлятор com0com из директории VComCaster.
+func (m *Module) uninstallCom0com(ctx context.Context, wu core.WinUtils, dir string) error {
+	installPath := filepath.Join(dir, "com0com")
+	_, err := wu.RunCommand(ctx, filepath.Join(installPath, "uninstall.exe"), "/S", fmt.Sprintf("_?=%s", installPath))
+	return err
+}
+
 func findNewPorts(before, after []string) []string {
 	beforeMap := make(map[string]bool)
 	for _, port := range before {
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goMH/assetmgr"
+	"goMH/modules/frpc"
+	"goMH/modules/iiko"
+	"goMH/tui"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultPrefetchWorkers - сколько файлов скачивается одновременно по умолчанию.
+const defaultPrefetchWorkers = 3
+
+// prefetchTask - файл, который нужно скачать заранее.
+type prefetchTask struct {
+	name      string
+	localPath string
+	// download скачивает файл и сообщает, был ли он уже в кэше.
+	download func(ctx context.Context) (skipped bool, err error)
+}
+
+// prefetchResult - итог скачивания одного файла.
+type prefetchResult struct {
+	skipped bool
+	err     error
+}
+
+// cmdAssetsPrefetch заранее скачивает ресурсы каталога и последние дистрибутивы iiko,
+// чтобы установка на точке не зависела от канала: goMH assets prefetch
+// [--all | --profile имя | ID ...] [--workers N]. ID - ресурс каталога или модуль.
+func (a *App) cmdAssetsPrefetch(args []string) int {
+	workers := defaultPrefetchWorkers
+	var all bool
+	var profileName string
+	var ids []string
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "-") {
+			ids = append(ids, args[i])
+			continue
+		}
+		switch flag := strings.TrimLeft(args[i], "-"); flag {
+		case "all":
+			all = true
+		case "profile", "workers":
+			if i+1 >= len(args) {
+				tui.Error(fmt.Sprintf("Не указано значение --%s", flag))
+				return exitUsage
+			}
+			i++
+			if flag == "profile" {
+				profileName = args[i]
+				continue
+			}
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 {
+				tui.Error(fmt.Sprintf("--workers: ожидается положительное число, получено '%s'", args[i]))
+				return exitUsage
+			}
+			workers = n
+		default:
+			tui.Error(fmt.Sprintf("Неизвестный параметр: %s", args[i]))
+			return exitUsage
+		}
+	}
+
+	modes := 0
+	for _, set := range []bool{all, profileName != "", len(ids) > 0} {
+		if set {
+			modes++
+		}
+	}
+	if modes != 1 {
+		tui.Error("Укажите одно из: --all, --profile <имя> или ID ресурсов/модулей")
+		return exitUsage
+	}
+
+	tasks, err := a.prefetchTasks(all, profileName, ids)
+	if err != nil {
+		tui.Error(err.Error())
+		return exitUsage
+	}
+	if len(tasks) == 0 {
+		tui.Info("Скачивать нечего.")
+		return exitOK
+	}
+
+	ctx, done := a.operationContext()
+	defer done()
+
+	tui.Info(fmt.Sprintf("Скачивание %d файлов, одновременно не более %d...", len(tasks), workers))
+	results := runPrefetch(ctx, tasks, workers)
+	if a.Plan != nil {
+		a.Plan.Print()
+		a.Plan.Reset()
+		return exitOK
+	}
+	return printPrefetchSummary(ctx, tasks, results)
+}
+
+// prefetchTasks составляет список файлов для скачивания без повторов.
+func (a *App) prefetchTasks(all bool, profileName string, ids []string) ([]prefetchTask, error) {
+	var tasks []prefetchTask
+	seen := make(map[string]bool)
+	add := func(list ...prefetchTask) {
+		for _, task := range list {
+			if !seen[task.localPath] {
+				seen[task.localPath] = true
+				tasks = append(tasks, task)
+			}
+		}
+	}
+
+	switch {
+	case all:
+		var assetIDs []string
+		for id := range a.Cfg.AssetCatalog {
+			assetIDs = append(assetIDs, id)
+		}
+		sort.Strings(assetIDs)
+		for _, id := range assetIDs {
+			add(a.assetTask(id))
+		}
+		for _, moduleID := range []string{"iiko", "FRPC"} {
+			if a.Cfg.HasModule(moduleID) {
+				list, err := a.moduleTasks(moduleID)
+				if err != nil {
+					return nil, err
+				}
+				add(list...)
+			}
+		}
+	case profileName != "":
+		_, profile, ok := a.Cfg.FindProfile(profileName)
+		if !ok {
+			return nil, fmt.Errorf("профиль '%s' не найден", profileName)
+		}
+		for _, module := range profile.Modules {
+			list, err := a.moduleTasks(module.ID)
+			if err != nil {
+				return nil, err
+			}
+			add(list...)
+		}
+	default:
+		for _, id := range ids {
+			if _, ok := a.Cfg.AssetCatalog[id]; ok {
+				add(a.assetTask(id))
+				continue
+			}
+			if _, ok := a.Modules[id]; !ok {
+				return nil, fmt.Errorf("'%s' нет ни в каталоге ресурсов, ни среди модулей", id)
+			}
+			list, err := a.moduleTasks(id)
+			if err != nil {
+				return nil, err
+			}
+			add(list...)
+		}
+	}
+	return tasks, nil
+}
+
+// moduleTasks возвращает файлы, которые скачивает модуль при установке.
+func (a *App) moduleTasks(moduleID string) ([]prefetchTask, error) {
+	var tasks []prefetchTask
+	for _, id := range a.Cfg.ModuleAssets(moduleID) {
+		tasks = append(tasks, a.assetTask(id))
+	}
+
+	switch moduleID {
+	case "iiko":
+		distros, err := (&iiko.Module{}).LatestDistros(a.AM)
+		if err != nil {
+			return nil, fmt.Errorf("iiko: %w", err)
+		}
+		for _, d := range distros {
+			tasks = append(tasks, prefetchTask{
+				name:      d.Name,
+				localPath: d.LocalPath,
+				download: func(ctx context.Context) (bool, error) {
+					return a.AM.DownloadFTPWithProgress(ctx, d.FTPPath, d.LocalPath)
+				},
+			})
+		}
+	case "FRPC":
+		frpcZip, nssmZip := frpc.Archives(a.Cfg)
+		for _, archive := range []frpc.Archive{frpcZip, nssmZip} {
+			tasks = append(tasks, prefetchTask{
+				name:      archive.Name,
+				localPath: archive.LocalPath,
+				download: func(ctx context.Context) (bool, error) {
+					return a.AM.DownloadMirrors(ctx, archive.URLs, archive.LocalPath)
+				},
+			})
+		}
+	}
+	return tasks, nil
+}
+
+// assetTask скачивает ресурс каталога в кэш. DownloadToCache не сообщает, был ли файл
+// в кэше, поэтому пропуск определяется по тому, что файл не изменился.
+func (a *App) assetTask(id string) prefetchTask {
+	cachePath, _ := a.AM.CachePath(id)
+	return prefetchTask{
+		name:      id,
+		localPath: cachePath,
+		download: func(ctx context.Context) (bool, error) {
+			before, statErr := os.Stat(cachePath)
+			if _, err := a.AM.DownloadToCache(ctx, id); err != nil {
+				return false, err
+			}
+			after, err := os.Stat(cachePath)
+			skipped := statErr == nil && err == nil &&
+				before.Size() == after.Size() && before.ModTime().Equal(after.ModTime())
+			return skipped, nil
+		},
+	}
+}
+
+// runPrefetch скачивает файлы не более чем в workers потоков с общим индикатором прогресса.
+func runPrefetch(ctx context.Context, tasks []prefetchTask, workers int) []prefetchResult {
+	bars := tui.NewMultiBar()
+	defer bars.Close()
+
+	results := make([]prefetchResult, len(tasks))
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				task := tasks[i]
+				if ctx.Err() != nil {
+					results[i].err = ctx.Err()
+					continue
+				}
+				line := bars.Add(task.name)
+				taskCtx := assetmgr.WithReporter(ctx, barReporter{bars: bars, line: line})
+				skipped, err := task.download(taskCtx)
+				results[i] = prefetchResult{skipped: skipped, err: err}
+				line.Done(results[i].label())
+			}
+		}()
+	}
+	for i := range tasks {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+	return results
+}
+
+// barReporter направляет сообщения и прогресс одной загрузки в общий индикатор.
+type barReporter struct {
+	bars *tui.MultiBar
+	line *tui.BarLine
+}
+
+func (r barReporter) Printf(format string, args ...interface{}) {
+	if msg := strings.TrimSpace(fmt.Sprintf(format, args...)); msg != "" {
+		r.bars.Println(msg)
+	}
+}
+
+func (r barReporter) Progress(description string, total, offset int64) io.Writer {
+	r.line.Reset(total, offset)
+	return r.line
+}
+
+func (r prefetchResult) label() string {
+	switch {
+	case r.err != nil && errors.Is(r.err, context.Canceled):
+		return tui.ColorYellow + "прервано" + tui.ColorReset
+	case r.err != nil:
+		return tui.ColorRed + "ошибка: " + r.err.Error() + tui.ColorReset
+	case r.skipped:
+		return "уже в кэше"
+	default:
+		return tui.ColorGreen + "скачан" + tui.ColorReset
+	}
+}
+
+// printPrefetchSummary выводит итог по каждому файлу и возвращает код завершения.
+func printPrefetchSummary(ctx context.Context, tasks []prefetchTask, results []prefetchResult) int {
+	fmt.Println("\n=== Итог предварительного скачивания ===")
+	var fetched, skipped, failed int
+	for i, task := range tasks {
+		r := results[i]
+		size := ""
+		if fi, err := os.Stat(task.localPath); err == nil && r.err == nil {
+			size = tui.FormatBytes(fi.Size())
+		}
+		fmt.Printf("  %-32s %-10s %s\n", task.name, size, r.label())
+		switch {
+		case r.err != nil:
+			failed++
+		case r.skipped:
+			skipped++
+		default:
+			fetched++
+		}
+	}
+	fmt.Printf("Скачано: %d, уже в кэше: %d, с ошибкой: %d.\n", fetched, skipped, failed)
+
+	switch {
+	case ctx.Err() != nil:
+		printOutcome(ctx.Err())
+		return exitInterrupted
+	case failed > 0:
+		return exitError
+	}
+	return exitOK
+}
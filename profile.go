@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goMH/config"
+	"goMH/core"
+	"goMH/tui"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Итоги установки модуля в профиле.
+const (
+	resultOK      = "успешно"
+	resultFailed  = "ошибка"
+	resultSkipped = "пропущен"
+)
+
+// profileResult - итог установки одного модуля профиля.
+type profileResult struct {
+	ID       string
+	Status   string
+	Duration time.Duration
+	Message  string
+}
+
+func (a *App) cmdProfile(args []string) int {
+	if len(args) == 0 {
+		return a.listProfiles()
+	}
+
+	name, profile, ok := a.Cfg.FindProfile(args[0])
+	if !ok {
+		tui.Error(fmt.Sprintf("Профиль '%s' не найден в конфигурации. Список профилей: goMH profile", args[0]))
+		return exitUsage
+	}
+
+	params, err := parseParams(args[1:])
+	if err != nil {
+		tui.Error(err.Error())
+		return exitUsage
+	}
+
+	ordered, err := profile.Order()
+	if err != nil {
+		tui.Error(fmt.Sprintf("Ошибка в профиле '%s': %v", name, err))
+		return exitError
+	}
+	var ids []string
+	for _, pm := range ordered {
+		if _, ok := a.findModule(pm.ID); !ok {
+			tui.Error(fmt.Sprintf("Модуль '%s' из профиля '%s' не найден или не включен в конфигурации.", pm.ID, name))
+			return exitError
+		}
+		ids = append(ids, pm.ID)
+	}
+
+	tui.Title(fmt.Sprintf("\n=== Профиль %s: %s ===", name, profile.Description))
+	tui.InfoF("Порядок установки: %s", strings.Join(ids, " -> "))
+
+	results := a.runProfile(ordered, params)
+	printProfileSummary(results)
+
+	for _, res := range results {
+		if res.Status != resultOK {
+			return exitError
+		}
+	}
+	return exitOK
+}
+
+func (a *App) listProfiles() int {
+	if len(a.Cfg.Profiles) == 0 {
+		tui.Warn("В конфигурации не определено ни одного профиля установки.")
+		return exitError
+	}
+	var names []string
+	for name := range a.Cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		profile := a.Cfg.Profiles[name]
+		var ids []string
+		for _, pm := range profile.Modules {
+			ids = append(ids, pm.ID)
+		}
+		fmt.Printf("%-16s %s [%s]\n", name, profile.Description, strings.Join(ids, ", "))
+	}
+	return exitOK
+}
+
+// runProfile по очереди запускает модули профиля. Модуль, зависимость которого
+// не установилась, пропускается, остальные продолжают устанавливаться.
+func (a *App) runProfile(modules []config.ProfileModule, params core.Params) []profileResult {
+	failed := make(map[string]bool)
+	var results []profileResult
+
+	for i, pm := range modules {
+		res := profileResult{ID: pm.ID}
+		var runErr error
+		for _, dep := range pm.DependsOn {
+			if failed[strings.ToLower(dep)] {
+				res.Status = resultSkipped
+				res.Message = fmt.Sprintf("не установлена зависимость %s", dep)
+				break
+			}
+		}
+
+		if res.Status == "" {
+			module, _ := a.findModule(pm.ID)
+			tui.Title(fmt.Sprintf("\n--- [%d/%d] Запуск модуля %s ---", i+1, len(modules), module.ID()))
+
+			base, err := pm.Params()
+			if err == nil {
+				start := time.Now()
+				err = a.runModule(module, a.prompterWith(module.ID(), base, moduleParams(params, module.ID()), false))
+				res.Duration = time.Since(start)
+			}
+			runErr = err
+			if err != nil {
+				res.Status = resultFailed
+				res.Message = err.Error()
+				tui.Error(fmt.Sprintf("Модуль %s завершился с ошибкой: %v", module.ID(), err))
+			} else {
+				res.Status = resultOK
+			}
+		}
+
+		if res.Status != resultOK {
+			failed[strings.ToLower(pm.ID)] = true
+		}
+		results = append(results, res)
+
+		// После Ctrl+C остальные модули профиля не запускаются
+		if errors.Is(runErr, context.Canceled) {
+			for _, rest := range modules[i+1:] {
+				results = append(results, profileResult{ID: rest.ID, Status: resultSkipped, Message: "прервано пользователем"})
+			}
+			break
+		}
+	}
+	return results
+}
+
+// moduleParams выбирает параметры командной строки для модуля профиля:
+// "--iiko.version 900" относится только к iiko, параметры без префикса - ко всем модулям.
+// Параметр с префиксом модуля важнее общего.
+func moduleParams(params core.Params, moduleID string) core.Params {
+	result := core.Params{}
+	prefix := strings.ToLower(moduleID) + "."
+	for key, value := range params {
+		if !strings.Contains(key, ".") {
+			result[key] = value
+		}
+	}
+	for key, value := range params {
+		if strings.HasPrefix(key, prefix) {
+			result[strings.TrimPrefix(key, prefix)] = value
+		}
+	}
+	return result
+}
+
+// printProfileSummary выводит итоговую таблицу установки профиля.
+func printProfileSummary(results []profileResult) {
+	tui.Title("\n=== Итоги установки профиля ===")
+	fmt.Printf("%-14s %-10s %8s  %s\n", "Модуль", "Результат", "Время", "Комментарий")
+	for _, res := range results {
+		color := tui.ColorGreen
+		switch res.Status {
+		case resultFailed:
+			color = tui.ColorRed
+		case resultSkipped:
+			color = tui.ColorYellow
+		}
+		duration := "-"
+		if res.Duration > 0 {
+			duration = res.Duration.Round(time.Second).String()
+		}
+		fmt.Printf("%-14s %s%-10s%s %8s  %s\n", res.ID, color, res.Status, tui.ColorReset, duration, firstLine(res.Message))
+	}
+}
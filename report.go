@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"goMH/modules/frpc"
+	"goMH/modules/vcomcaster"
+	"goMH/reporting"
+	"goMH/tui"
+	"path/filepath"
+	"time"
+)
+
+// inventory - отчет о машине для центрального сервера (раздел reporting конфигурации).
+type inventory struct {
+	Hostname    string    `json:"hostname"`
+	Site        string    `json:"site,omitempty"`
+	Time        time.Time `json:"time"`
+	GoMHVersion string    `json:"gomh_version"`
+	// Trigger - причина отчета: "report" или "run:<модуль>".
+	Trigger  string               `json:"trigger"`
+	Modules  []moduleStatus       `json:"modules"`
+	FrpsHost string               `json:"frps_host,omitempty"`
+	Tunnels  []frpc.Tunnel        `json:"frpc_tunnels,omitempty"`
+	Scanners []vcomcaster.Scanner `json:"scanners,omitempty"`
+}
+
+// collectInventory собирает состояние модулей, туннели FRPC и сканеры VComCaster.
+func (a *App) collectInventory(trigger string) inventory {
+	status := a.collectStatus()
+	inv := inventory{
+		Hostname:    status.Hostname,
+		Site:        a.Cfg.Site,
+		Time:        status.Time,
+		GoMHVersion: version,
+		Trigger:     trigger,
+		Modules:     status.Modules,
+	}
+	if tunnels, err := frpc.ReadTunnels(filepath.Join(a.Cfg.FrpcConfig.InstallPath, "frpc.ini")); err == nil {
+		inv.FrpsHost = a.Cfg.FrpcConfig.ServerConfig.Host
+		inv.Tunnels = tunnels
+	}
+	if scanner, err := vcomcaster.ReadScanner(vcomcaster.ConfigPath(a.Cfg.RootPath)); err == nil {
+		inv.Scanners = append(inv.Scanners, scanner)
+	}
+	return inv
+}
+
+// cmdReport отправляет инвентаризацию на сервер: goMH report [--json].
+// С --json отчет только выводится на экран.
+func (a *App) cmdReport(args []string) int {
+	params, err := parseParams(args)
+	if err != nil {
+		tui.Error(err.Error())
+		return exitUsage
+	}
+
+	if params["json"] != "" {
+		data, err := json.MarshalIndent(a.collectInventory("report"), "", "  ")
+		if err != nil {
+			tui.Error(fmt.Sprintf("Не удалось сформировать JSON: %v", err))
+			return exitError
+		}
+		fmt.Println(tui.Mask(string(data)))
+		return exitOK
+	}
+
+	client := reporting.New(a.Cfg.Reporting, a.Cfg.RootPath)
+	if !client.Enabled() {
+		tui.Error("Отправка отчетов не настроена: укажите reporting.url в конфигурации.")
+		return exitError
+	}
+	if err := a.sendReport(client, "report", client.Flush); err != nil {
+		return exitError
+	}
+	return exitOK
+}
+
+// reportAfterRun отправляет инвентаризацию после запуска модуля, если отчеты включены.
+// Каждый отчет отправляется одной попыткой, чтобы недоступный сервер не задерживал
+// работу; неотправленное остается в outbox. Ошибка отправки не влияет на результат запуска.
+func (a *App) reportAfterRun(moduleID string) {
+	if a.Plan != nil {
+		return
+	}
+	client := reporting.New(a.Cfg.Reporting, a.Cfg.RootPath)
+	if client.Enabled() {
+		_ = a.sendReport(client, "run:"+moduleID, client.FlushOnce)
+	}
+}
+
+// sendReport кладет отчет в outbox и отправляет все неотправленные отчеты функцией flush.
+func (a *App) sendReport(client *reporting.Client, trigger string, flush func(context.Context) (int, error)) error {
+	tui.Info("Отправка отчета об инвентаризации...")
+	if err := client.Queue(a.collectInventory(trigger)); err != nil {
+		tui.Warn(fmt.Sprintf("Не удалось сохранить отчет: %v", err))
+		return err
+	}
+
+	ctx, done := a.operationContext()
+	defer done()
+	sent, err := flush(ctx)
+	if err != nil {
+		pending, _ := client.Pending()
+		tui.Warn(fmt.Sprintf("Отчет не отправлен: %v. Неотправленных отчетов в outbox: %d, они будут отправлены позже.", err, len(pending)))
+		return err
+	}
+	tui.SuccessF("Отчет отправлен (отчетов доставлено: %d).", sent)
+	return nil
+}
@@ -0,0 +1,204 @@
+// Package reporting отправляет инвентаризацию машины на центральный сервер
+// (раздел reporting конфигурации).
+//
+// Отчет сначала сохраняется в outbox (root_path/outbox), затем все отчеты из outbox
+// отправляются POST-запросом от старых к новым. Если сервер недоступен, отчеты
+// остаются в outbox и досылаются при следующей отправке.
+package reporting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"goMH/config"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OutboxDir - директория неотправленных отчетов в root_path.
+const OutboxDir = "outbox"
+
+const (
+	defaultRetries     = 3
+	defaultOutboxLimit = 20
+	retryDelay         = 2 * time.Second
+)
+
+// Client отправляет отчеты на адрес из конфигурации.
+type Client struct {
+	cfg    config.ReportingConfig
+	outbox string
+	http   *http.Client
+}
+
+// New создает клиента. Отчеты хранятся в rootPath/outbox.
+func New(cfg config.ReportingConfig, rootPath string) *Client {
+	if cfg.Retries == 0 {
+		cfg.Retries = defaultRetries
+	}
+	if cfg.OutboxLimit == 0 {
+		cfg.OutboxLimit = defaultOutboxLimit
+	}
+	return &Client{
+		cfg:    cfg,
+		outbox: filepath.Join(rootPath, OutboxDir),
+		http:   &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Enabled сообщает, задан ли адрес для отчетов.
+func (c *Client) Enabled() bool {
+	return c.cfg.URL != ""
+}
+
+// Queue сохраняет отчет в outbox. Если отчетов больше OutboxLimit, самые старые удаляются.
+func (c *Client) Queue(report interface{}) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.outbox, 0755); err != nil {
+		return fmt.Errorf("не удалось создать %s: %w", c.outbox, err)
+	}
+	name := "report_" + time.Now().Format("20060102_150405.000000000") + ".json"
+	path := filepath.Join(c.outbox, name)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("не удалось сохранить отчет: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		os.Remove(path + ".tmp")
+		return fmt.Errorf("не удалось сохранить отчет: %w", err)
+	}
+
+	pending, err := c.Pending()
+	if err != nil {
+		return nil
+	}
+	for len(pending) > c.cfg.OutboxLimit {
+		os.Remove(pending[0])
+		pending = pending[1:]
+	}
+	return nil
+}
+
+// Pending возвращает неотправленные отчеты от старых к новым.
+func (c *Client) Pending() ([]string, error) {
+	entries, err := os.ReadDir(c.outbox)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), "report_") && strings.HasSuffix(entry.Name(), ".json") {
+			files = append(files, filepath.Join(c.outbox, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Flush отправляет отчеты из outbox и удаляет доставленные. Отправка останавливается
+// на первом отчете, который не удалось доставить. Отчеты, отклоненные сервером как
+// некорректные (4xx, кроме 401, 403, 408 и 429), удаляются: повтор их не исправит,
+// и отправка продолжается со следующего отчета.
+func (c *Client) Flush(ctx context.Context) (sent int, err error) {
+	return c.flush(ctx, c.cfg.Retries)
+}
+
+// FlushOnce - то же, что Flush, но каждый отчет отправляется одной попыткой без пауз.
+// Используется после запуска модуля, чтобы недоступный сервер не задерживал работу:
+// недоставленные отчеты остаются в outbox до следующей отправки.
+func (c *Client) FlushOnce(ctx context.Context) (sent int, err error) {
+	return c.flush(ctx, 0)
+}
+
+func (c *Client) flush(ctx context.Context, retries int) (sent int, err error) {
+	pending, err := c.Pending()
+	if err != nil {
+		return 0, fmt.Errorf("не удалось прочитать outbox: %w", err)
+	}
+	var rejected []error
+	for _, path := range pending {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return sent, err
+		}
+		err = c.post(ctx, data, retries)
+		if r, ok := err.(*rejectedError); ok {
+			os.Remove(path)
+			rejected = append(rejected, fmt.Errorf("отчет %s удален из outbox: %w", filepath.Base(path), r))
+			continue
+		}
+		if err != nil {
+			return sent, err
+		}
+		os.Remove(path)
+		sent++
+	}
+	return sent, errors.Join(rejected...)
+}
+
+// rejectedError - сервер отклонил отчет, повторная отправка не поможет.
+type rejectedError struct {
+	status string
+}
+
+func (e *rejectedError) Error() string {
+	return "сервер отклонил отчет: " + e.status
+}
+
+// post отправляет один отчет, повторяя попытку (до retries раз) при ошибке сети
+// и ответах 5xx, 408 и 429.
+func (c *Client) post(ctx context.Context, data []byte, retries int) error {
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(retryDelay * time.Duration(1<<(attempt-1))):
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.URL, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if c.cfg.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+		}
+		resp, err := c.http.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			lastErr = fmt.Errorf("сервер отчетов недоступен: %w", err)
+			continue
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		switch code := resp.StatusCode; {
+		case code >= 200 && code < 300:
+			return nil
+		case code == http.StatusUnauthorized || code == http.StatusForbidden:
+			return fmt.Errorf("сервер отчетов отказал в доступе (%s), проверьте reporting.token", resp.Status)
+		case code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= 500:
+			lastErr = fmt.Errorf("сервер отчетов вернул ошибку: %s", resp.Status)
+		default:
+			return &rejectedError{status: resp.Status}
+		}
+	}
+	return lastErr
+}
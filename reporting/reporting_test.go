@@ -0,0 +1,69 @@
+package reporting
+
+import (
+	"context"
+	"goMH/config"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFlushSkipsRejectedReport(t *testing.T) {
+	var delivered []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "bad") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		delivered = append(delivered, string(body))
+	}))
+	defer srv.Close()
+
+	client := New(config.ReportingConfig{URL: srv.URL}, t.TempDir())
+	for _, report := range []string{"bad", "good"} {
+		if err := client.Queue(map[string]string{"report": report}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sent, err := client.Flush(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("Flush: err = %v, want ошибку об отклоненном отчете", err)
+	}
+	if sent != 1 || len(delivered) != 1 || !strings.Contains(delivered[0], "good") {
+		t.Errorf("доставлено %d: %v, want только отчет good", sent, delivered)
+	}
+	if pending, _ := client.Pending(); len(pending) != 0 {
+		t.Errorf("в outbox осталось %v, want пусто", pending)
+	}
+}
+
+func TestFlushOnceDoesNotRetry(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := New(config.ReportingConfig{URL: srv.URL}, t.TempDir())
+	for i := 0; i < 2; i++ {
+		if err := client.Queue(map[string]int{"n": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := client.FlushOnce(context.Background()); err == nil {
+		t.Error("FlushOnce должен вернуть ошибку недоступного сервера")
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("запросов %d, want 1 (без повторов, остальные отчеты ждут в outbox)", n)
+	}
+	if pending, _ := client.Pending(); len(pending) != 2 {
+		t.Errorf("в outbox %d отчетов, want 2", len(pending))
+	}
+}
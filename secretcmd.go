@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"goMH/secrets"
+	"goMH/tui"
+	"os"
+	"path/filepath"
+)
+
+// cmdSecret управляет хранилищем секретов: goMH secret set|list|rm.
+func (a *App) cmdSecret(args []string) int {
+	if len(args) == 0 {
+		tui.Error("Укажите действие: goMH secret set <имя> | goMH secret list | goMH secret rm <имя>")
+		return exitUsage
+	}
+	vaultPath := filepath.Join(a.Cfg.RootPath, secrets.VaultFileName)
+
+	switch args[0] {
+	case "set", "rm":
+		if len(args) != 2 {
+			tui.Error(fmt.Sprintf("Укажите имя секрета: goMH secret %s <имя>", args[0]))
+			return exitUsage
+		}
+	case "list":
+	default:
+		tui.Error(fmt.Sprintf("Неизвестное действие: %s", args[0]))
+		return exitUsage
+	}
+
+	vault, err := openVaultForEdit(vaultPath)
+	if err != nil {
+		tui.Error(err.Error())
+		return exitError
+	}
+
+	switch args[0] {
+	case "list":
+		names := vault.Names()
+		if len(names) == 0 {
+			tui.Info("Хранилище секретов пусто.")
+			return exitOK
+		}
+		for _, name := range names {
+			fmt.Printf(" %-24s %s\n", name, secrets.RefPrefix+name)
+		}
+		return exitOK
+	case "set":
+		value, err := tui.ReadSecret(fmt.Sprintf("Значение секрета '%s'", args[1]))
+		if err != nil {
+			tui.Error(err.Error())
+			return exitError
+		}
+		if value == "" {
+			tui.Error("Пустое значение, секрет не изменен.")
+			return exitError
+		}
+		vault.Set(args[1], value)
+	case "rm":
+		if !vault.Delete(args[1]) {
+			tui.Warn(fmt.Sprintf("Секрета '%s' нет в хранилище.", args[1]))
+			return exitError
+		}
+	}
+
+	if err := vault.Save(); err != nil {
+		tui.Error(err.Error())
+		return exitError
+	}
+	tui.SuccessF("Хранилище секретов сохранено: %s", vaultPath)
+	return exitOK
+}
+
+// openVaultForEdit открывает хранилище. Для нового хранилища пароль запрашивается дважды.
+func openVaultForEdit(path string) (*secrets.Vault, error) {
+	if secrets.VaultExists(path) {
+		passphrase, err := vaultPassphrase()
+		if err != nil {
+			return nil, err
+		}
+		return secrets.OpenVault(path, passphrase)
+	}
+
+	tui.InfoF("Хранилище %s не найдено и будет создано.", path)
+	passphrase := os.Getenv(secrets.EnvPassphrase)
+	if passphrase == "" {
+		var err error
+		if passphrase, err = tui.ReadSecret("Новый пароль хранилища"); err != nil {
+			return nil, err
+		}
+		confirm, err := tui.ReadSecret("Повторите пароль")
+		if err != nil {
+			return nil, err
+		}
+		if passphrase != confirm {
+			return nil, errors.New("пароли не совпадают")
+		}
+	}
+	if passphrase == "" {
+		return nil, errors.New("пароль хранилища не может быть пустым")
+	}
+	return secrets.OpenVault(path, passphrase)
+}
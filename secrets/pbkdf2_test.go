@@ -0,0 +1,36 @@
+package secrets
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// Тестовые векторы PBKDF2-HMAC-SHA256 из RFC 7914, раздел 11.
+func TestPBKDF2SHA256(t *testing.T) {
+	tests := []struct {
+		password, salt string
+		iterations     int
+		want           string
+	}{
+		{"passwd", "salt", 1,
+			"55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc" +
+				"49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{"Password", "NaCl", 80000,
+			"4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56" +
+				"a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d"},
+	}
+	for _, tt := range tests {
+		got := hex.EncodeToString(pbkdf2SHA256([]byte(tt.password), []byte(tt.salt), tt.iterations, 64))
+		if got != tt.want {
+			t.Errorf("pbkdf2SHA256(%q, %q, %d) = %s, want %s", tt.password, tt.salt, tt.iterations, got, tt.want)
+		}
+	}
+}
+
+func TestPBKDF2SHA256KeyLength(t *testing.T) {
+	// Длина ключа не кратна размеру хеша: берется начало последнего блока
+	full := pbkdf2SHA256([]byte("passwd"), []byte("salt"), 1, 64)
+	if got := pbkdf2SHA256([]byte("passwd"), []byte("salt"), 1, 40); hex.EncodeToString(got) != hex.EncodeToString(full[:40]) {
+		t.Errorf("ключ длиной 40 байт = %x, want %x", got, full[:40])
+	}
+}
@@ -0,0 +1,102 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// RefPrefix - префикс значения конфигурации, которое нужно взять из секретов.
+const RefPrefix = "secret:"
+
+// Переменные окружения: значение секрета, пароль хранилища для неинтерактивного запуска
+// и токен локального API (goMH serve).
+const (
+	EnvPrefix     = "GOMH_SECRET_"
+	EnvPassphrase = "GOMH_VAULT_PASSPHRASE"
+	EnvAPIToken   = "GOMH_API_TOKEN"
+)
+
+// IsRef сообщает, является ли значение ссылкой на секрет.
+func IsRef(value string) bool {
+	return strings.HasPrefix(value, RefPrefix)
+}
+
+// EnvName возвращает имя переменной окружения для секрета, например GOMH_SECRET_FRPS_ADMIN.
+func EnvName(name string) string {
+	return EnvPrefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
+
+// IsEnvName сообщает, относится ли переменная окружения к секретам, а не к конфигурации.
+func IsEnvName(name string) bool {
+	name = strings.ToUpper(name)
+	return strings.HasPrefix(name, EnvPrefix) || name == EnvPassphrase || name == EnvAPIToken
+}
+
+// Resolver получает значения секретов. Хранилище открывается только при первом
+// обращении к секрету, которого нет в окружении, поэтому без ссылок на хранилище
+// пароль не спрашивается.
+type Resolver struct {
+	// VaultPath - путь к файлу хранилища.
+	VaultPath string
+	// Passphrase возвращает пароль хранилища (из окружения или от пользователя).
+	Passphrase func() (string, error)
+
+	vault *Vault
+	// vaultErr - ошибка открытия хранилища: неверный пароль спрашивается один раз,
+	// а не для каждого секрета.
+	vaultErr error
+	values   []string
+}
+
+// Resolve возвращает значение секрета по имени.
+func (r *Resolver) Resolve(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("пустое имя секрета")
+	}
+	if value, ok := os.LookupEnv(EnvName(name)); ok {
+		r.remember(value)
+		return value, nil
+	}
+
+	if r.vault == nil {
+		if !VaultExists(r.VaultPath) {
+			return "", fmt.Errorf("секрет '%s' не найден: нет переменной %s и хранилища %s", name, EnvName(name), r.VaultPath)
+		}
+		if r.vaultErr == nil {
+			r.vault, r.vaultErr = r.openVault()
+		}
+		if r.vaultErr != nil {
+			return "", fmt.Errorf("секрет '%s': %w", name, r.vaultErr)
+		}
+	}
+
+	value, ok := r.vault.Get(name)
+	if !ok {
+		return "", fmt.Errorf("секрет '%s' не найден ни в %s, ни в хранилище", name, EnvName(name))
+	}
+	r.remember(value)
+	return value, nil
+}
+
+func (r *Resolver) openVault() (*Vault, error) {
+	if r.Passphrase == nil {
+		return nil, fmt.Errorf("не задан пароль хранилища (%s)", EnvPassphrase)
+	}
+	passphrase, err := r.Passphrase()
+	if err != nil {
+		return nil, err
+	}
+	return OpenVault(r.VaultPath, passphrase)
+}
+
+// Values возвращает все полученные значения, чтобы их можно было маскировать в выводе и журналах.
+func (r *Resolver) Values() []string {
+	return r.values
+}
+
+func (r *Resolver) remember(value string) {
+	if value != "" {
+		r.values = append(r.values, value)
+	}
+}
@@ -0,0 +1,57 @@
+package secrets
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolverAsksPassphraseOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), VaultFileName)
+	v, _ := OpenVault(path, "correct horse")
+	v.Set("ftp", "ftp-secret")
+	if err := v.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	asked := 0
+	r := &Resolver{VaultPath: path, Passphrase: func() (string, error) {
+		asked++
+		return "wrong horse", nil
+	}}
+	for _, name := range []string{"ftp", "frps_admin"} {
+		if _, err := r.Resolve(name); !errors.Is(err, ErrWrongPassphrase) {
+			t.Errorf("Resolve(%s): err = %v, want ErrWrongPassphrase", name, err)
+		}
+	}
+	if asked != 1 {
+		t.Errorf("пароль хранилища спрошен %d раз, want 1", asked)
+	}
+}
+
+func TestResolverPrefersEnvironment(t *testing.T) {
+	t.Setenv(EnvName("frps_admin"), "from-env")
+	r := &Resolver{VaultPath: filepath.Join(t.TempDir(), VaultFileName)}
+	if value, err := r.Resolve("frps_admin"); err != nil || value != "from-env" {
+		t.Errorf("Resolve = %q, %v; want from-env, nil", value, err)
+	}
+	if _, err := r.Resolve("ftp"); err == nil {
+		t.Error("Resolve без переменной и хранилища должен вернуть ошибку")
+	}
+}
+
+func TestIsEnvName(t *testing.T) {
+	tests := map[string]bool{
+		"GOMH_SECRET_FTP":       true,
+		"gomh_secret_ftp":       true,
+		"GOMH_VAULT_PASSPHRASE": true,
+		"GOMH_API_TOKEN":        true,
+		"GOMH_ROOT_PATH":        false,
+		"GOMH_FTP_CONFIG__PASS": false,
+	}
+	for name, want := range tests {
+		if got := IsEnvName(name); got != want {
+			t.Errorf("IsEnvName(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
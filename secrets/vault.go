@@ -0,0 +1,168 @@
+// Package secrets хранит пароли и токены, на которые ссылается конфигурация
+// ("pass": "secret:frps_admin"), вне config.json и исходного кода.
+//
+// Значение ищется сначала в переменной окружения GOMH_SECRET_<ИМЯ>, затем
+// в зашифрованном хранилище (файл secrets.vault в root_path). Хранилище шифруется
+// AES-256-GCM ключом, полученным из пароля хранилища через PBKDF2-SHA256.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// VaultFileName - имя файла хранилища в root_path.
+const VaultFileName = "secrets.vault"
+
+const (
+	vaultVersion    = 1
+	kdfIterations   = 600000
+	saltSize        = 16
+	aesKeySize      = 32
+	vaultPermission = 0600
+)
+
+// ErrWrongPassphrase возвращается, если хранилище не удалось расшифровать.
+var ErrWrongPassphrase = errors.New("неверный пароль хранилища или файл поврежден")
+
+// vaultFile - формат файла хранилища на диске.
+type vaultFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// Vault - расшифрованное хранилище секретов.
+type Vault struct {
+	path       string
+	passphrase string
+	values     map[string]string
+}
+
+// OpenVault расшифровывает хранилище. Если файла нет, возвращается пустое
+// хранилище, которое будет создано при первом Save.
+func OpenVault(path, passphrase string) (*Vault, error) {
+	v := &Vault{path: path, passphrase: passphrase, values: make(map[string]string)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return v, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать хранилище секретов: %w", err)
+	}
+
+	var file vaultFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("хранилище секретов %s повреждено: %w", path, err)
+	}
+	if file.Version != vaultVersion || file.KDF != "pbkdf2-sha256" {
+		return nil, fmt.Errorf("неподдерживаемый формат хранилища секретов (версия %d, %s)", file.Version, file.KDF)
+	}
+
+	gcm, err := newGCM(passphrase, file.Salt, file.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	if err := json.Unmarshal(plain, &v.values); err != nil {
+		return nil, fmt.Errorf("хранилище секретов повреждено: %w", err)
+	}
+	return v, nil
+}
+
+// VaultExists сообщает, создан ли файл хранилища.
+func VaultExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Get возвращает секрет по имени.
+func (v *Vault) Get(name string) (string, bool) {
+	value, ok := v.values[name]
+	return value, ok
+}
+
+// Set добавляет или заменяет секрет. Изменения сохраняются вызовом Save.
+func (v *Vault) Set(name, value string) {
+	v.values[name] = value
+}
+
+// Delete удаляет секрет и сообщает, был ли он в хранилище.
+func (v *Vault) Delete(name string) bool {
+	_, ok := v.values[name]
+	delete(v.values, name)
+	return ok
+}
+
+// Names возвращает отсортированные имена секретов.
+func (v *Vault) Names() []string {
+	names := make([]string, 0, len(v.values))
+	for name := range v.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Save шифрует хранилище с новой солью и записывает его на диск через временный файл.
+func (v *Vault) Save() error {
+	plain, err := json.Marshal(v.values)
+	if err != nil {
+		return err
+	}
+	file := vaultFile{Version: vaultVersion, KDF: "pbkdf2-sha256", Iterations: kdfIterations, Salt: make([]byte, saltSize)}
+	if _, err := rand.Read(file.Salt); err != nil {
+		return err
+	}
+	gcm, err := newGCM(v.passphrase, file.Salt, file.Iterations)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return err
+	}
+	file.Data = gcm.Seal(nil, file.Nonce, plain, nil)
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(v.path), 0755); err != nil {
+		return err
+	}
+	tmp := v.path + ".tmp"
+	if err := os.WriteFile(tmp, data, vaultPermission); err != nil {
+		return fmt.Errorf("не удалось записать хранилище секретов: %w", err)
+	}
+	if err := os.Rename(tmp, v.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("не удалось записать хранилище секретов: %w", err)
+	}
+	return nil
+}
+
+func newGCM(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if iterations <= 0 || len(salt) == 0 {
+		return nil, errors.New("хранилище секретов повреждено: нет параметров ключа")
+	}
+	key := pbkdf2SHA256([]byte(passphrase), salt, iterations, aesKeySize)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
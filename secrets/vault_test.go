@@ -0,0 +1,45 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVaultRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), VaultFileName)
+	v, err := OpenVault(path, "correct horse")
+	if err != nil {
+		t.Fatalf("OpenVault нового хранилища: %v", err)
+	}
+	v.Set("frps_admin", "Passw0rd!")
+	v.Set("ftp", "ftp-secret")
+	if err := v.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "Passw0rd!") {
+		t.Error("секрет записан в файл хранилища открытым текстом")
+	}
+
+	reopened, err := OpenVault(path, "correct horse")
+	if err != nil {
+		t.Fatalf("OpenVault: %v", err)
+	}
+	if value, ok := reopened.Get("frps_admin"); !ok || value != "Passw0rd!" {
+		t.Errorf("Get(frps_admin) = %q, %v; want Passw0rd!, true", value, ok)
+	}
+	if names := strings.Join(reopened.Names(), ","); names != "frps_admin,ftp" {
+		t.Errorf("Names() = %s, want frps_admin,ftp", names)
+	}
+
+	if _, err := OpenVault(path, "wrong horse"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("OpenVault с неверным паролем: err = %v, want ErrWrongPassphrase", err)
+	}
+}
@@ -0,0 +1,188 @@
+// Package selfupdate проверяет канал релизов и безопасно заменяет исполняемый файл goMH.
+//
+// Канал релизов - это JSON-манифест по URL из конфигурации (update.manifest_url):
+//
+//	{
+//	  "version": "1.4.0",
+//	  "url": "https://f.serty.top/distr/installer/goMH.exe",
+//	  "sha256": "<hex SHA-256 файла>",
+//	  "signature": "<base64 ed25519-подпись строки \"version\\nsha256\">",
+//	  "notes": "Что нового"
+//	}
+//
+// Подпись связывает версию с хешем файла, поэтому подменить файл или выдать старую
+// сборку за новую без закрытого ключа нельзя.
+package selfupdate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"goMH/trust"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Manifest описывает последнюю доступную сборку.
+type Manifest struct {
+	Version   string `json:"version"`
+	URL       string `json:"url"`
+	SHA256    string `json:"sha256"`
+	Signature string `json:"signature"`
+	Notes     string `json:"notes,omitempty"`
+}
+
+// SignedMessage возвращает данные, которые подписываются ключом релизов.
+func (m *Manifest) SignedMessage() []byte {
+	return []byte(m.Version + "\n" + strings.ToLower(m.SHA256))
+}
+
+// FetchManifest загружает и проверяет манифест: обязательные поля и подпись.
+func FetchManifest(manifestURL string) (*Manifest, error) {
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Get(manifestURL)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось загрузить манифест обновлений: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("сервер обновлений вернул ошибку: %s", resp.Status)
+	}
+
+	var m Manifest
+	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга манифеста обновлений: %w", err)
+	}
+	if m.Version == "" || m.URL == "" || m.SHA256 == "" || m.Signature == "" {
+		return nil, fmt.Errorf("в манифесте обновлений не заполнены version, url, sha256 или signature")
+	}
+	if err := trust.Verify(m.SignedMessage(), m.Signature); err != nil {
+		return nil, fmt.Errorf("манифест обновлений не прошел проверку подписи: %w", err)
+	}
+	return &m, nil
+}
+
+// VerifyFile сверяет SHA-256 скачанного файла с манифестом.
+func VerifyFile(path string, m *Manifest) error {
+	sum, err := trust.FileSHA256(path)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(sum, m.SHA256) {
+		return fmt.Errorf("хеш файла %s не совпадает с манифестом (ожидался %s, получен %s)", path, m.SHA256, sum)
+	}
+	return nil
+}
+
+// IsNewer сообщает, новее ли версия candidate, чем current. Версии сравниваются
+// по числовым частям ("1.10.0" новее "1.9.2"); сборка "dev" старее любой версии.
+func IsNewer(candidate, current string) bool {
+	if current == "dev" || current == "" {
+		return candidate != "dev" && candidate != ""
+	}
+	a := versionParts(candidate)
+	b := versionParts(current)
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	return false
+}
+
+func versionParts(version string) []int {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	var parts []int
+	for _, part := range strings.Split(version, ".") {
+		// Суффиксы вида "1.4.0-rc1" не учитываются
+		if idx := strings.IndexAny(part, "-+"); idx >= 0 {
+			part = part[:idx]
+		}
+		n, _ := strconv.Atoi(part)
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// Apply заменяет исполняемый файл exePath новой сборкой newPath.
+// Запущенный exe в Windows удалить нельзя, но можно переименовать: старый файл
+// становится exePath+".old" и удаляется при следующем запуске (см. CleanupOld).
+// При ошибке на любом шаге старый файл возвращается на место.
+func Apply(exePath, newPath string) error {
+	staged := exePath + ".new"
+	oldPath := exePath + ".old"
+
+	// Копируем рядом с exe: кэш и флешка с программой могут быть на разных дисках,
+	// а переименование работает только в пределах одного тома.
+	if err := copyFile(newPath, staged); err != nil {
+		return fmt.Errorf("не удалось подготовить новую версию: %w", err)
+	}
+	_ = os.Remove(oldPath)
+	if err := os.Rename(exePath, oldPath); err != nil {
+		os.Remove(staged)
+		return fmt.Errorf("не удалось переименовать текущий исполняемый файл: %w", err)
+	}
+	if err := os.Rename(staged, exePath); err != nil {
+		_ = os.Rename(oldPath, exePath)
+		os.Remove(staged)
+		return fmt.Errorf("не удалось установить новую версию: %w", err)
+	}
+	return nil
+}
+
+// CleanupOld удаляет файл, оставшийся от предыдущего обновления.
+func CleanupOld(exePath string) {
+	_ = os.Remove(exePath + ".old")
+}
+
+// Restart запускает exePath с теми же аргументами в текущей консоли,
+// дожидается завершения и возвращает его код выхода.
+func Restart(exePath string, args []string) (int, error) {
+	cmd := exec.Command(exePath, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Dir, _ = os.Getwd()
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), nil
+		}
+		return 1, fmt.Errorf("не удалось запустить новую версию: %w", err)
+	}
+	return 0, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,163 @@
+package selfupdate
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"goMH/trust"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testKey подменяет встроенный ключ релизов ключом теста и возвращает seed для trust.Sign.
+func testKey(t *testing.T) string {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(trust.SetPublicKey(pub))
+	return base64.StdEncoding.EncodeToString(priv.Seed())
+}
+
+// releaseServer отдает манифест по /manifest.json и сборку по /goMH.exe.
+func releaseServer(t *testing.T, manifest *Manifest, binary []byte) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/manifest.json", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(manifest)
+	})
+	mux.HandleFunc("/goMH.exe", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(binary)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func signedManifest(t *testing.T, seed, version string, binary []byte) *Manifest {
+	t.Helper()
+	sum := sha256.Sum256(binary)
+	m := &Manifest{Version: version, SHA256: hex.EncodeToString(sum[:])}
+	sig, err := trust.Sign(m.SignedMessage(), seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Signature = sig
+	return m
+}
+
+// download скачивает сборку из манифеста во временную директорию.
+func download(t *testing.T, m *Manifest) string {
+	t.Helper()
+	resp, err := http.Get(m.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "goMH.exe")
+	if err := os.WriteFile(path, data, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUpdateWithValidSignature(t *testing.T) {
+	seed := testKey(t)
+	binary := []byte("goMH 1.10.0")
+	manifest := signedManifest(t, seed, "1.10.0", binary)
+	srv := releaseServer(t, manifest, binary)
+	manifest.URL = srv.URL + "/goMH.exe"
+
+	m, err := FetchManifest(srv.URL + "/manifest.json")
+	if err != nil {
+		t.Fatalf("FetchManifest: %v", err)
+	}
+	if !IsNewer(m.Version, "1.9.0") {
+		t.Fatalf("версия %s должна быть новее 1.9.0", m.Version)
+	}
+	newPath := download(t, m)
+	if err := VerifyFile(newPath, m); err != nil {
+		t.Fatalf("VerifyFile: %v", err)
+	}
+
+	exePath := filepath.Join(t.TempDir(), "goMH.exe")
+	if err := os.WriteFile(exePath, []byte("goMH 1.9.0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Apply(exePath, newPath); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if data, _ := os.ReadFile(exePath); string(data) != string(binary) {
+		t.Errorf("после Apply exe содержит %q, want %q", data, binary)
+	}
+	if data, _ := os.ReadFile(exePath + ".old"); string(data) != "goMH 1.9.0" {
+		t.Errorf("прежняя сборка не сохранена в .old: %q", data)
+	}
+	CleanupOld(exePath)
+	if _, err := os.Stat(exePath + ".old"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("CleanupOld не удалил .old: %v", err)
+	}
+}
+
+func TestFetchManifestRejectsBadSignature(t *testing.T) {
+	seed := testKey(t)
+	binary := []byte("goMH 1.10.0")
+	manifest := signedManifest(t, seed, "1.10.0", binary)
+	manifest.Version = "2.0.0" // подпись была сделана для 1.10.0
+	srv := releaseServer(t, manifest, binary)
+	manifest.URL = srv.URL + "/goMH.exe"
+
+	if _, err := FetchManifest(srv.URL + "/manifest.json"); !errors.Is(err, trust.ErrBadSignature) {
+		t.Errorf("FetchManifest: err = %v, want ErrBadSignature", err)
+	}
+}
+
+func TestVerifyFileRejectsHashMismatch(t *testing.T) {
+	seed := testKey(t)
+	manifest := signedManifest(t, seed, "1.10.0", []byte("goMH 1.10.0"))
+	srv := releaseServer(t, manifest, []byte("подмененная сборка"))
+	manifest.URL = srv.URL + "/goMH.exe"
+
+	m, err := FetchManifest(srv.URL + "/manifest.json")
+	if err != nil {
+		t.Fatalf("FetchManifest: %v", err)
+	}
+	if err := VerifyFile(download(t, m), m); err == nil {
+		t.Error("VerifyFile должен отклонить файл с другим хешем")
+	}
+}
+
+func TestIsNewer(t *testing.T) {
+	tests := []struct {
+		candidate, current string
+		want               bool
+	}{
+		{"1.10.0", "1.9.0", true},
+		{"1.9.0", "1.10.0", false},
+		{"1.4.0", "1.4.0", false},
+		{"1.4.1", "1.4", true},
+		{"1.4", "1.4.0", false},
+		{"v2.0.0", "1.99.99", true},
+		{"1.5.0-rc1", "1.4.9", true},
+		{"1.0.0", "dev", true},
+		{"dev", "dev", false},
+		{"dev", "1.0.0", false},
+	}
+	for _, tt := range tests {
+		if got := IsNewer(tt.candidate, tt.current); got != tt.want {
+			t.Errorf("IsNewer(%q, %q) = %v, want %v", tt.candidate, tt.current, got, tt.want)
+		}
+	}
+}
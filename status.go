@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"goMH/core"
+	"goMH/tui"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// moduleStatus - состояние одного модуля в отчете "goMH status".
+type moduleStatus struct {
+	ID      string       `json:"id"`
+	Name    string       `json:"name"`
+	State   core.State   `json:"state"`
+	Version string       `json:"version,omitempty"`
+	Details string       `json:"details,omitempty"`
+	Checks  []core.Check `json:"checks,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// machineStatus - сводка по всем управляемым компонентам машины.
+type machineStatus struct {
+	Hostname   string         `json:"hostname"`
+	Time       time.Time      `json:"time"`
+	Modules    []moduleStatus `json:"modules"`
+	CachePath  string         `json:"cache_path"`
+	CacheSize  int64          `json:"cache_size"`
+	CacheFiles int            `json:"cache_files"`
+}
+
+func (a *App) cmdStatus(args []string) int {
+	params, err := parseParams(args)
+	if err != nil {
+		tui.Error(err.Error())
+		return exitUsage
+	}
+
+	status := a.collectStatus()
+	if params["json"] != "" {
+		data, err := json.MarshalIndent(status, "", "  ")
+		if err != nil {
+			tui.Error(fmt.Sprintf("Не удалось сформировать JSON: %v", err))
+			return exitError
+		}
+		fmt.Println(string(data))
+		return exitOK
+	}
+	printStatus(status)
+	return exitOK
+}
+
+// collectStatus опрашивает все доступные модули. Модули с Lifecycle сообщают состояние
+// и версию, модули с Inspector - подробности (службы, процессы, туннели).
+func (a *App) collectStatus() machineStatus {
+	hostname, _ := os.Hostname()
+	status := machineStatus{Hostname: hostname, Time: time.Now(), CachePath: a.Cfg.AssetsCachePath}
+
+	for _, module := range a.availableModules() {
+		ms := moduleStatus{ID: module.ID(), Name: module.MenuText()}
+		lc, isLifecycle := module.(core.Lifecycle)
+		inspector, isInspector := module.(core.Inspector)
+		if !isLifecycle && !isInspector {
+			continue
+		}
+
+		if isLifecycle {
+			detected, err := lc.Detect(a.AM, a.WU)
+			if err != nil {
+				ms.Error = err.Error()
+			}
+			ms.State, ms.Version, ms.Details = detected.State, detected.Version, detected.Details
+		}
+		if isInspector && ms.State != core.StateNotInstalled {
+			checks, err := inspector.Inspect(a.AM, a.WU)
+			if err != nil && ms.Error == "" {
+				ms.Error = err.Error()
+			}
+			ms.Checks = checks
+		}
+		status.Modules = append(status.Modules, ms)
+	}
+
+	status.CacheSize, status.CacheFiles = dirSize(a.Cfg.AssetsCachePath)
+	return status
+}
+
+func printStatus(status machineStatus) {
+	tui.Title(fmt.Sprintf("\n=== Состояние машины %s (%s) ===", status.Hostname, status.Time.Format("02.01.2006 15:04")))
+	fmt.Printf("%-14s %-16s %-12s %s\n", "Модуль", "Состояние", "Версия", "Подробности")
+	for _, ms := range status.Modules {
+		color := tui.ColorYellow
+		switch ms.State {
+		case core.StateInstalled:
+			color = tui.ColorGreen
+		case core.StateNotInstalled:
+			color = tui.ColorRed
+		}
+		version := ms.Version
+		if version == "" {
+			version = "-"
+		}
+		fmt.Printf("%-14s %s%-16s%s %-12s %s\n", ms.ID, color, ms.State, tui.ColorReset, version, ms.Details)
+
+		for _, check := range ms.Checks {
+			mark := tui.ColorGreen + "[+]" + tui.ColorReset
+			if !check.OK {
+				mark = tui.ColorRed + "[-]" + tui.ColorReset
+			}
+			fmt.Printf("    %s %s: %s\n", mark, check.Name, check.Value)
+		}
+		if ms.Error != "" {
+			tui.Warn(fmt.Sprintf("    ошибка проверки: %s", firstLine(ms.Error)))
+		}
+	}
+	fmt.Println()
+	fmt.Printf("Кэш ассетов: %s - %s (файлов: %d)\n", status.CachePath, tui.FormatBytes(status.CacheSize), status.CacheFiles)
+}
+
+// dirSize возвращает суммарный размер и количество файлов в директории.
+func dirSize(dir string) (int64, int) {
+	var size int64
+	var count int
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+			count++
+		}
+		return nil
+	})
+	return size, count
+}
+
+func firstLine(s string) string {
+	if idx := strings.IndexByte(s, '\n'); idx >= 0 {
+		return s[:idx]
+	}
+	return s
+}
+
+// statusEntry - пункт главного меню со сводкой состояния машины.
+type statusEntry struct {
+	app *App
+}
+
+func (s *statusEntry) ID() string { return "Status" }
+func (s *statusEntry) MenuText() string {
+	return "Состояние машины (сводка по всем модулям)"
+}
+
+func (s *statusEntry) Run(ctx context.Context, am core.AssetManager, wu core.WinUtils, p core.Prompter) error {
+	printStatus(s.app.collectStatus())
+	return nil
+}
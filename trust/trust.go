@@ -0,0 +1,93 @@
+// Package trust проверяет подписи ed25519, которыми подписываются релизы goMH
+// и удаленная конфигурация. Открытый ключ встроен в программу, закрытый хранится
+// только у сопровождающих и в репозиторий не попадает. FileSHA256 считает хеши файлов,
+// которые сверяются с подписанными манифестами и каталогом ресурсов.
+//
+// Где лежит закрытый ключ: файл release.key (seed в base64), созданный gomh-sign keygen.
+// Он хранится офлайн у сопровождающего релизов (зашифрованный носитель и резервная копия
+// у второго сопровождающего), на серверы сборки и раздачи не копируется. Подпись
+// выполняется вручную: gomh-sign manifest и gomh-sign file.
+//
+// Смена ключа (плановая или при компрометации):
+//  1. gomh-sign keygen - новый release.key и открытый ключ.
+//  2. Собрать goMH с новым ключом в publicKeyBase64 и прежним в previousPublicKeyBase64;
+//     манифест этой сборки подписать прежним ключом - установленные копии проверяют
+//     обновление старым ключом.
+//  3. Когда все машины обновились (версия goMH приходит в отчетах, gomh_version), переподписать
+//     config.json и манифесты новым ключом.
+//  4. Следующей сборкой очистить previousPublicKeyBase64 и уничтожить прежний release.key.
+//
+// При компрометации прежний ключ в previousPublicKeyBase64 не указывается, а config.json
+// сразу переподписывается новым ключом: установленные копии перестанут принимать
+// конфигурацию и обновления, их обновляют вручную.
+package trust
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// publicKeyBase64 - открытый ключ подписи в base64. При сборке с другим ключом
+// его можно заменить: -ldflags "-X goMH/trust.publicKeyBase64=..."
+var publicKeyBase64 = "EUIqmE8dpb5p0G9HEpKibbuTNSH8jU/crQ96/2GSzG0="
+
+// previousPublicKeyBase64 - прежний ключ, подписи которым принимаются на время смены
+// ключа (см. описание пакета). Пустой вне смены ключа.
+var previousPublicKeyBase64 = ""
+
+// SetPublicKey заменяет встроенный открытый ключ и возвращает функцию, которая
+// восстанавливает прежний. Нужна тестам, которые подписывают данные своим ключом.
+func SetPublicKey(key ed25519.PublicKey) (restore func()) {
+	prev := publicKeyBase64
+	publicKeyBase64 = base64.StdEncoding.EncodeToString(key)
+	return func() { publicKeyBase64 = prev }
+}
+
+// ErrBadSignature возвращается, если подпись не совпадает с данными.
+var ErrBadSignature = errors.New("подпись недействительна")
+
+// PublicKey возвращает встроенный открытый ключ.
+func PublicKey() (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(publicKeyBase64)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("встроенный открытый ключ поврежден")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// Verify проверяет подпись signature (base64) для данных message встроенным ключом,
+// а во время смены ключа - и прежним.
+func Verify(message []byte, signature string) error {
+	key, err := PublicKey()
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("подпись имеет неверный формат")
+	}
+	if ed25519.Verify(key, message, sig) {
+		return nil
+	}
+	if previousPublicKeyBase64 != "" {
+		prev, err := base64.StdEncoding.DecodeString(previousPublicKeyBase64)
+		if err == nil && len(prev) == ed25519.PublicKeySize && ed25519.Verify(prev, message, sig) {
+			return nil
+		}
+	}
+	return ErrBadSignature
+}
+
+// Sign подписывает данные закрытым ключом, заданным seed'ом в base64.
+// Используется утилитой gomh-sign на машине сопровождающего.
+func Sign(message []byte, seedBase64 string) (string, error) {
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(seedBase64))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return "", fmt.Errorf("закрытый ключ имеет неверный формат")
+	}
+	sig := ed25519.Sign(ed25519.NewKeyFromSeed(seed), message)
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
@@ -1,7 +1,6 @@
 package tui
 
 import (
-	"bufio"
 	"fmt"
 	"goMH/core"
 	"os"
@@ -24,27 +23,53 @@ func clearScreen() {
 	}
 }
 
-func ShowMenu(modules []Installer) (Installer, error) {
-	reader := bufio.NewReader(os.Stdin)
+// StateLabel возвращает цветную метку состояния модуля для меню.
+func StateLabel(status core.Status) string {
+	switch status.State {
+	case core.StateInstalled:
+		text := "установлен"
+		if status.Version != "" {
+			text += " " + status.Version
+		}
+		return ColorGreen + "[" + text + "]" + ColorReset
+	case core.StateNotInstalled:
+		return ColorRed + "[не установлен]" + ColorReset
+	default:
+		return ""
+	}
+}
 
+// ShowMenu показывает главное меню. site - выбранная площадка для заголовка (может быть пустой),
+// state (может быть nil) возвращает метку состояния модуля.
+func ShowMenu(site string, modules []Installer, state func(Installer) string) (Installer, error) {
 	for {
 		clearScreen()
 		fmt.Println(ColorYellow + "==================================================" + ColorReset)
 		fmt.Println(ColorYellow + "      МЕНЮ УСТАНОВЩИКА MYHORECA (golang)          " + ColorReset)
+		if site != "" {
+			fmt.Println(ColorYellow + "      Площадка: " + site + ColorReset)
+		}
 		fmt.Println(ColorYellow + "==================================================" + ColorReset)
 		fmt.Println()
 
 		for i, mod := range modules {
 			// Используем стандартный fmt.Printf, но можем добавить цвет, если хотим
-			fmt.Printf(" %d. %s\n", i+1, mod.MenuText())
+			label := ""
+			if state != nil {
+				label = state(mod)
+			}
+			if label != "" {
+				fmt.Printf(" %d. %s %s\n", i+1, mod.MenuText(), label)
+			} else {
+				fmt.Printf(" %d. %s\n", i+1, mod.MenuText())
+			}
 		}
 		fmt.Println()
 		fmt.Println(" Q. Выход")
 		fmt.Println()
 		fmt.Print("Введите номер пункта и нажмите Enter: ")
 
-		choiceStr, _ := reader.ReadString('\n')
-		choiceStr = strings.TrimSpace(choiceStr)
+		choiceStr := readLine()
 
 		if strings.EqualFold(choiceStr, "q") {
 			return nil, fmt.Errorf("пользователь выбрал выход")
@@ -53,7 +78,7 @@ func ShowMenu(modules []Installer) (Installer, error) {
 		choiceInt, err := strconv.Atoi(choiceStr)
 		if err != nil || choiceInt < 1 || choiceInt > len(modules) {
 			Error("\nНеверный выбор. Нажмите Enter, чтобы попробовать снова.")
-			_, _ = reader.ReadString('\n') // Ожидаем нажатия Enter
+			readLine() // Ожидаем нажатия Enter
 			continue
 		}
 
@@ -0,0 +1,129 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// multiBarRefresh - как часто перерисовываются строки прогресса.
+const multiBarRefresh = 200 * time.Millisecond
+
+// MultiBar выводит несколько строк прогресса одновременно (по строке на задачу),
+// а сообщения - над ними. Завершенные строки остаются в выводе над активными.
+type MultiBar struct {
+	mu     sync.Mutex
+	out    io.Writer
+	lines  []*BarLine
+	drawn  int
+	last   time.Time
+	closed bool
+}
+
+// NewMultiBar создает индикатор, который пишет в stderr.
+func NewMultiBar() *MultiBar {
+	return &MultiBar{out: os.Stderr}
+}
+
+// BarLine - строка прогресса одной задачи.
+type BarLine struct {
+	parent  *MultiBar
+	name    string
+	total   int64
+	current int64
+}
+
+// Add добавляет строку прогресса.
+func (m *MultiBar) Add(name string) *BarLine {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	line := &BarLine{parent: m, name: name, total: -1}
+	m.lines = append(m.lines, line)
+	m.redraw(true)
+	return line
+}
+
+// Println выводит сообщение над строками прогресса.
+func (m *MultiBar) Println(msg string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clear()
+	fmt.Fprintln(m.out, Mask(strings.TrimRight(msg, "\n")))
+	m.redraw(true)
+}
+
+// Close убирает оставшиеся строки прогресса.
+func (m *MultiBar) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clear()
+	m.lines = nil
+	m.closed = true
+}
+
+// Reset задает размер задачи и уже выполненную часть (например, при докачке).
+func (l *BarLine) Reset(total, current int64) {
+	l.parent.mu.Lock()
+	defer l.parent.mu.Unlock()
+	l.total, l.current = total, current
+	l.parent.redraw(false)
+}
+
+// Write учитывает записанные байты: BarLine можно передать в io.Copy через io.MultiWriter.
+func (l *BarLine) Write(p []byte) (int, error) {
+	l.parent.mu.Lock()
+	defer l.parent.mu.Unlock()
+	l.current += int64(len(p))
+	l.parent.redraw(false)
+	return len(p), nil
+}
+
+// Done убирает строку из активных и печатает над ними итог задачи.
+func (l *BarLine) Done(result string) {
+	m := l.parent
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, line := range m.lines {
+		if line == l {
+			m.lines = append(m.lines[:i], m.lines[i+1:]...)
+			break
+		}
+	}
+	m.clear()
+	fmt.Fprintln(m.out, Mask(fmt.Sprintf("%-32s %s", l.name, result)))
+	m.redraw(true)
+}
+
+// clear стирает нарисованные строки прогресса (курсор возвращается к первой из них).
+func (m *MultiBar) clear() {
+	if m.drawn > 0 {
+		fmt.Fprintf(m.out, "\033[%dA\033[J", m.drawn)
+		m.drawn = 0
+	}
+}
+
+func (m *MultiBar) redraw(force bool) {
+	if m.closed || (!force && time.Since(m.last) < multiBarRefresh) {
+		return
+	}
+	m.last = time.Now()
+	m.clear()
+	for _, line := range m.lines {
+		fmt.Fprintln(m.out, line.render())
+	}
+	m.drawn = len(m.lines)
+}
+
+func (l *BarLine) render() string {
+	const width = 30
+	if l.total <= 0 {
+		return fmt.Sprintf("%-32s [%s] %s", l.name, strings.Repeat("?", width), FormatBytes(l.current))
+	}
+	done := int(min(l.current, l.total) * width / l.total)
+	return fmt.Sprintf("%-32s [%s%s] %3d%% %s / %s", l.name,
+		strings.Repeat("=", done), strings.Repeat(" ", width-done),
+		l.current*100/l.total, FormatBytes(l.current), FormatBytes(l.total))
+}
@@ -1,6 +1,11 @@
 package tui
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
 
 // Используем простые ANSI-коды, они хорошо работают в современных терминалах Windows.
 const (
@@ -12,9 +17,37 @@ const (
 	ColorCyan   = "\033[36m"
 )
 
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+)
+
+// AddSecret регистрирует значение (пароль, токен), которое заменяется на "***"
+// во всем выводе tui.
+func AddSecret(value string) {
+	if strings.TrimSpace(value) == "" {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	secrets = append(secrets, value)
+	// Длинные значения заменяем первыми, чтобы короткий секрет не разрезал длинный
+	sort.Slice(secrets, func(a, b int) bool { return len(secrets[a]) > len(secrets[b]) })
+}
+
+// Mask скрывает в строке зарегистрированные секреты.
+func Mask(s string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, "***")
+	}
+	return s
+}
+
 // Info выводит информационное сообщение (голубой цвет).
 func Info(msg string) {
-	fmt.Println(ColorCyan + msg + ColorReset)
+	fmt.Println(ColorCyan + Mask(msg) + ColorReset)
 }
 
 // InfoF форматирует и выводит информационное сообщение.
@@ -24,7 +57,7 @@ func InfoF(format string, a ...interface{}) {
 
 // Success выводит сообщение об успехе (зеленый цвет).
 func Success(msg string) {
-	fmt.Println(ColorGreen + msg + ColorReset)
+	fmt.Println(ColorGreen + Mask(msg) + ColorReset)
 }
 
 // SuccessF форматирует и выводит сообщение об успехе.
@@ -34,15 +67,29 @@ func SuccessF(format string, a ...interface{}) {
 
 // Warn выводит предупреждение (желтый цвет).
 func Warn(msg string) {
-	fmt.Println(ColorYellow + msg + ColorReset)
+	fmt.Println(ColorYellow + Mask(msg) + ColorReset)
 }
 
 // Error выводит сообщение об ошибке (красный цвет).
 func Error(msg string) {
-	fmt.Println(ColorRed + msg + ColorReset)
+	fmt.Println(ColorRed + Mask(msg) + ColorReset)
 }
 
 // Title выводит заголовок (синий цвет).
 func Title(msg string) {
-	fmt.Println(ColorBlue + msg + ColorReset)
+	fmt.Println(ColorBlue + Mask(msg) + ColorReset)
+}
+
+// FormatBytes переводит размер в байтах в читаемый вид.
+func FormatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d Б", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cБ", float64(size)/float64(div), []rune("КМГТ")[exp])
 }
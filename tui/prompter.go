@@ -0,0 +1,357 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"goMH/core"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Prompter - локальный псевдоним, чтобы модули и main могли писать tui.Prompter.
+type Prompter = core.Prompter
+
+// stdin - общий буферизованный читатель консоли. Несколько bufio.Reader поверх
+// os.Stdin теряют друг у друга прочитанные данные, поэтому читатель один.
+var stdin = bufio.NewReader(os.Stdin)
+
+// readLine читает строку из консоли без завершающих пробелов и переводов строки.
+func readLine() string {
+	line, _ := stdin.ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+// ReadSecret спрашивает пароль без отображения вводимых символов. Если ввод
+// не из консоли (перенаправлен), строка читается как есть.
+func ReadSecret(prompt string) (string, error) {
+	fmt.Print(prompt + ": ")
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return readLine(), nil
+	}
+	value, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return "", fmt.Errorf("не удалось прочитать пароль: %w", err)
+	}
+	return strings.TrimSpace(string(value)), nil
+}
+
+// --- Интерактивная реализация ---
+
+// TerminalPrompter задает вопросы пользователю в консоли.
+type TerminalPrompter struct{}
+
+// NewTerminalPrompter создает Prompter для работы с живым пользователем.
+func NewTerminalPrompter() *TerminalPrompter {
+	return &TerminalPrompter{}
+}
+
+func (t *TerminalPrompter) Choose(key, title string, options []core.Option) (int, error) {
+	for {
+		Title("\n--- " + title + " ---")
+		for i, opt := range options {
+			fmt.Printf(" %d. %s\n", i+1, opt.Label)
+		}
+		fmt.Println("\n 0. Назад")
+		fmt.Print("Введите номер пункта: ")
+
+		choiceStr := readLine()
+		if choiceStr == "0" {
+			return -1, core.ErrCancelled
+		}
+		choice, err := strconv.Atoi(choiceStr)
+		if err != nil || choice < 1 || choice > len(options) {
+			Error("Неверный выбор. Попробуйте снова.")
+			continue
+		}
+		return choice - 1, nil
+	}
+}
+
+func (t *TerminalPrompter) MultiChoose(key, title string, options []core.Option) ([]int, error) {
+	Title("\n--- " + title + " ---")
+	for i, opt := range options {
+		fmt.Printf(" %d. %s\n", i+1, opt.Label)
+	}
+	fmt.Print("Введите номера через запятую (напр., 1,3), 'all' для всех или Enter для пропуска: ")
+
+	choiceStr := readLine()
+	if strings.EqualFold(choiceStr, "all") {
+		return allIndexes(options), nil
+	}
+
+	var selected []int
+	for _, part := range splitList(choiceStr) {
+		idx, err := strconv.Atoi(part)
+		if err != nil || idx < 1 || idx > len(options) {
+			Warn(fmt.Sprintf("Некорректный номер '%s', пропускаем.", part))
+			continue
+		}
+		selected = append(selected, idx-1)
+	}
+	return selected, nil
+}
+
+func (t *TerminalPrompter) Confirm(key, question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	for {
+		fmt.Printf("%s (%s): ", question, hint)
+		answer := readLine()
+		if answer == "" {
+			return def, nil
+		}
+		if value, ok := parseYesNo(answer); ok {
+			return value, nil
+		}
+		Error("Пожалуйста, ответьте 'y' или 'n'.")
+	}
+}
+
+func (t *TerminalPrompter) Input(key, question, def string, validate func(string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Printf("%s [%s]: ", question, def)
+		} else {
+			fmt.Printf("%s: ", question)
+		}
+		answer := readLine()
+		if answer == "" {
+			answer = def
+		}
+		if validate != nil {
+			if err := validate(answer); err != nil {
+				Error(fmt.Sprintf("Некорректное значение: %v", err))
+				continue
+			}
+		}
+		return answer, nil
+	}
+}
+
+func (t *TerminalPrompter) Pause(message string) {
+	fmt.Println(message)
+	readLine()
+}
+
+func (t *TerminalPrompter) Interactive() bool { return true }
+
+// --- Сценарная реализация ---
+
+// ScriptedPrompter отвечает на вопросы из заранее заданного набора ответов
+// (параметры командной строки, файл ответов). Ключи сравниваются без учета регистра.
+type ScriptedPrompter struct {
+	answers map[string]string
+}
+
+// NewScriptedPrompter создает Prompter, который никогда не обращается к консоли.
+func NewScriptedPrompter(answers core.Params) *ScriptedPrompter {
+	normalized := make(map[string]string, len(answers))
+	for key, value := range answers {
+		normalized[strings.ToLower(key)] = strings.TrimSpace(value)
+	}
+	return &ScriptedPrompter{answers: normalized}
+}
+
+func (s *ScriptedPrompter) answer(key string) (string, bool) {
+	value, ok := s.answers[strings.ToLower(key)]
+	return value, ok
+}
+
+// has сообщает, есть ли в сценарии ответ на вопрос key.
+func (s *ScriptedPrompter) has(key string) bool {
+	_, ok := s.answer(key)
+	return ok
+}
+
+// forget убирает ответ на вопрос key из сценария. FallbackPrompter использует каждый
+// ответ один раз: когда меню модуля показывается в цикле, при следующем проходе вопрос
+// задается пользователю, а не повторяется из сценария.
+func (s *ScriptedPrompter) forget(key string) {
+	delete(s.answers, strings.ToLower(key))
+}
+
+func (s *ScriptedPrompter) Choose(key, title string, options []core.Option) (int, error) {
+	answer, ok := s.answer(key)
+	if !ok {
+		return -1, fmt.Errorf("%w '%s' (%s)", core.ErrNoAnswer, key, title)
+	}
+	idx, ok := matchOption(options, answer)
+	if !ok {
+		return -1, fmt.Errorf("ответ '%s' на вопрос '%s' не соответствует ни одному варианту", answer, key)
+	}
+	InfoF("%s: %s", title, options[idx].Label)
+	return idx, nil
+}
+
+func (s *ScriptedPrompter) MultiChoose(key, title string, options []core.Option) ([]int, error) {
+	answer, ok := s.answer(key)
+	if !ok {
+		return nil, fmt.Errorf("%w '%s' (%s)", core.ErrNoAnswer, key, title)
+	}
+	if strings.EqualFold(answer, "none") {
+		return nil, nil
+	}
+	if strings.EqualFold(answer, "all") {
+		return allIndexes(options), nil
+	}
+
+	var selected []int
+	for _, part := range splitList(answer) {
+		idx, ok := matchOption(options, part)
+		if !ok {
+			return nil, fmt.Errorf("ответ '%s' на вопрос '%s' не соответствует ни одному варианту", part, key)
+		}
+		selected = append(selected, idx)
+		InfoF("%s: %s", title, options[idx].Label)
+	}
+	return selected, nil
+}
+
+// Confirm без ответа в сценарии возвращает ErrNoAnswer, как и остальные вопросы:
+// молча подставленное значение по умолчанию в сценарии легко не заметить.
+// Пустой ответ ("rollback": "") означает значение по умолчанию.
+func (s *ScriptedPrompter) Confirm(key, question string, def bool) (bool, error) {
+	answer, ok := s.answer(key)
+	if !ok {
+		return false, fmt.Errorf("%w '%s' (%s)", core.ErrNoAnswer, key, question)
+	}
+	if answer == "" {
+		return def, nil
+	}
+	value, ok := parseYesNo(answer)
+	if !ok {
+		return false, fmt.Errorf("ответ '%s' на вопрос '%s' должен быть 'yes' или 'no'", answer, key)
+	}
+	return value, nil
+}
+
+func (s *ScriptedPrompter) Input(key, question, def string, validate func(string) error) (string, error) {
+	answer, ok := s.answer(key)
+	if !ok || answer == "" {
+		if def == "" {
+			return "", fmt.Errorf("%w '%s' (%s)", core.ErrNoAnswer, key, question)
+		}
+		answer = def
+	}
+	if validate != nil {
+		if err := validate(answer); err != nil {
+			return "", fmt.Errorf("некорректный ответ '%s' на вопрос '%s': %w", answer, key, err)
+		}
+	}
+	return answer, nil
+}
+
+func (s *ScriptedPrompter) Pause(message string) {}
+
+func (s *ScriptedPrompter) Interactive() bool { return false }
+
+// --- Сценарий с дозапросом ---
+
+// FallbackPrompter берет ответы из сценария, а вопросы без ответа задает пользователю в консоли.
+// Каждый ответ сценария используется один раз (см. ScriptedPrompter.forget).
+type FallbackPrompter struct {
+	scripted    *ScriptedPrompter
+	terminal    *TerminalPrompter
+	interactive bool
+}
+
+// NewFallbackPrompter создает Prompter, который спрашивает пользователя только о том,
+// чего нет в answers. interactive - запуск из меню: пользователь за консолью, поэтому
+// работают паузы и меню модулей в цикле. Для "run -ask-missing" interactive = false.
+func NewFallbackPrompter(answers core.Params, interactive bool) *FallbackPrompter {
+	return &FallbackPrompter{scripted: NewScriptedPrompter(answers), terminal: NewTerminalPrompter(), interactive: interactive}
+}
+
+func (f *FallbackPrompter) Choose(key, title string, options []core.Option) (int, error) {
+	if !f.scripted.has(key) {
+		return f.terminal.Choose(key, title, options)
+	}
+	defer f.scripted.forget(key)
+	return f.scripted.Choose(key, title, options)
+}
+
+func (f *FallbackPrompter) MultiChoose(key, title string, options []core.Option) ([]int, error) {
+	if !f.scripted.has(key) {
+		return f.terminal.MultiChoose(key, title, options)
+	}
+	defer f.scripted.forget(key)
+	return f.scripted.MultiChoose(key, title, options)
+}
+
+func (f *FallbackPrompter) Confirm(key, question string, def bool) (bool, error) {
+	if !f.scripted.has(key) {
+		return f.terminal.Confirm(key, question, def)
+	}
+	defer f.scripted.forget(key)
+	return f.scripted.Confirm(key, question, def)
+}
+
+func (f *FallbackPrompter) Input(key, question, def string, validate func(string) error) (string, error) {
+	if !f.scripted.has(key) {
+		return f.terminal.Input(key, question, def, validate)
+	}
+	defer f.scripted.forget(key)
+	return f.scripted.Input(key, question, def, validate)
+}
+
+func (f *FallbackPrompter) Pause(message string) {
+	if f.interactive {
+		f.terminal.Pause(message)
+	}
+}
+
+// Interactive возвращает true только при запуске из меню. С -ask-missing сценарий
+// выполняется один раз, меню в цикле не показываются.
+func (f *FallbackPrompter) Interactive() bool { return f.interactive }
+
+// --- Вспомогательные функции ---
+
+// matchOption ищет вариант по значению, тексту или номеру (начиная с 1).
+func matchOption(options []core.Option, answer string) (int, bool) {
+	for i, opt := range options {
+		if strings.EqualFold(opt.Value, answer) || strings.EqualFold(opt.Label, answer) {
+			return i, true
+		}
+	}
+	if idx, err := strconv.Atoi(answer); err == nil && idx >= 1 && idx <= len(options) {
+		return idx - 1, true
+	}
+	return -1, false
+}
+
+// splitList разбивает строку вида "1, 3,5" на непустые элементы.
+func splitList(s string) []string {
+	var result []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+func allIndexes(options []core.Option) []int {
+	result := make([]int, len(options))
+	for i := range options {
+		result[i] = i
+	}
+	return result
+}
+
+// parseYesNo распознает ответы "да/нет" на русском и английском.
+func parseYesNo(answer string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes", "true", "1", "д", "да":
+		return true, true
+	case "n", "no", "false", "0", "н", "нет":
+		return false, true
+	}
+	return false, false
+}
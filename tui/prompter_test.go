@@ -0,0 +1,182 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"goMH/core"
+	"strings"
+	"testing"
+)
+
+var testOptions = []core.Option{
+	{Value: "Front", Label: "iiko 900 Front"},
+	{Value: "Office", Label: "iiko 900 Office"},
+	{Value: "Chain", Label: "iiko 900 Chain"},
+}
+
+func TestMatchOption(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   int
+		ok     bool
+	}{
+		{"Front", 0, true},
+		{"office", 1, true},
+		{"IIKO 900 CHAIN", 2, true},
+		{"1", 0, true},
+		{"3", 2, true},
+		{"0", -1, false},
+		{"4", -1, false},
+		{"-1", -1, false},
+		{"Back", -1, false},
+		{"", -1, false},
+	}
+	for _, tt := range tests {
+		got, ok := matchOption(testOptions, tt.answer)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("matchOption(%q) = %d, %v; want %d, %v", tt.answer, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMatchOptionPrefersValueOverNumber(t *testing.T) {
+	options := []core.Option{{Value: "2", Label: "два"}, {Value: "1", Label: "один"}}
+	if got, _ := matchOption(options, "1"); got != 1 {
+		t.Errorf("matchOption(\"1\") = %d, want 1 (совпадение по значению)", got)
+	}
+}
+
+func TestParseYesNo(t *testing.T) {
+	tests := []struct {
+		answer    string
+		value, ok bool
+	}{
+		{"y", true, true},
+		{"YES", true, true},
+		{" true ", true, true},
+		{"1", true, true},
+		{"да", true, true},
+		{"Д", true, true},
+		{"n", false, true},
+		{"No", false, true},
+		{"false", false, true},
+		{"0", false, true},
+		{"нет", false, true},
+		{"Н", false, true},
+		{"", false, false},
+		{"maybe", false, false},
+		{"2", false, false},
+	}
+	for _, tt := range tests {
+		value, ok := parseYesNo(tt.answer)
+		if value != tt.value || ok != tt.ok {
+			t.Errorf("parseYesNo(%q) = %v, %v; want %v, %v", tt.answer, value, ok, tt.value, tt.ok)
+		}
+	}
+}
+
+func TestScriptedPrompterChoose(t *testing.T) {
+	p := NewScriptedPrompter(core.Params{"Component": " office "})
+	idx, err := p.Choose("component", "Компонент", testOptions)
+	if err != nil || idx != 1 {
+		t.Fatalf("Choose = %d, %v; want 1, nil", idx, err)
+	}
+
+	if _, err := p.Choose("version", "Версия", testOptions); !errors.Is(err, core.ErrNoAnswer) {
+		t.Errorf("Choose без ответа: err = %v, want ErrNoAnswer", err)
+	}
+
+	bad := NewScriptedPrompter(core.Params{"component": "Back"})
+	if _, err := bad.Choose("component", "Компонент", testOptions); err == nil || errors.Is(err, core.ErrNoAnswer) {
+		t.Errorf("Choose с неизвестным ответом: err = %v, want ошибку сопоставления", err)
+	}
+}
+
+func TestScriptedPrompterMultiChoose(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   []int
+	}{
+		{"all", []int{0, 1, 2}},
+		{"none", nil},
+		{"Front, 3", []int{0, 2}},
+	}
+	for _, tt := range tests {
+		p := NewScriptedPrompter(core.Params{"patches": tt.answer})
+		got, err := p.MultiChoose("patches", "Патчи", testOptions)
+		if err != nil {
+			t.Fatalf("MultiChoose(%q): %v", tt.answer, err)
+		}
+		if len(got) != len(tt.want) {
+			t.Fatalf("MultiChoose(%q) = %v, want %v", tt.answer, got, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Fatalf("MultiChoose(%q) = %v, want %v", tt.answer, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestScriptedPrompterConfirmAndInput(t *testing.T) {
+	p := NewScriptedPrompter(core.Params{"rollback": "да", "alias": "SRV-01", "port": "abc"})
+	if ok, err := p.Confirm("rollback", "Откатить?", false); err != nil || !ok {
+		t.Errorf("Confirm = %v, %v; want true, nil", ok, err)
+	}
+
+	if _, err := p.Confirm("confirm", "Удалить?", false); !errors.Is(err, core.ErrNoAnswer) {
+		t.Errorf("Confirm без ответа: err = %v, want ErrNoAnswer", err)
+	}
+	empty := NewScriptedPrompter(core.Params{"rollback": ""})
+	if ok, err := empty.Confirm("rollback", "Откатить?", true); err != nil || !ok {
+		t.Errorf("Confirm с пустым ответом = %v, %v; want true (умолчание), nil", ok, err)
+	}
+
+	invalid := NewScriptedPrompter(core.Params{"rollback": "maybe"})
+	if _, err := invalid.Confirm("rollback", "Откатить?", false); err == nil {
+		t.Error("Confirm с ответом 'maybe' должен вернуть ошибку")
+	}
+
+	if v, err := p.Input("alias", "Алиас", "", nil); err != nil || v != "SRV-01" {
+		t.Errorf("Input = %q, %v; want SRV-01, nil", v, err)
+	}
+	if v, err := p.Input("host", "Хост", "localhost", nil); err != nil || v != "localhost" {
+		t.Errorf("Input со значением по умолчанию = %q, %v; want localhost, nil", v, err)
+	}
+	if _, err := p.Input("host", "Хост", "", nil); !errors.Is(err, core.ErrNoAnswer) {
+		t.Errorf("Input без ответа и умолчания: err = %v, want ErrNoAnswer", err)
+	}
+	notNumber := func(s string) error {
+		if s == "abc" {
+			return errors.New("не число")
+		}
+		return nil
+	}
+	if _, err := p.Input("port", "Порт", "", notNumber); err == nil {
+		t.Error("Input должен вернуть ошибку проверки значения")
+	}
+}
+
+func TestFallbackPrompterInteractive(t *testing.T) {
+	if NewFallbackPrompter(core.Params{}, false).Interactive() {
+		t.Error("FallbackPrompter для -ask-missing не должен быть интерактивным")
+	}
+	if !NewFallbackPrompter(core.Params{}, true).Interactive() {
+		t.Error("FallbackPrompter в меню должен быть интерактивным")
+	}
+}
+
+func TestFallbackPrompterUsesAnswerOnce(t *testing.T) {
+	saved := stdin
+	defer func() { stdin = saved }()
+	stdin = bufio.NewReader(strings.NewReader("0\n"))
+
+	p := NewFallbackPrompter(core.Params{"action": "Office"}, true)
+	if idx, err := p.Choose("action", "Действие", testOptions); err != nil || idx != 1 {
+		t.Fatalf("первый Choose = %d, %v; want 1 из сценария", idx, err)
+	}
+	// Повторный вопрос (следующий проход меню) задается в консоли, где пользователь выбирает "Назад"
+	if _, err := p.Choose("action", "Действие", testOptions); !errors.Is(err, core.ErrCancelled) {
+		t.Errorf("второй Choose: err = %v, want ErrCancelled из консоли", err)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"goMH/journal"
+	"goMH/selfupdate"
+	"goMH/tui"
+	"os"
+	"path/filepath"
+)
+
+// version - версия сборки. Задается при сборке: go build -ldflags "-X main.version=1.4.0"
+var version = "dev"
+
+func (a *App) cmdVersion() int {
+	fmt.Printf("goMH %s\n", version)
+	return exitOK
+}
+
+func (a *App) cmdUpdate(args []string) int {
+	params, err := parseParams(args)
+	if err != nil {
+		tui.Error(err.Error())
+		return exitUsage
+	}
+	if a.Cfg.Update.ManifestURL == "" {
+		tui.Error("В конфигурации не задан update.manifest_url: канал обновлений не настроен.")
+		return exitError
+	}
+
+	manifest, err := selfupdate.FetchManifest(a.Cfg.Update.ManifestURL)
+	if err != nil {
+		tui.Error(err.Error())
+		return exitError
+	}
+	if !selfupdate.IsNewer(manifest.Version, version) {
+		tui.SuccessF("Установлена актуальная версия goMH %s.", version)
+		return exitOK
+	}
+	printUpdateAvailable(manifest)
+	if params["check"] != "" {
+		return exitOK
+	}
+
+	ctx, done := a.operationContext()
+	defer done()
+	if _, err := a.installUpdate(ctx, manifest); err != nil {
+		tui.Error(fmt.Sprintf("Обновление не выполнено: %v", err))
+		return exitError
+	}
+	tui.SuccessF("goMH обновлен до версии %s. Новая версия будет использована при следующем запуске.", manifest.Version)
+	return exitOK
+}
+
+// checkForUpdate проверяет канал обновлений при запуске интерактивного меню.
+// Если есть новая сборка и пользователь согласен, она устанавливается и
+// запускается вместо текущей; тогда процесс завершается с кодом новой версии.
+func (a *App) checkForUpdate() {
+	if a.Cfg.Update.ManifestURL == "" || version == "dev" {
+		return
+	}
+	manifest, err := selfupdate.FetchManifest(a.Cfg.Update.ManifestURL)
+	if err != nil {
+		tui.Warn(fmt.Sprintf("Не удалось проверить обновления: %v", err))
+		return
+	}
+	if !selfupdate.IsNewer(manifest.Version, version) {
+		return
+	}
+	printUpdateAvailable(manifest)
+
+	confirmed, err := tui.NewTerminalPrompter().Confirm("update", "Обновить goMH сейчас?", false)
+	if err != nil || !confirmed {
+		return
+	}
+	ctx, done := a.operationContext()
+	exePath, err := a.installUpdate(ctx, manifest)
+	done()
+	if err != nil {
+		tui.Error(fmt.Sprintf("Обновление не выполнено: %v. Работа продолжится в текущей версии.", err))
+		return
+	}
+
+	tui.SuccessF("goMH обновлен до версии %s. Перезапуск...", manifest.Version)
+	a.Close()
+	code, err := selfupdate.Restart(exePath, os.Args[1:])
+	if err != nil {
+		tui.Error(err.Error())
+	}
+	os.Exit(code)
+}
+
+func printUpdateAvailable(m *selfupdate.Manifest) {
+	tui.Warn(fmt.Sprintf("Доступна новая версия goMH: %s (текущая %s).", m.Version, version))
+	if m.Notes != "" {
+		tui.InfoF("Что нового: %s", m.Notes)
+	}
+}
+
+// installUpdate скачивает сборку через менеджер ресурсов, проверяет хеш
+// и заменяет ею текущий исполняемый файл. Возвращает путь к исполняемому файлу.
+func (a *App) installUpdate(ctx context.Context, m *selfupdate.Manifest) (string, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("не удалось определить путь к исполняемому файлу: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exePath); err == nil {
+		exePath = resolved
+	}
+
+	if a.Plan != nil {
+		a.Plan.Add("обновление", "скачать goMH %s с %s и заменить %s", m.Version, m.URL, exePath)
+		return "", fmt.Errorf("в режиме dry-run обновление не применяется")
+	}
+
+	localPath := filepath.Join(a.Cfg.AssetsCachePath, fmt.Sprintf("goMH_%s.exe", m.Version))
+	if _, err := a.AM.DownloadHTTPWithProgress(ctx, m.URL, localPath); err != nil {
+		return "", fmt.Errorf("не удалось скачать новую версию: %w", err)
+	}
+	if err := selfupdate.VerifyFile(localPath, m); err != nil {
+		os.Remove(localPath)
+		return "", err
+	}
+	if err := selfupdate.Apply(exePath, localPath); err != nil {
+		return "", err
+	}
+
+	if a.Journal != nil {
+		a.Journal.Write(journal.Record{
+			Event:   journal.EventSystem,
+			Status:  "ok",
+			Path:    exePath,
+			SHA256:  m.SHA256,
+			Message: fmt.Sprintf("обновление goMH с %s до %s", version, m.Version),
+		})
+	}
+	return exePath, nil
+}
@@ -1,7 +1,9 @@
 package winutils
 
 import (
+	"context"
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -9,6 +11,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -23,17 +26,26 @@ func IsAdmin() bool {
 
 // RunCommand остается без изменений
 func RunCommand(name string, args ...string) (string, error) {
-	cmd := exec.Command(name, args...)
+	return RunCommandContext(context.Background(), name, args...)
+}
+
+// RunCommandContext выполняет команду как RunCommand, но при отмене ctx
+// завершает процесс вместе с дочерними (установщики часто запускают вложенные).
+func RunCommandContext(ctx context.Context, name string, args ...string) (string, error) {
+	cmd := commandContext(ctx, name, args...)
 	cmd.Env = append(os.Environ(), "LANG=en_US.UTF-8")
 	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("выполнение '%s' прервано: %w", name, ctx.Err())
+	}
 	if err != nil {
-		return "", fmt.Errorf("ошибка выполнения '%s %v': %v, вывод: %s", name, args, err, string(output))
+		return "", fmt.Errorf("ошибка выполнения '%s %v': %w, вывод: %s", name, args, err, string(output))
 	}
 	return strings.TrimSpace(string(output)), nil
 }
 
-func RunCommandWithEnv(env map[string]string, name string, args ...string) (string, error) {
-	cmd := exec.Command(name, args...)
+func RunCommandWithEnv(ctx context.Context, env map[string]string, name string, args ...string) (string, error) {
+	cmd := commandContext(ctx, name, args...)
 
 	// Собираем переменные окружения
 	newEnv := os.Environ() // Начинаем с существующих
@@ -43,12 +55,62 @@ func RunCommandWithEnv(env map[string]string, name string, args ...string) (stri
 	cmd.Env = newEnv // Устанавливаем их для команды
 
 	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("выполнение '%s' прервано: %w", name, ctx.Err())
+	}
 	if err != nil {
-		return "", fmt.Errorf("ошибка выполнения '%s %v' с кастомным env: %v, вывод: %s", name, args, err, string(output))
+		return "", fmt.Errorf("ошибка выполнения '%s %v' с кастомным env: %w, вывод: %s", name, args, err, string(output))
 	}
 	return strings.TrimSpace(string(output)), nil
 }
 
+// commandContext создает команду, которая при отмене ctx завершается вместе с деревом процессов.
+func commandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Cancel = func() error {
+		if runtime.GOOS == "windows" {
+			// taskkill /T завершает и процессы, запущенные установщиком
+			if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run(); err == nil {
+				return nil
+			}
+		}
+		return cmd.Process.Kill()
+	}
+	// Не ждем бесконечно вывода от дочерних процессов, переживших родителя
+	cmd.WaitDelay = 5 * time.Second
+	return cmd
+}
+
+// StartProcess запускает приложение без ожидания завершения (например, GUI-программу).
+func StartProcess(executablePath, workingDir string) error {
+	cmd := exec.Command(executablePath)
+	cmd.Dir = workingDir
+	return cmd.Start()
+}
+
+// WriteFile записывает файл, предварительно создав недостающие директории.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("не удалось создать директорию %s: %w", filepath.Dir(path), err)
+	}
+	return os.WriteFile(path, data, perm)
+}
+
+// RemoveAll удаляет файл или директорию со всем содержимым.
+func RemoveAll(path string) error {
+	return os.RemoveAll(path)
+}
+
+// MkdirAll создает директорию вместе с родительскими.
+func MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+// Rename переименовывает (перемещает в пределах диска) файл или директорию.
+func Rename(oldPath, newPath string) error {
+	return os.Rename(oldPath, newPath)
+}
+
 // CreateScheduledTask создает или обновляет задачу в Планировщике Windows через импорт XML.
 func CreateScheduledTask(taskName, executablePath, workingDir string) error {
 	fmt.Printf("Создание/обновление задачи '%s' через XML...\n", taskName)
@@ -267,6 +329,50 @@ func ServiceExists(serviceName string) (bool, error) {
 	return false, fmt.Errorf("не удалось выполнить проверку службы '%s': %w", serviceName, err)
 }
 
+// ServiceRunning проверяет, запущена ли служба. Для несуществующей службы возвращает false без ошибки.
+func ServiceRunning(serviceName string) (bool, error) {
+	exists, err := ServiceExists(serviceName)
+	if err != nil || !exists {
+		return false, err
+	}
+	out, err := RunCommand("sc.exe", "query", serviceName)
+	if err != nil {
+		return false, fmt.Errorf("не удалось получить состояние службы '%s': %w", serviceName, err)
+	}
+	// Строка вида "STATE              : 4  RUNNING" не локализуется
+	return strings.Contains(out, "RUNNING"), nil
+}
+
+// installedProgram - запись о программе из ключей Uninstall реестра.
+type installedProgram struct {
+	DisplayName          string
+	DisplayVersion       string
+	UninstallString      string
+	QuietUninstallString string
+}
+
+// FindInstalledProgram ищет в ключах Uninstall реестра (включая WOW6432Node) программу,
+// имя которой начинается с namePrefix. Если программа не найдена, возвращает nil без ошибки.
+func FindInstalledProgram(namePrefix string) (*installedProgram, error) {
+	script := fmt.Sprintf(
+		"Get-ItemProperty 'HKLM:\\Software\\Microsoft\\Windows\\CurrentVersion\\Uninstall\\*','HKLM:\\Software\\WOW6432Node\\Microsoft\\Windows\\CurrentVersion\\Uninstall\\*' -ErrorAction SilentlyContinue | "+
+			"Where-Object { $_.DisplayName -like '%s*' } | Select-Object -First 1 DisplayName,DisplayVersion,UninstallString,QuietUninstallString | ConvertTo-Json",
+		strings.ReplaceAll(namePrefix, "'", "''"),
+	)
+	out, err := RunCommand("powershell", "-NoProfile", "-Command", script)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать список установленных программ: %w", err)
+	}
+	if strings.TrimSpace(out) == "" {
+		return nil, nil
+	}
+	var program installedProgram
+	if err := json.Unmarshal([]byte(out), &program); err != nil {
+		return nil, fmt.Errorf("не удалось разобрать ответ PowerShell: %w", err)
+	}
+	return &program, nil
+}
+
 // SetServiceTriggers устанавливает триггеры запуска для службы Windows.
 // triggers - это слайс строк, например ["start/machinepolicy", "start/userpolicy"]
 func SetServiceTriggers(serviceName string, triggers []string) error {